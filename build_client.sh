@@ -1,3 +1,4 @@
 #!/bin/bash
 
-go build -o Client client/fuse/main/main.go
+go build -o dfs dfs/main/main.go
+go build -o dfsadmin dfsadmin/main/main.go
@@ -0,0 +1,458 @@
+package client
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"os"
+	"path"
+	utils "project-dfs"
+	"project-dfs/pb"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultTimeout    = 30 * time.Second
+	DefaultRetries    = 3
+	DefaultRetryDelay = 200 * time.Millisecond
+)
+
+// Client of the file system. It keeps a session with the naming server alive
+// and a pool of connections to storage servers shared by the files it opens.
+type Client struct {
+	NamingAddress string
+	User          string
+	Timeout       time.Duration // deadline of a single request
+	Retries       int           // number of times a request failing on every replica is retried
+	RetryDelay    time.Duration // doubled after every retry
+
+	namingConn   *grpc.ClientConn
+	namingClient pb.NamingClient
+
+	sessionMutex sync.Mutex
+	sessionId    uint64
+	done         chan struct{}
+	closeOnce    sync.Once
+
+	connsMutex sync.Mutex
+	conns      map[string]*grpc.ClientConn // key:value = address:connection
+}
+
+// Connects to the naming server and opens a session of the user
+func Dial(namingAddress string, user string) (*Client, error) {
+	conn, err := grpc.Dial(namingAddress, utils.DialOptions()...)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{
+		NamingAddress: namingAddress,
+		User:          user,
+		Timeout:       DefaultTimeout,
+		Retries:       DefaultRetries,
+		RetryDelay:    DefaultRetryDelay,
+		namingConn:    conn,
+		namingClient:  pb.NewNamingClient(conn),
+		done:          make(chan struct{}),
+		conns:         make(map[string]*grpc.ClientConn),
+	}
+
+	timeout, err := client.openSession()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go client.keepAliveLoop(timeout)
+	return client, nil
+}
+
+// Closes the session, which closes files left open, and the connections of the client.
+// Fails with os.ErrClosed if the client has been closed before.
+func (client *Client) Close() error {
+	err := error(&os.PathError{Op: "close", Path: client.NamingAddress, Err: os.ErrClosed})
+	client.closeOnce.Do(func() {
+		err = client.close()
+	})
+	return err
+}
+
+func (client *Client) close() error {
+	close(client.done)
+
+	ctx, cancel := client.context()
+	defer cancel()
+	response, err := client.namingClient.CloseSession(ctx, &pb.CloseSessionRequest{SessionId: client.session()})
+	if err == nil {
+		err = statusError("close", client.NamingAddress, response.ErrorStatus)
+	}
+
+	client.connsMutex.Lock()
+	for address, conn := range client.conns {
+		conn.Close()
+		delete(client.conns, address)
+	}
+	client.connsMutex.Unlock()
+	client.namingConn.Close()
+	return err
+}
+
+func (client *Client) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), client.Timeout)
+}
+
+func (client *Client) session() uint64 {
+	client.sessionMutex.Lock()
+	defer client.sessionMutex.Unlock()
+	return client.sessionId
+}
+
+func (client *Client) openSession() (time.Duration, error) {
+	var response *pb.OpenSessionResponse
+	err := client.retry(func(ctx context.Context) (err error) {
+		response, err = client.namingClient.OpenSession(ctx, &pb.OpenSessionRequest{User: client.User})
+		return err
+	})
+	if err == nil {
+		err = statusError("open session", client.NamingAddress, response.ErrorStatus)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	client.sessionMutex.Lock()
+	client.sessionId = response.SessionId
+	client.sessionMutex.Unlock()
+	return time.Duration(response.TimeoutMillis) * time.Millisecond, nil
+}
+
+// Keeps the session alive until the client is closed. A session that has expired nevertheless
+// is replaced by a new one; files opened in the expired session fail with ESTALE.
+func (client *Client) keepAliveLoop(timeout time.Duration) {
+	for {
+		select {
+		case <-client.done:
+			return
+		case <-time.After(timeout / 3):
+		}
+
+		ctx, cancel := client.context()
+		response, err := client.namingClient.KeepAlive(ctx, &pb.KeepAliveRequest{SessionId: client.session()})
+		cancel()
+		if err != nil {
+			// the naming server is unreachable; the session is kept alive once it is back
+			continue
+		}
+		if response.ErrorStatus.Code == uint32(syscall.ESTALE) {
+			renewed, err := client.openSession()
+			if err != nil {
+				continue
+			}
+			timeout = renewed
+		} else if response.TimeoutMillis > 0 {
+			timeout = time.Duration(response.TimeoutMillis) * time.Millisecond
+		}
+	}
+}
+
+// Returns a client of the storage server, reusing the connection to it
+func (client *Client) storageClient(address string) (pb.StorageClient, error) {
+	client.connsMutex.Lock()
+	defer client.connsMutex.Unlock()
+
+	conn, ok := client.conns[address]
+	if !ok {
+		var err error
+		conn, err = grpc.Dial(address, utils.DialOptions()...)
+		if err != nil {
+			return nil, err
+		}
+		client.conns[address] = conn
+	}
+	return pb.NewStorageClient(conn), nil
+}
+
+// Reports whether the request may succeed if it is sent again, possibly to another server.
+// A request that timed out or was aborted may have been applied nevertheless,
+// so it is sent again only if applying it twice does no harm.
+func isTransient(err error, idempotent bool) bool {
+	switch status.Code(err) {
+	case codes.Unavailable:
+		return true
+	case codes.Aborted, codes.DeadlineExceeded:
+		return idempotent
+	}
+	return false
+}
+
+// Calls the naming server with an idempotent request, retrying while it is unreachable
+func (client *Client) retry(call func(ctx context.Context) error) error {
+	return client.retryCall(true, call)
+}
+
+// Calls the naming server with a request that must not be applied twice,
+// retrying only while the server is unreachable
+func (client *Client) retryUnsent(call func(ctx context.Context) error) error {
+	return client.retryCall(false, call)
+}
+
+func (client *Client) retryCall(idempotent bool, call func(ctx context.Context) error) error {
+	delay := client.RetryDelay
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := client.context()
+		err := call(ctx)
+		cancel()
+		if err == nil || !isTransient(err, idempotent) || attempt == client.Retries {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// Converts the path to the form used by the index, where the root is the empty path
+func cleanPath(name string) string {
+	p := path.Clean("/" + name)
+	if p == "/" {
+		return ""
+	}
+	return p
+}
+
+// Opens the file for reading
+func (client *Client) Open(name string) (*File, error) {
+	return client.OpenFile(name, os.O_RDONLY)
+}
+
+// Creates the file or truncates it if it exists, and opens it for reading and writing
+func (client *Client) Create(name string) (*File, error) {
+	return client.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
+}
+
+// Opens the file with the os.O_* flags
+func (client *Client) OpenFile(name string, flag int) (*File, error) {
+	var response *pb.OpenResponse
+	call := func(ctx context.Context) (err error) {
+		response, err = client.namingClient.Open(ctx, &pb.OpenRequest{
+			SessionId: client.session(),
+			Path:      cleanPath(name),
+			Flags:     openFlags(flag),
+		})
+		return err
+	}
+	var err error
+	if flag&os.O_EXCL != 0 {
+		// an exclusive open sent again would find the file it has created
+		err = client.retryUnsent(call)
+	} else {
+		err = client.retry(call)
+	}
+	if err == nil {
+		err = statusError("open", name, response.ErrorStatus)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &File{
+		client:   client,
+		name:     name,
+		flag:     flag,
+		handle:   response.Handle,
+		fileId:   response.FileId,
+		version:  response.Version,
+		size:     response.Size,
+		replicas: shuffleReplicas(response.StorageInfo),
+	}, nil
+}
+
+// Converts os.O_* flags to the flags of the naming service
+func openFlags(flag int) uint32 {
+	var flags uint32
+	switch flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR) {
+	case os.O_WRONLY:
+		flags = uint32(pb.OpenFlag_O_WRONLY)
+	case os.O_RDWR:
+		flags = uint32(pb.OpenFlag_O_RDWR)
+	}
+	if flag&os.O_CREATE != 0 {
+		flags |= uint32(pb.OpenFlag_O_CREAT)
+	}
+	if flag&os.O_EXCL != 0 {
+		flags |= uint32(pb.OpenFlag_O_EXCL)
+	}
+	if flag&os.O_TRUNC != 0 {
+		flags |= uint32(pb.OpenFlag_O_TRUNC)
+	}
+	return flags
+}
+
+// Returns information about the node at the path
+func (client *Client) Stat(name string) (*FileInfo, error) {
+	p := cleanPath(name)
+	if p == "" {
+		return &FileInfo{node: &pb.Node{Mode: pb.NodeMode_DIRECTORY, Name: "/"}}, nil
+	}
+
+	entries, err := client.ReadDir(path.Dir(p))
+	if err != nil {
+		if pathErr, ok := err.(*os.PathError); ok && pathErr.Err == syscall.ENOENT {
+			pathErr.Op, pathErr.Path = "stat", name
+		}
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Name() == path.Base(p) {
+			return entry, nil
+		}
+	}
+	return nil, &os.PathError{Op: "stat", Path: name, Err: syscall.ENOENT}
+}
+
+// Lists the directory
+func (client *Client) ReadDir(name string) ([]*FileInfo, error) {
+	var response *pb.ListDirectoryResponse
+	err := client.retry(func(ctx context.Context) (err error) {
+		response, err = client.namingClient.ListDirectory(ctx, &pb.ListDirectoryRequest{Path: cleanPath(name)})
+		return err
+	})
+	if err == nil {
+		err = statusError("readdir", name, response.ErrorStatus)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*FileInfo, 0, len(response.Contents))
+	for _, node := range response.Contents {
+		entries = append(entries, &FileInfo{node: node})
+	}
+	return entries, nil
+}
+
+// Creates the directory. Fails if it exists or its parent does not, like os.Mkdir.
+func (client *Client) Mkdir(name string) error {
+	p := cleanPath(name)
+	if _, err := client.Stat(p); err == nil {
+		return &os.PathError{Op: "mkdir", Path: name, Err: syscall.EEXIST}
+	}
+	parent, err := client.Stat(path.Dir(p))
+	if err != nil {
+		if pathErr, ok := err.(*os.PathError); ok {
+			pathErr.Op, pathErr.Path = "mkdir", name
+		}
+		return err
+	}
+	if !parent.IsDir() {
+		return &os.PathError{Op: "mkdir", Path: name, Err: syscall.ENOTDIR}
+	}
+	return client.MkdirAll(name)
+}
+
+// Creates the directory along with any missing parents
+func (client *Client) MkdirAll(name string) error {
+	var response *pb.MakeDirectoryResponse
+	err := client.retry(func(ctx context.Context) (err error) {
+		response, err = client.namingClient.MakeDirectory(ctx, &pb.MakeDirectoryRequest{Path: cleanPath(name), User: client.User})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return statusError("mkdir", name, response.ErrorStatus)
+}
+
+// Creates a symbolic link at the path pointing to the target
+func (client *Client) Symlink(target string, name string) error {
+	var response *pb.SymlinkResponse
+	err := client.retryUnsent(func(ctx context.Context) (err error) {
+		response, err = client.namingClient.Symlink(ctx, &pb.SymlinkRequest{Target: target, Path: cleanPath(name), User: client.User})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return statusError("symlink", name, response.ErrorStatus)
+}
+
+// Returns the target of the symbolic link
+func (client *Client) Readlink(name string) (string, error) {
+	var response *pb.ReadlinkResponse
+	err := client.retry(func(ctx context.Context) (err error) {
+		response, err = client.namingClient.Readlink(ctx, &pb.ReadlinkRequest{Path: cleanPath(name)})
+		return err
+	})
+	if err == nil {
+		err = statusError("readlink", name, response.ErrorStatus)
+	}
+	if err != nil {
+		return "", err
+	}
+	return response.Target, nil
+}
+
+// Renames the node, replacing the target if it exists
+func (client *Client) Rename(oldName string, newName string) error {
+	var response *pb.MoveResponse
+	err := client.retryUnsent(func(ctx context.Context) (err error) {
+		response, err = client.namingClient.Move(ctx, &pb.MoveRequest{Path: cleanPath(oldName), NewPath: cleanPath(newName), User: client.User})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return statusError("rename", oldName, response.ErrorStatus)
+}
+
+// Copies the node along with its contents. Replicas are copied by storage servers, without passing through the client.
+func (client *Client) Copy(oldName string, newName string) error {
+	var response *pb.CopyResponse
+	err := client.retryUnsent(func(ctx context.Context) (err error) {
+		response, err = client.namingClient.Copy(ctx, &pb.CopyRequest{Path: cleanPath(oldName), NewPath: cleanPath(newName), User: client.User})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return statusError("copy", oldName, response.ErrorStatus)
+}
+
+// Removes the file or the empty directory
+func (client *Client) Remove(name string) error {
+	err := client.remove(name, false, false)
+	if pathErr, ok := err.(*os.PathError); ok && pathErr.Err == syscall.EISDIR {
+		err = client.remove(name, true, false)
+	}
+	return err
+}
+
+// Removes the node along with its contents. Succeeds if the node does not exist.
+func (client *Client) RemoveAll(name string) error {
+	err := client.remove(name, true, true)
+	if pathErr, ok := err.(*os.PathError); ok && pathErr.Err == syscall.ENOTDIR {
+		err = client.remove(name, false, false)
+	}
+	if pathErr, ok := err.(*os.PathError); ok && pathErr.Err == syscall.ENOENT {
+		return nil
+	}
+	return err
+}
+
+func (client *Client) remove(name string, directory bool, recursive bool) error {
+	request := &pb.DeleteRequest{Path: cleanPath(name), Recursive: recursive, User: client.User}
+	var response *pb.DeleteResponse
+	err := client.retryUnsent(func(ctx context.Context) (err error) {
+		if directory {
+			response, err = client.namingClient.DeleteDirectory(ctx, request)
+		} else {
+			response, err = client.namingClient.DeleteFile(ctx, request)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return statusError("remove", name, response.ErrorStatus)
+}
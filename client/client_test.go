@@ -0,0 +1,374 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"project-dfs/naming_server"
+	"project-dfs/pb"
+	"project-dfs/storage_server"
+	"sort"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// The test binary doubles as the servers of the test cluster. Every server runs in a process of its own,
+// as storage servers keep replicas in their working directory.
+const serverEnv = "DFS_TEST_SERVER"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(serverEnv) {
+	case "naming":
+		naming_server.Run()
+		os.Exit(0)
+	case "storage":
+		storage_server.Run()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// Naming server and two storage servers listening on the loopback interface
+type testCluster struct {
+	NamingAddress string
+	servers       map[string]*exec.Cmd // key:value = address:process
+}
+
+// Returns a free address on the loopback interface
+func loopbackAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func startCluster(t *testing.T) *testCluster {
+	dir, err := ioutil.TempDir("", "dfs-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster := &testCluster{
+		NamingAddress: loopbackAddress(t),
+		servers:       make(map[string]*exec.Cmd),
+	}
+	t.Cleanup(func() {
+		for _, cmd := range cluster.servers {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+		}
+		_ = os.RemoveAll(dir)
+	})
+
+	cluster.start(t, filepath.Join(dir, "naming"), cluster.NamingAddress, "naming", "SESSION_TIMEOUT=10")
+	for _, alias := range []string{"s1", "s2"} {
+		// storage servers listen once they are registered, so they take files as soon as they are reachable
+		cluster.start(t, filepath.Join(dir, alias), loopbackAddress(t), "storage",
+			"ALIAS="+alias,
+			"PUBLIC_HOSTNAME=127.0.0.1",
+			"NAMING_SERVER_ADDRESS="+cluster.NamingAddress,
+			"HEARTBEAT_INTERVAL=1")
+	}
+	return cluster
+}
+
+// Starts the server in the directory and waits until it accepts connections
+func (cluster *testCluster) start(t *testing.T, dir string, address string, server string, env ...string) {
+	err := os.MkdirAll(dir, 0777)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(os.Args[0])
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), append(env, serverEnv+"="+server, "ADDRESS="+address)...)
+	err = cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster.servers[address] = cmd
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", address)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s server at %s did not start: %v", server, address, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Kills the server listening on the address
+func (cluster *testCluster) stop(address string) {
+	cmd := cluster.servers[address]
+	_ = cmd.Process.Kill()
+	_ = cmd.Wait()
+	delete(cluster.servers, address)
+}
+
+func dialCluster(t *testing.T, cluster *testCluster) *Client {
+	client, err := Dial(cluster.NamingAddress, "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.RetryDelay = 10 * time.Millisecond
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func writeFile(t *testing.T, client *Client, name string, data string) {
+	file, err := client.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = file.WriteString(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, client *Client, name string) string {
+	file, err := client.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestFileOperations(t *testing.T) {
+	client := dialCluster(t, startCluster(t))
+
+	err := client.MkdirAll("/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := client.Create("/docs/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = file.WriteString("hello world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = file.WriteAt([]byte("WORLD"), 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offset, err := file.Seek(-5, io.SeekEnd)
+	if err != nil || offset != 6 {
+		t.Fatalf("Seek = %d, %v; want 6", offset, err)
+	}
+	buf := make([]byte, 5)
+	n, err := file.Read(buf)
+	if err != nil || string(buf[:n]) != "WORLD" {
+		t.Fatalf("Read = %q, %v; want WORLD", buf[:n], err)
+	}
+	n, err = file.ReadAt(buf, 0)
+	if err != nil || string(buf[:n]) != "hello" {
+		t.Fatalf("ReadAt = %q, %v; want hello", buf[:n], err)
+	}
+	n, err = file.ReadAt(buf, 8)
+	if err != io.EOF || string(buf[:n]) != "RLD" {
+		t.Fatalf("ReadAt past the end = %q, %v; want RLD, EOF", buf[:n], err)
+	}
+
+	info, err := file.Stat()
+	if err != nil || info.Size() != 11 || info.IsDir() {
+		t.Fatalf("Stat = %+v, %v; want file of 11 bytes", info, err)
+	}
+	err = file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := readFile(t, client, "/docs/a.txt"); data != "hello WORLD" {
+		t.Fatalf("contents = %q; want hello WORLD", data)
+	}
+
+	writeFile(t, client, "/docs/b.txt", "second")
+	entries, err := client.ReadDir("/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	if len(names) != 2 || names[0] != "a.txt" || names[1] != "b.txt" {
+		t.Fatalf("ReadDir = %v; want [a.txt b.txt]", names)
+	}
+
+	err = client.Rename("/docs/a.txt", "/docs/c.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.Stat("/docs/a.txt"); !os.IsNotExist(err) {
+		t.Fatalf("Stat of renamed file = %v; want not exist", err)
+	}
+	if data := readFile(t, client, "/docs/c.txt"); data != "hello WORLD" {
+		t.Fatalf("contents after rename = %q; want hello WORLD", data)
+	}
+
+	err = client.Remove("/docs/c.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.Open("/docs/c.txt"); !os.IsNotExist(err) {
+		t.Fatalf("Open of removed file = %v; want not exist", err)
+	}
+	err = client.Remove("/docs")
+	if err == nil {
+		t.Fatal("Remove of a directory with files succeeded")
+	}
+}
+
+func TestOpenExclusive(t *testing.T) {
+	client := dialCluster(t, startCluster(t))
+
+	file, err := client.OpenFile("/new", os.O_RDWR|os.O_CREATE|os.O_EXCL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	_, err = client.OpenFile("/new", os.O_RDWR|os.O_CREATE|os.O_EXCL)
+	if !os.IsExist(err) {
+		t.Fatalf("second exclusive open = %v; want exists", err)
+	}
+}
+
+func TestReadFailover(t *testing.T) {
+	cluster := startCluster(t)
+	client := dialCluster(t, cluster)
+
+	writeFile(t, client, "/replicated", "contents kept on both storage servers")
+	file, err := client.Open("/replicated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if len(file.replicas) != 2 {
+		t.Fatalf("file has %d replicas; want 2", len(file.replicas))
+	}
+
+	// the replica that served the first read is gone before the second one
+	buf := make([]byte, 8)
+	_, err = file.ReadAt(buf, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := file.replicas[file.preferred].PublicAddress
+	cluster.stop(served)
+
+	n, err := file.ReadAt(buf, 9)
+	if err != nil || string(buf[:n]) != "kept on " {
+		t.Fatalf("ReadAt after failover = %q, %v; want \"kept on \"", buf[:n], err)
+	}
+	if file.replicas[file.preferred].PublicAddress == served {
+		t.Fatal("read was served by the stopped storage server")
+	}
+}
+
+func TestStorageErrorIsIOError(t *testing.T) {
+	err := storageError("read", "/f", &pb.ErrorStatus{Code: 1, Description: "input/output error"})
+	var storageErr *StorageError
+	if !errors.As(err, &storageErr) || !errors.Is(err, syscall.EIO) {
+		t.Fatalf("storage failure %#v is not an I/O error", err)
+	}
+	err = storageError("read", "/f", &pb.ErrorStatus{Code: uint32(syscall.ENOENT), Description: "no such file"})
+	if !errors.Is(err, syscall.ENOENT) {
+		t.Fatalf("storage status %#v lost its errno", err)
+	}
+}
+
+func TestWriteReadOnly(t *testing.T) {
+	client := dialCluster(t, startCluster(t))
+
+	writeFile(t, client, "/ro", "original")
+	file, err := client.Open("/ro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = file.WriteAt([]byte("changed"), 0)
+	if !errors.Is(err, syscall.EBADF) {
+		t.Fatalf("WriteAt on a read-only file = %v; want EBADF", err)
+	}
+	_, err = file.WriteString("changed")
+	if !errors.Is(err, syscall.EBADF) {
+		t.Fatalf("Write on a read-only file = %v; want EBADF", err)
+	}
+	err = file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := readFile(t, client, "/ro"); data != "original" {
+		t.Fatalf("contents = %q; want original", data)
+	}
+}
+
+func TestOpenFileFollowsRename(t *testing.T) {
+	client := dialCluster(t, startCluster(t))
+
+	writeFile(t, client, "/a", "first file")
+	file, err := client.OpenFile("/a", os.O_RDWR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	// the path of the open file is taken over by another one
+	err = client.Rename("/a", "/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, client, "/a", "second file")
+
+	err = file.Truncate(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := file.Stat()
+	if err != nil || info.Size() != 5 {
+		t.Fatalf("Stat after Truncate = %+v, %v; want size 5", info, err)
+	}
+	if data := readFile(t, client, "/b"); data != "first" {
+		t.Fatalf("contents of the renamed file = %q; want first", data)
+	}
+	if data := readFile(t, client, "/a"); data != "second file" {
+		t.Fatalf("contents of the file replacing it = %q; want second file", data)
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	cluster := startCluster(t)
+	client, err := Dial(cluster.NamingAddress, "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Close()
+	if !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("second Close = %v; want os.ErrClosed", err)
+	}
+}
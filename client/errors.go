@@ -0,0 +1,41 @@
+package client
+
+import (
+	"os"
+	"project-dfs/pb"
+	"syscall"
+)
+
+// Converts the status returned by the naming server to an error. Its codes are errno values.
+func statusError(op string, name string, status *pb.ErrorStatus) error {
+	if status == nil || status.Code == 0 {
+		return nil
+	}
+	return &os.PathError{Op: op, Path: name, Err: syscall.Errno(status.Code)}
+}
+
+// Converts the status returned by a storage server to an error.
+// Storage servers report failures of their own file operations with code 1 and the message of the failure.
+func storageError(op string, name string, status *pb.ErrorStatus) error {
+	if status == nil || status.Code == 0 {
+		return nil
+	}
+	if status.Code == 1 {
+		return &os.PathError{Op: op, Path: name, Err: &StorageError{Description: status.Description}}
+	}
+	return &os.PathError{Op: op, Path: name, Err: syscall.Errno(status.Code)}
+}
+
+// StorageError is a failure of a file operation of a storage server, described by its message.
+// It is an I/O error, so errors.Is(err, syscall.EIO) holds for it.
+type StorageError struct {
+	Description string
+}
+
+func (err *StorageError) Error() string {
+	return err.Description
+}
+
+func (err *StorageError) Unwrap() error {
+	return syscall.EIO
+}
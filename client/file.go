@@ -0,0 +1,454 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path"
+	"project-dfs/pb"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Size of the chunks files are transferred in; requests stay well below the message limit of gRPC
+const ChunkSize = 1 << 20
+
+var (
+	// Replica that cannot serve the read, e.g. because it has not yet applied writes committed before the file was opened
+	errStaleReplica = errors.New("replica is stale")
+	errNoReplicas   = errors.New("no replica of the file is available")
+)
+
+// File opened through the client. Its methods follow the ones of os.File.
+type File struct {
+	client  *Client
+	name    string
+	flag    int
+	handle  uint64
+	fileId  uint64
+	version uint64 // replicas older than the version the file was opened at are not read from
+
+	mutex     sync.Mutex
+	offset    int64
+	size      int64
+	replicas  []*pb.DiscoveredStorage
+	preferred int // replica that served the last request
+	closed    bool
+}
+
+// Spreads clients of a file over its replicas
+func shuffleReplicas(replicas []*pb.DiscoveredStorage) []*pb.DiscoveredStorage {
+	rand.Shuffle(len(replicas), func(i, j int) {
+		replicas[i], replicas[j] = replicas[j], replicas[i]
+	})
+	return replicas
+}
+
+func (file *File) Name() string {
+	return file.name
+}
+
+// Asks the naming server for the current replicas of the file
+func (file *File) discover() error {
+	var response *pb.DiscoverResponse
+	err := file.client.retry(func(ctx context.Context) (err error) {
+		response, err = file.client.namingClient.Discover(ctx, &pb.DiscoverRequest{FileId: file.fileId})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	file.mutex.Lock()
+	file.replicas = shuffleReplicas(response.StorageInfo)
+	file.preferred = 0
+	file.mutex.Unlock()
+	return nil
+}
+
+// Sends the request to the replicas of the file, starting with the one that served the last request,
+// until one of them serves it. Once every replica has failed, the replicas are discovered anew and
+// the request is retried. Statuses of storage servers are returned to the caller, which decides
+// whether the request fails or is passed to the next replica by returning errStaleReplica.
+// A request that is not idempotent is sent again only if it has not reached the replica.
+func (file *File) onReplica(idempotent bool, call func(ctx context.Context, ss pb.StorageClient) error) error {
+	client := file.client
+	delay := client.RetryDelay
+	var lastErr error
+	for attempt := 0; attempt <= client.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+			err := file.discover()
+			if err != nil {
+				return err
+			}
+		}
+
+		file.mutex.Lock()
+		replicas := file.replicas
+		preferred := file.preferred
+		file.mutex.Unlock()
+		if len(replicas) == 0 {
+			lastErr = &os.PathError{Op: "discover", Path: file.name, Err: errNoReplicas}
+			continue
+		}
+
+		for i := range replicas {
+			index := (preferred + i) % len(replicas)
+			ss, err := client.storageClient(replicas[index].PublicAddress)
+			if err == nil {
+				ctx, cancel := client.context()
+				err = call(ctx, ss)
+				cancel()
+			}
+			if err == nil {
+				file.mutex.Lock()
+				file.preferred = index
+				file.mutex.Unlock()
+				return nil
+			}
+			if err != errStaleReplica && !isTransient(err, idempotent) {
+				return err
+			}
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (file *File) checkOpen(op string) error {
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+	if file.closed {
+		return &os.PathError{Op: op, Path: file.name, Err: os.ErrClosed}
+	}
+	return nil
+}
+
+// Fails like os.File does if the file is not open for writing. Storage servers do not check handles,
+// and only writable handles commit what is written through them.
+func (file *File) checkWritable(op string, errno syscall.Errno) error {
+	err := file.checkOpen(op)
+	if err == nil && file.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		err = &os.PathError{Op: op, Path: file.name, Err: errno}
+	}
+	return err
+}
+
+// Reads len(p) bytes from the offset. Returns io.EOF if fewer bytes are read.
+func (file *File) ReadAt(p []byte, offset int64) (int, error) {
+	err := file.checkOpen("read")
+	if err != nil {
+		return 0, err
+	}
+	if offset < 0 {
+		return 0, &os.PathError{Op: "readat", Path: file.name, Err: errors.New("negative offset")}
+	}
+
+	n := 0
+	for n < len(p) {
+		count := len(p) - n
+		if count > ChunkSize {
+			count = ChunkSize
+		}
+
+		var result *pb.ReadFileResult
+		err = file.onReplica(true, func(ctx context.Context, ss pb.StorageClient) (err error) {
+			result, err = ss.ReadFile(ctx, &pb.ReadFileArgs{
+				FileId: file.fileId,
+				Offset: offset + int64(n),
+				Count:  int64(count),
+			})
+			if err != nil {
+				return err
+			}
+			if result.ErrorStatus.Code != 0 || result.Version < file.version {
+				// another replica may hold the file
+				return errStaleReplica
+			}
+			return nil
+		})
+		if err == errStaleReplica {
+			err = storageError("read", file.name, result.ErrorStatus)
+			if err == nil {
+				err = &os.PathError{Op: "read", Path: file.name, Err: syscall.ESTALE}
+			}
+			return n, err
+		}
+		if err != nil {
+			return n, err
+		}
+
+		copy(p[n:], result.Buffer)
+		n += int(result.Count)
+		if int(result.Count) < count {
+			return n, io.EOF
+		}
+	}
+	return n, nil
+}
+
+func (file *File) Read(p []byte) (int, error) {
+	file.mutex.Lock()
+	offset := file.offset
+	file.mutex.Unlock()
+
+	n, err := file.ReadAt(p, offset)
+	file.mutex.Lock()
+	file.offset += int64(n)
+	file.mutex.Unlock()
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// Writes p at the offset. Writes are ordered by the primary replica, which passes them on to the other ones.
+func (file *File) WriteAt(p []byte, offset int64) (int, error) {
+	err := file.checkWritable("write", syscall.EBADF)
+	if err != nil {
+		return 0, err
+	}
+	if file.flag&os.O_APPEND != 0 {
+		return 0, errors.New("client: WriteAt with O_APPEND flag")
+	}
+
+	n := 0
+	for n < len(p) {
+		chunk := p[n:]
+		if len(chunk) > ChunkSize {
+			chunk = chunk[:ChunkSize]
+		}
+
+		var result *pb.WriteFileResult
+		err = file.onReplica(true, func(ctx context.Context, ss pb.StorageClient) (err error) {
+			result, err = ss.WriteFile(ctx, &pb.WriteFileArgs{
+				FileId: file.fileId,
+				Offset: offset + int64(n),
+				Buffer: chunk,
+			})
+			return err
+		})
+		if err == nil {
+			err = storageError("write", file.name, result.ErrorStatus)
+		}
+		if err != nil {
+			return n, err
+		}
+		n += len(chunk)
+
+		file.mutex.Lock()
+		if offset+int64(n) > file.size {
+			file.size = offset + int64(n)
+		}
+		file.mutex.Unlock()
+	}
+	return n, nil
+}
+
+// Writes p at the current offset, or at the end of the file if it was opened with os.O_APPEND
+func (file *File) Write(p []byte) (int, error) {
+	if file.flag&os.O_APPEND != 0 {
+		return file.append(p)
+	}
+
+	file.mutex.Lock()
+	offset := file.offset
+	file.mutex.Unlock()
+
+	n, err := file.WriteAt(p, offset)
+	file.mutex.Lock()
+	file.offset += int64(n)
+	file.mutex.Unlock()
+	return n, err
+}
+
+// Appends records to the end of the file. The primary replica picks their offsets,
+// so appends of other clients never overlap with them.
+func (file *File) append(p []byte) (int, error) {
+	err := file.checkWritable("write", syscall.EBADF)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for n < len(p) {
+		chunk := p[n:]
+		if len(chunk) > ChunkSize {
+			chunk = chunk[:ChunkSize]
+		}
+
+		var result *pb.AppendResult
+		// a record appended twice would appear twice in the file
+		err = file.onReplica(false, func(ctx context.Context, ss pb.StorageClient) (err error) {
+			result, err = ss.Append(ctx, &pb.AppendArgs{FileId: file.fileId, Buffer: chunk})
+			return err
+		})
+		if err == nil {
+			err = storageError("write", file.name, result.ErrorStatus)
+		}
+		if err != nil {
+			return n, err
+		}
+		n += len(chunk)
+
+		file.mutex.Lock()
+		file.offset = result.Offset + int64(len(chunk))
+		if file.offset > file.size {
+			file.size = file.offset
+		}
+		file.mutex.Unlock()
+	}
+	return n, nil
+}
+
+func (file *File) WriteString(s string) (int, error) {
+	return file.Write([]byte(s))
+}
+
+// Sets the offset for the next Read or Write. The end of the file is the size known to the client.
+func (file *File) Seek(offset int64, whence int) (int64, error) {
+	err := file.checkOpen("seek")
+	if err != nil {
+		return 0, err
+	}
+
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+	switch whence {
+	case io.SeekCurrent:
+		offset += file.offset
+	case io.SeekEnd:
+		offset += file.size
+	}
+	if offset < 0 {
+		return 0, &os.PathError{Op: "seek", Path: file.name, Err: errors.New("negative offset")}
+	}
+	file.offset = offset
+	return offset, nil
+}
+
+// Describes the open file, which is looked up by ID, so it is the same file even if its path has been
+// renamed or replaced since it was opened
+func (file *File) Stat() (*FileInfo, error) {
+	err := file.checkOpen("stat")
+	if err != nil {
+		return nil, err
+	}
+	var response *pb.DiscoverResponse
+	err = file.client.retry(func(ctx context.Context) (err error) {
+		response, err = file.client.namingClient.Discover(ctx, &pb.DiscoverRequest{FileId: file.fileId})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if response.FileId != file.fileId {
+		return nil, &os.PathError{Op: "stat", Path: file.name, Err: syscall.ENOENT}
+	}
+	info := &FileInfo{node: &pb.Node{
+		Mode:   pb.NodeMode_REGULAR_FILE,
+		Name:   path.Base(file.name),
+		Size:   response.Size,
+		Links:  response.Links,
+		FileId: response.FileId,
+	}}
+
+	// writes of the client may not have been committed to the index yet
+	file.mutex.Lock()
+	if file.size > info.node.Size {
+		info.node.Size = file.size
+	}
+	file.mutex.Unlock()
+	return info, nil
+}
+
+// Truncates the open file by ID, so it is the same file even if its path has been renamed or replaced
+func (file *File) Truncate(size int64) error {
+	err := file.checkWritable("truncate", syscall.EINVAL)
+	if err != nil {
+		return err
+	}
+
+	var response *pb.TruncateResponse
+	err = file.client.retry(func(ctx context.Context) (err error) {
+		response, err = file.client.namingClient.Truncate(ctx, &pb.TruncateRequest{FileId: file.fileId, Size: size})
+		return err
+	})
+	if err == nil {
+		err = statusError("truncate", file.name, response.ErrorStatus)
+	}
+	if err != nil {
+		return err
+	}
+
+	file.mutex.Lock()
+	file.size = size
+	file.mutex.Unlock()
+	return nil
+}
+
+// Closes the handle, which commits the contents written through it as a new version of the file
+func (file *File) Close() error {
+	file.mutex.Lock()
+	if file.closed {
+		file.mutex.Unlock()
+		return &os.PathError{Op: "close", Path: file.name, Err: os.ErrClosed}
+	}
+	file.closed = true
+	file.mutex.Unlock()
+
+	var response *pb.CloseResponse
+	err := file.client.retryUnsent(func(ctx context.Context) (err error) {
+		response, err = file.client.namingClient.Close(ctx, &pb.CloseRequest{
+			SessionId: file.client.session(),
+			Handle:    file.handle,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return statusError("close", file.name, response.ErrorStatus)
+}
+
+// FileInfo describes a node of the file system and implements os.FileInfo
+type FileInfo struct {
+	node *pb.Node
+}
+
+func (info *FileInfo) Name() string {
+	return path.Base(info.node.Name)
+}
+
+func (info *FileInfo) Size() int64 {
+	return info.node.Size
+}
+
+func (info *FileInfo) Mode() os.FileMode {
+	switch info.node.Mode {
+	case pb.NodeMode_DIRECTORY:
+		return os.ModeDir | 0755
+	case pb.NodeMode_SYMLINK:
+		return os.ModeSymlink | 0777
+	}
+	return 0644
+}
+
+// The index does not keep modification times
+func (info *FileInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (info *FileInfo) IsDir() bool {
+	return info.node.Mode == pb.NodeMode_DIRECTORY
+}
+
+// Returns the *pb.Node listed by the naming server
+func (info *FileInfo) Sys() interface{} {
+	return info.node
+}
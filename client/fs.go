@@ -0,0 +1,315 @@
+//go:build go1.16
+// +build go1.16
+
+package client
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"project-dfs/pb"
+	"sort"
+	"syscall"
+)
+
+// Number of symbolic links followed when opening a file, like MAXSYMLINKS of Linux
+const maxSymlinks = 40
+
+// FS exposes the file system through the interfaces of io/fs, so that it can be passed to code
+// accepting fs.FS. Names are slash-separated paths relative to the root of the cluster, without
+// a leading slash, as io/fs requires. Writable methods follow the ones of the os package.
+type FS struct {
+	client *Client
+}
+
+// Returns the file system of the client in the form of fs.FS
+func (client *Client) FS() *FS {
+	return &FS{client: client}
+}
+
+var (
+	_ fs.FS         = (*FS)(nil)
+	_ fs.ReadDirFS  = (*FS)(nil)
+	_ fs.StatFS     = (*FS)(nil)
+	_ fs.ReadFileFS = (*FS)(nil)
+)
+
+// Converts a name of io/fs to a path of the client
+func (fsys *FS) path(op string, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return "/" + name, nil
+}
+
+// Reports the error under the name of io/fs the caller used
+func fsError(op string, name string, err error) error {
+	if pathErr, ok := err.(*fs.PathError); ok {
+		return &fs.PathError{Op: op, Path: name, Err: pathErr.Err}
+	}
+	return err
+}
+
+// Resolves symbolic links at the end of the path. Links in the middle of the path are not followed.
+func (fsys *FS) resolve(p string) (string, *FileInfo, error) {
+	for i := 0; i < maxSymlinks; i++ {
+		info, err := fsys.client.Stat(p)
+		if err != nil {
+			return "", nil, err
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			return p, info, nil
+		}
+
+		target, err := fsys.client.Readlink(p)
+		if err != nil {
+			return "", nil, err
+		}
+		if !path.IsAbs(target) {
+			target = path.Join(path.Dir(p), target)
+		}
+		p = target
+	}
+	return "", nil, &fs.PathError{Op: "open", Path: p, Err: syscall.ELOOP}
+}
+
+// Opens the file or directory for reading. Directories implement fs.ReadDirFile.
+func (fsys *FS) Open(name string) (fs.File, error) {
+	p, err := fsys.path("open", name)
+	if err != nil {
+		return nil, err
+	}
+	p, info, err := fsys.resolve(p)
+	if err != nil {
+		return nil, fsError("open", name, err)
+	}
+	if info.IsDir() {
+		return &dirFile{fsys: fsys, name: name, path: p, info: renamed(info, name)}, nil
+	}
+
+	file, err := fsys.client.Open(p)
+	if err != nil {
+		return nil, fsError("open", name, err)
+	}
+	return &fsFile{File: file, name: name}, nil
+}
+
+// Returns the entries of the directory sorted by name
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := fsys.path("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	p, _, err = fsys.resolve(p)
+	if err != nil {
+		return nil, fsError("readdir", name, err)
+	}
+	entries, err := fsys.readDir(p)
+	if err != nil {
+		return nil, fsError("readdir", name, err)
+	}
+	return entries, nil
+}
+
+// Lists the directory at the path of the client, sorted by name
+func (fsys *FS) readDir(p string) ([]fs.DirEntry, error) {
+	infos, err := fsys.client.ReadDir(p)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]fs.DirEntry, 0, len(infos))
+	for _, info := range infos {
+		entries = append(entries, info)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// Returns information about the node, following symbolic links
+func (fsys *FS) Stat(name string) (fs.FileInfo, error) {
+	p, err := fsys.path("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	_, info, err := fsys.resolve(p)
+	if err != nil {
+		return nil, fsError("stat", name, err)
+	}
+	return renamed(info, name), nil
+}
+
+func (fsys *FS) ReadFile(name string) ([]byte, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fsError("read", name, err)
+	}
+	return data, nil
+}
+
+// Creates the file or truncates it, and opens it for reading and writing
+func (fsys *FS) Create(name string) (*File, error) {
+	return fsys.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// Opens the file with the os.O_* flags. The index keeps no permissions, so perm is ignored.
+func (fsys *FS) OpenFile(name string, flag int, perm fs.FileMode) (*File, error) {
+	p, err := fsys.path("open", name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := fsys.client.OpenFile(p, flag)
+	if err != nil {
+		return nil, fsError("open", name, err)
+	}
+	return file, nil
+}
+
+func (fsys *FS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	file, err := fsys.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return fsError("write", name, err)
+}
+
+func (fsys *FS) Mkdir(name string, perm fs.FileMode) error {
+	p, err := fsys.path("mkdir", name)
+	if err != nil {
+		return err
+	}
+	return fsError("mkdir", name, fsys.client.Mkdir(p))
+}
+
+func (fsys *FS) MkdirAll(name string, perm fs.FileMode) error {
+	p, err := fsys.path("mkdir", name)
+	if err != nil {
+		return err
+	}
+	return fsError("mkdir", name, fsys.client.MkdirAll(p))
+}
+
+func (fsys *FS) Remove(name string) error {
+	p, err := fsys.path("remove", name)
+	if err != nil {
+		return err
+	}
+	return fsError("remove", name, fsys.client.Remove(p))
+}
+
+func (fsys *FS) RemoveAll(name string) error {
+	p, err := fsys.path("remove", name)
+	if err != nil {
+		return err
+	}
+	return fsError("remove", name, fsys.client.RemoveAll(p))
+}
+
+func (fsys *FS) Rename(oldName string, newName string) error {
+	oldPath, err := fsys.path("rename", oldName)
+	if err != nil {
+		return err
+	}
+	newPath, err := fsys.path("rename", newName)
+	if err != nil {
+		return err
+	}
+	return fsError("rename", oldName, fsys.client.Rename(oldPath, newPath))
+}
+
+// Along with Name and IsDir, Type and Info make FileInfo an fs.DirEntry
+func (info *FileInfo) Type() fs.FileMode {
+	return info.Mode().Type()
+}
+
+func (info *FileInfo) Info() (fs.FileInfo, error) {
+	return info, nil
+}
+
+// Returns the information under the base of the name of io/fs, so that the root is "."
+func renamed(info *FileInfo, name string) *FileInfo {
+	return &FileInfo{node: &pb.Node{
+		Mode:   info.node.Mode,
+		Name:   path.Base(name),
+		Size:   info.node.Size,
+		Links:  info.node.Links,
+		Xattrs: info.node.Xattrs,
+		FileId: info.node.FileId,
+	}}
+}
+
+// Regular file opened through FS
+type fsFile struct {
+	*File
+	name string
+}
+
+func (file *fsFile) Stat() (fs.FileInfo, error) {
+	info, err := file.File.Stat()
+	if err != nil {
+		return nil, fsError("stat", file.name, err)
+	}
+	return renamed(info, file.name), nil
+}
+
+// Directory opened through FS. Its entries are listed on the first call to ReadDir.
+type dirFile struct {
+	fsys    *FS
+	name    string
+	path    string
+	info    *FileInfo
+	entries []fs.DirEntry
+	listed  bool
+	offset  int
+}
+
+func (dir *dirFile) Stat() (fs.FileInfo, error) {
+	return dir.info, nil
+}
+
+func (dir *dirFile) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: dir.name, Err: syscall.EISDIR}
+}
+
+func (dir *dirFile) Close() error {
+	return nil
+}
+
+// Returns the next n entries of the directory in the order of FS.ReadDir, or all remaining ones if n <= 0,
+// like os.File.ReadDir
+func (dir *dirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !dir.listed {
+		entries, err := dir.fsys.readDir(dir.path)
+		if err != nil {
+			return nil, fsError("readdir", dir.name, err)
+		}
+		dir.entries = entries
+		dir.listed = true
+	}
+
+	remaining := dir.entries[dir.offset:]
+	if n <= 0 {
+		dir.offset = len(dir.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	dir.offset += n
+	return remaining[:n], nil
+}
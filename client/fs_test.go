@@ -0,0 +1,46 @@
+//go:build go1.16
+// +build go1.16
+
+package client
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFS(t *testing.T) {
+	client := dialCluster(t, startCluster(t))
+
+	// names are written out of order, so that listings have to be sorted
+	for _, name := range []string{"/docs/b.txt", "/docs/a.txt", "/docs/sub/c.txt", "/top.txt"} {
+		writeFile(t, client, name, "contents of "+name)
+	}
+	err := client.MkdirAll("/empty")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = fstest.TestFS(client.FS(), "docs/a.txt", "docs/b.txt", "docs/sub/c.txt", "top.txt", "empty")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fstest sorts the entries read from a directory file before comparing them, so their order is checked here
+	dir, err := client.FS().Open("docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dir.Close()
+	entries, err := dir.(fs.ReadDirFile).ReadDir(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if len(names) != 3 || names[0] != "a.txt" || names[1] != "b.txt" || names[2] != "sub" {
+		t.Fatalf("ReadDir of the directory file = %v; want [a.txt b.txt sub]", names)
+	}
+}
@@ -0,0 +1,464 @@
+package dfs
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"project-dfs/client"
+)
+
+func (cli *CLI) ls(args []string) error {
+	paths, err := parseFlags(flag.NewFlagSet("ls", flag.ContinueOnError), args, 0, -1)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		paths = []string{"/"}
+	}
+
+	var entries []*Entry
+	for i, p := range paths {
+		info, err := cli.Client.Stat(p)
+		if err != nil {
+			cli.fail(p, err)
+			continue
+		}
+		if !info.IsDir() {
+			entries = append(entries, newEntry(p, info))
+			if !cli.JSON {
+				fmt.Fprintln(cli.Stdout, newEntry(p, info))
+			}
+			continue
+		}
+
+		infos, err := cli.Client.ReadDir(p)
+		if err != nil {
+			cli.fail(p, err)
+			continue
+		}
+		sortInfos(infos)
+		if !cli.JSON && len(paths) > 1 {
+			if i > 0 {
+				fmt.Fprintln(cli.Stdout)
+			}
+			fmt.Fprintln(cli.Stdout, p+":")
+		}
+		for _, child := range infos {
+			entry := newEntry(path.Join(p, child.Name()), child)
+			entries = append(entries, entry)
+			if !cli.JSON {
+				fmt.Fprintln(cli.Stdout, entry)
+			}
+		}
+	}
+
+	if cli.JSON {
+		if entries == nil {
+			entries = []*Entry{}
+		}
+		cli.printJSON(entries)
+	}
+	return nil
+}
+
+func (cli *CLI) stat(args []string) error {
+	paths, err := parseFlags(flag.NewFlagSet("stat", flag.ContinueOnError), args, 1, -1)
+	if err != nil {
+		return err
+	}
+
+	entries := []*Entry{}
+	for _, p := range paths {
+		info, err := cli.Client.Stat(p)
+		if err != nil {
+			cli.fail(p, err)
+			continue
+		}
+		entry := newEntry(p, info)
+		entries = append(entries, entry)
+		if !cli.JSON {
+			fmt.Fprintf(cli.Stdout, "Path: %s\nType: %s\nSize: %d\nLinks: %d\nFile ID: %d\n",
+				entry.Path, entry.Type, entry.Size, entry.Links, entry.FileId)
+		}
+	}
+
+	if cli.JSON {
+		cli.printJSON(entries)
+	}
+	return nil
+}
+
+// Result of a command creating or removing nodes
+type change struct {
+	Path        string `json:"path"`
+	Destination string `json:"destination,omitempty"`
+	Size        *int64 `json:"size,omitempty"`
+}
+
+func (cli *CLI) printChanges(changes []*change) {
+	if cli.JSON {
+		if changes == nil {
+			changes = []*change{}
+		}
+		cli.printJSON(changes)
+	}
+}
+
+func (cli *CLI) mkdir(args []string) error {
+	flags := flag.NewFlagSet("mkdir", flag.ContinueOnError)
+	parents := flags.Bool("p", false, "create missing parents, succeeding if the directory exists")
+	paths, err := parseFlags(flags, args, 1, -1)
+	if err != nil {
+		return err
+	}
+
+	var changes []*change
+	for _, p := range paths {
+		if *parents {
+			err = cli.Client.MkdirAll(p)
+		} else {
+			err = cli.Client.Mkdir(p)
+		}
+		if err != nil {
+			cli.fail(p, err)
+			continue
+		}
+		changes = append(changes, &change{Path: p})
+	}
+	cli.printChanges(changes)
+	return nil
+}
+
+// Resolves the destination of a command into an existing directory, like cp(1) does
+func (cli *CLI) destination(source string, destination string) string {
+	info, err := cli.Client.Stat(destination)
+	if err == nil && info.IsDir() {
+		return path.Join(destination, path.Base(source))
+	}
+	return destination
+}
+
+// Hides io.ReaderFrom of the writer, so that copies go through the buffer of io.CopyBuffer
+type writerOnly struct {
+	io.Writer
+}
+
+func (cli *CLI) put(args []string) error {
+	paths, err := parseFlags(flag.NewFlagSet("put", flag.ContinueOnError), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	local, remote := paths[0], cli.destination(paths[0], paths[1])
+
+	var input io.Reader = cli.Stdin
+	if local != "-" {
+		fd, err := os.Open(local)
+		if err != nil {
+			cli.fail(local, err)
+			return nil
+		}
+		defer fd.Close()
+		input = fd
+	}
+
+	file, err := cli.Client.Create(remote)
+	if err != nil {
+		cli.fail(remote, err)
+		return nil
+	}
+	size, err := io.CopyBuffer(file, input, make([]byte, client.ChunkSize))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cli.fail(remote, err)
+		return nil
+	}
+
+	if cli.JSON {
+		cli.printChanges([]*change{{Path: local, Destination: remote, Size: &size}})
+	}
+	return nil
+}
+
+func (cli *CLI) get(args []string) error {
+	paths, err := parseFlags(flag.NewFlagSet("get", flag.ContinueOnError), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	remote, local := paths[0], paths[1]
+
+	file, err := cli.Client.Open(remote)
+	if err != nil {
+		cli.fail(remote, err)
+		return nil
+	}
+	defer file.Close()
+
+	output := cli.Stdout
+	if local != "-" {
+		if info, err := os.Stat(local); err == nil && info.IsDir() {
+			local = filepath.Join(local, path.Base(remote))
+		}
+		fd, err := os.Create(local)
+		if err != nil {
+			cli.fail(local, err)
+			return nil
+		}
+		defer fd.Close()
+		output = fd
+	}
+
+	size, err := io.CopyBuffer(writerOnly{output}, file, make([]byte, client.ChunkSize))
+	if err != nil {
+		cli.fail(remote, err)
+		return nil
+	}
+
+	// the contents are the output when they are written to standard output
+	if cli.JSON && local != "-" {
+		cli.printChanges([]*change{{Path: remote, Destination: local, Size: &size}})
+	}
+	return nil
+}
+
+func (cli *CLI) cat(args []string) error {
+	paths, err := parseFlags(flag.NewFlagSet("cat", flag.ContinueOnError), args, 1, -1)
+	if err != nil {
+		return err
+	}
+
+	type contents struct {
+		Path string `json:"path"`
+		Data []byte `json:"data"` // base64
+	}
+	files := []*contents{}
+	for _, p := range paths {
+		file, err := cli.Client.Open(p)
+		if err != nil {
+			cli.fail(p, err)
+			continue
+		}
+
+		var output bytes.Buffer
+		var writer io.Writer = writerOnly{cli.Stdout}
+		if cli.JSON {
+			writer = &output
+		}
+		_, err = io.CopyBuffer(writer, file, make([]byte, client.ChunkSize))
+		file.Close()
+		if err != nil {
+			cli.fail(p, err)
+			continue
+		}
+		if cli.JSON {
+			files = append(files, &contents{Path: p, Data: output.Bytes()})
+		}
+	}
+
+	if cli.JSON {
+		cli.printJSON(files)
+	}
+	return nil
+}
+
+func (cli *CLI) cp(args []string) error {
+	paths, err := parseFlags(flag.NewFlagSet("cp", flag.ContinueOnError), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	source, destination := paths[0], cli.destination(paths[0], paths[1])
+
+	err = cli.Client.Copy(source, destination)
+	if err != nil {
+		cli.fail(source, err)
+		return nil
+	}
+	cli.printChanges([]*change{{Path: source, Destination: destination}})
+	return nil
+}
+
+func (cli *CLI) mv(args []string) error {
+	paths, err := parseFlags(flag.NewFlagSet("mv", flag.ContinueOnError), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	source, destination := paths[0], cli.destination(paths[0], paths[1])
+
+	err = cli.Client.Rename(source, destination)
+	if err != nil {
+		cli.fail(source, err)
+		return nil
+	}
+	cli.printChanges([]*change{{Path: source, Destination: destination}})
+	return nil
+}
+
+func (cli *CLI) rm(args []string) error {
+	flags := flag.NewFlagSet("rm", flag.ContinueOnError)
+	recursive := flags.Bool("r", false, "remove directories along with their contents")
+	paths, err := parseFlags(flags, args, 1, -1)
+	if err != nil {
+		return err
+	}
+
+	var changes []*change
+	for _, p := range paths {
+		if *recursive {
+			// unlike RemoveAll, rm fails on missing paths
+			_, err = cli.Client.Stat(p)
+			if err == nil {
+				err = cli.Client.RemoveAll(p)
+			}
+		} else {
+			err = cli.Client.Remove(p)
+		}
+		if err != nil {
+			cli.fail(p, err)
+			continue
+		}
+		changes = append(changes, &change{Path: p})
+	}
+	cli.printChanges(changes)
+	return nil
+}
+
+// Space used by a subtree
+type spaceUsage struct {
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	Files       int64  `json:"files"`
+	Directories int64  `json:"directories"`
+}
+
+// Adds the subtree to the usage. Hard links are counted once.
+func (cli *CLI) walkUsage(p string, info *client.FileInfo, seen map[uint64]bool, u *spaceUsage) error {
+	entry := newEntry(p, info)
+	if seen[entry.FileId] {
+		return nil
+	}
+	seen[entry.FileId] = true
+
+	switch entry.Type {
+	case "directory":
+		u.Directories++
+		infos, err := cli.Client.ReadDir(p)
+		if err != nil {
+			return err
+		}
+		for _, child := range infos {
+			err = cli.walkUsage(path.Join(p, child.Name()), child, seen, u)
+			if err != nil {
+				return err
+			}
+		}
+	case "file":
+		u.Files++
+		u.Size += entry.Size
+	}
+	return nil
+}
+
+func (cli *CLI) du(args []string) error {
+	paths, err := parseFlags(flag.NewFlagSet("du", flag.ContinueOnError), args, 0, -1)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		paths = []string{"/"}
+	}
+
+	usages := []*spaceUsage{}
+	for _, p := range paths {
+		info, err := cli.Client.Stat(p)
+		if err == nil {
+			u := &spaceUsage{Path: p}
+			err = cli.walkUsage(p, info, make(map[uint64]bool), u)
+			if err == nil {
+				usages = append(usages, u)
+				if !cli.JSON {
+					fmt.Fprintf(cli.Stdout, "%d\t%s\n", u.Size, p)
+				}
+			}
+		}
+		if err != nil {
+			cli.fail(p, err)
+		}
+	}
+
+	if cli.JSON {
+		cli.printJSON(usages)
+	}
+	return nil
+}
+
+// Subtree as printed by tree
+type treeNode struct {
+	*Entry
+	Children []*treeNode `json:"children,omitempty"`
+}
+
+func (cli *CLI) walkTree(p string, info *client.FileInfo) (*treeNode, error) {
+	node := &treeNode{Entry: newEntry(p, info)}
+	if !info.IsDir() {
+		return node, nil
+	}
+
+	infos, err := cli.Client.ReadDir(p)
+	if err != nil {
+		return nil, err
+	}
+	sortInfos(infos)
+	for _, child := range infos {
+		childNode, err := cli.walkTree(path.Join(p, child.Name()), child)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, childNode)
+	}
+	return node, nil
+}
+
+func (cli *CLI) printTree(node *treeNode, prefix string) {
+	for i, child := range node.Children {
+		branch, indent := "├── ", "│   "
+		if i == len(node.Children)-1 {
+			branch, indent = "└── ", "    "
+		}
+		fmt.Fprintln(cli.Stdout, prefix+branch+child.Name)
+		cli.printTree(child, prefix+indent)
+	}
+}
+
+func (cli *CLI) tree(args []string) error {
+	paths, err := parseFlags(flag.NewFlagSet("tree", flag.ContinueOnError), args, 0, 1)
+	if err != nil {
+		return err
+	}
+	p := "/"
+	if len(paths) > 0 {
+		p = paths[0]
+	}
+
+	info, err := cli.Client.Stat(p)
+	var root *treeNode
+	if err == nil {
+		root, err = cli.walkTree(p, info)
+	}
+	if err != nil {
+		cli.fail(p, err)
+		return nil
+	}
+
+	if cli.JSON {
+		cli.printJSON(root)
+		return nil
+	}
+	fmt.Fprintln(cli.Stdout, p)
+	cli.printTree(root, "")
+	return nil
+}
@@ -0,0 +1,260 @@
+package dfs
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"io/ioutil"
+	"os"
+	"project-dfs/client"
+	"project-dfs/pb"
+	"sort"
+	"syscall"
+)
+
+// Exit codes follow sysexits(3), so that scripts can tell failures apart
+const (
+	ExitOK          = 0
+	ExitFailure     = 1
+	ExitUsage       = 64 // wrong command line
+	ExitDataErr     = 65 // invalid argument
+	ExitNoInput     = 66 // node does not exist
+	ExitUnavailable = 69 // naming server cannot be reached
+	ExitCantCreat   = 73 // node cannot be created or removed
+	ExitIOErr       = 74 // storage servers failed
+	ExitTempFail    = 75 // failure that may go away when the command is retried
+	ExitNoPerm      = 77 // quota exceeded or node is read-only
+)
+
+// Maps the errno of an ErrorStatus, or the code of a failed request, to the exit code
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		switch errno {
+		case syscall.ENOENT, syscall.ENOTDIR:
+			return ExitNoInput
+		case syscall.EEXIST, syscall.EISDIR, syscall.ENOTEMPTY, syscall.EBUSY, syscall.EXDEV:
+			return ExitCantCreat
+		case syscall.EINVAL, syscall.ERANGE, syscall.ELOOP, syscall.ENAMETOOLONG:
+			return ExitDataErr
+		case syscall.EACCES, syscall.EPERM, syscall.EROFS, syscall.EDQUOT, syscall.ENOSPC:
+			return ExitNoPerm
+		case syscall.EIO, syscall.ENXIO:
+			// including failures of storage servers, which unwrap to EIO
+			return ExitIOErr
+		case syscall.EAGAIN, syscall.ESTALE, syscall.EBADF:
+			return ExitTempFail
+		}
+		return ExitFailure
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable:
+		return ExitUnavailable
+	case codes.DeadlineExceeded, codes.Aborted:
+		return ExitTempFail
+	}
+	return ExitFailure
+}
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(cli *CLI, args []string) error
+}
+
+var commands = []*command{
+	{"ls", "[path...]", "list directories", (*CLI).ls},
+	{"stat", "path...", "show information about nodes", (*CLI).stat},
+	{"mkdir", "[-p] path...", "create directories", (*CLI).mkdir},
+	{"put", "local remote", "upload a local file ('-' reads standard input)", (*CLI).put},
+	{"get", "remote local", "download a file ('-' writes standard output)", (*CLI).get},
+	{"cat", "path...", "print files", (*CLI).cat},
+	{"cp", "source destination", "copy a file or directory within the cluster", (*CLI).cp},
+	{"mv", "source destination", "move a file or directory", (*CLI).mv},
+	{"rm", "[-r] path...", "remove files, or directories along with their contents", (*CLI).rm},
+	{"du", "[path...]", "summarize space used by files", (*CLI).du},
+	{"tree", "[path]", "list a directory recursively", (*CLI).tree},
+}
+
+// State of a single invocation of the tool
+type CLI struct {
+	Client *client.Client
+	JSON   bool
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	status int // exit code of the last failure
+}
+
+func usage(output io.Writer) {
+	fmt.Fprintln(output, "Usage: dfs [-naming address] [-user name] [-json] command [arguments]")
+	fmt.Fprintln(output, "\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(output, "  %-6s %-20s %s\n", c.name, c.args, c.summary)
+	}
+	fmt.Fprintln(output, "\nExit codes: 64 usage, 65 invalid argument, 66 no such file, 69 naming server unavailable,")
+	fmt.Fprintln(output, "73 cannot create or remove, 74 storage failure, 75 temporary failure, 77 not permitted, 1 other.")
+}
+
+func Run() {
+	os.Exit(Main(os.Args[1:]))
+}
+
+// Runs the tool with the arguments and returns its exit code
+func Main(args []string) int {
+	// Obtain defaults of the options from environment
+	namingAddress := os.Getenv("NAMING_SERVER_ADDRESS")
+	if namingAddress == "" {
+		namingAddress = "localhost:5678"
+	}
+	user := os.Getenv("DFS_USER")
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+
+	flags := flag.NewFlagSet("dfs", flag.ContinueOnError)
+	flags.Usage = func() { usage(os.Stderr) }
+	flags.StringVar(&namingAddress, "naming", namingAddress, "address of the naming server")
+	flags.StringVar(&user, "user", user, "user owning created files")
+	jsonOutput := flags.Bool("json", false, "print results as JSON")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	if flags.NArg() == 0 {
+		usage(os.Stderr)
+		return ExitUsage
+	}
+
+	var cmd *command
+	for _, c := range commands {
+		if c.name == flags.Arg(0) {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintln(os.Stderr, "dfs: unknown command", flags.Arg(0))
+		usage(os.Stderr)
+		return ExitUsage
+	}
+
+	c, err := client.Dial(namingAddress, user)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dfs: cannot connect to naming server:", err)
+		return ExitCode(err)
+	}
+	defer c.Close()
+
+	cli := &CLI{
+		Client: c,
+		JSON:   *jsonOutput,
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+	err = cmd.run(cli, flags.Args()[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dfs %s: %s\nUsage: dfs %s %s\n", cmd.name, err, cmd.name, cmd.args)
+		return ExitUsage
+	}
+	return cli.status
+}
+
+// Parses the flags of the command, failing on a wrong number of arguments
+func parseFlags(flags *flag.FlagSet, args []string, min int, max int) ([]string, error) {
+	flags.SetOutput(ioutil.Discard)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() < min || max >= 0 && flags.NArg() > max {
+		return nil, errors.New("wrong number of arguments")
+	}
+	return flags.Args(), nil
+}
+
+// Reports the failure of the operation on the path and keeps its exit code.
+// The command goes on with the remaining paths, like rm(1) does.
+func (cli *CLI) fail(path string, err error) {
+	cli.status = ExitCode(err)
+
+	if !cli.JSON {
+		fmt.Fprintln(cli.Stderr, "dfs:", err)
+		return
+	}
+	failure := struct {
+		Path  string `json:"path"`
+		Errno uint32 `json:"errno,omitempty"`
+		Error string `json:"error"`
+	}{Path: path, Error: err.Error()}
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		failure.Errno = uint32(errno)
+	}
+	_ = json.NewEncoder(cli.Stderr).Encode(failure)
+}
+
+func (cli *CLI) printJSON(v interface{}) {
+	encoder := json.NewEncoder(cli.Stdout)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(v)
+}
+
+// Node as printed by the commands
+type Entry struct {
+	Path   string `json:"path"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Size   int64  `json:"size"`
+	Links  uint32 `json:"links"`
+	FileId uint64 `json:"fileId"`
+}
+
+func newEntry(p string, info *client.FileInfo) *Entry {
+	entry := &Entry{
+		Path: p,
+		Name: info.Name(),
+		Type: "file",
+		Size: info.Size(),
+	}
+	switch {
+	case info.IsDir():
+		entry.Type = "directory"
+	case info.Mode()&os.ModeSymlink != 0:
+		entry.Type = "symlink"
+	}
+	if node, ok := info.Sys().(*pb.Node); ok {
+		entry.Links = node.Links
+		entry.FileId = node.FileId
+	}
+	return entry
+}
+
+func (entry *Entry) String() string {
+	mode := "-"
+	switch entry.Type {
+	case "directory":
+		mode = "d"
+	case "symlink":
+		mode = "l"
+	}
+	return fmt.Sprintf("%s %3d %12d %s", mode, entry.Links, entry.Size, entry.Name)
+}
+
+func sortInfos(infos []*client.FileInfo) {
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name() < infos[j].Name()
+	})
+}
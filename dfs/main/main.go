@@ -0,0 +1,7 @@
+package main
+
+import "project-dfs/dfs"
+
+func main() {
+	dfs.Run()
+}
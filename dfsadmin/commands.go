@@ -0,0 +1,432 @@
+package dfsadmin
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"project-dfs/dfs"
+	"project-dfs/pb"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Storage server as printed by nodes
+type node struct {
+	Alias          string    `json:"alias"`
+	State          string    `json:"state"`
+	Alive          bool      `json:"alive"`
+	Address        string    `json:"address,omitempty"`
+	PublicAddress  string    `json:"publicAddress,omitempty"`
+	LastHeartbeat  time.Time `json:"lastHeartbeat"`
+	CapacityBytes  int64     `json:"capacityBytes"`
+	AvailableBytes int64     `json:"availableBytes"`
+	StoredBytes    int64     `json:"storedBytes"`
+	Replicas       uint64    `json:"replicas"`
+}
+
+func (cli *CLI) nodes(args []string) error {
+	_, err := parseFlags(flag.NewFlagSet("nodes", flag.ContinueOnError), args, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	response, err := cli.Admin.ListNodes(context.Background(), &pb.ListNodesRequest{})
+	if err == nil {
+		err = statusError(response.ErrorStatus)
+	}
+	if err != nil {
+		cli.fail("", err)
+		return nil
+	}
+
+	nodes := make([]*node, 0, len(response.Nodes))
+	for _, n := range response.Nodes {
+		nodes = append(nodes, &node{
+			Alias:          n.Alias,
+			State:          stateName(n.State),
+			Alive:          n.Alive,
+			Address:        n.Address,
+			PublicAddress:  n.PublicAddress,
+			LastHeartbeat:  time.Unix(0, n.LastHeartbeat*int64(time.Millisecond)),
+			CapacityBytes:  n.CapacityBytes,
+			AvailableBytes: n.AvailableBytes,
+			StoredBytes:    n.StoredBytes,
+			Replicas:       n.Replicas,
+		})
+	}
+	if cli.JSON {
+		cli.printJSON(nodes)
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(cli.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "ALIAS\tSTATE\tALIVE\tCAPACITY\tAVAILABLE\tSTORED\tREPLICAS\tADDRESS")
+	for _, n := range nodes {
+		alive := "no"
+		if n.Alive {
+			alive = "yes"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\n", n.Alias, n.State, alive,
+			n.CapacityBytes, n.AvailableBytes, n.StoredBytes, n.Replicas, n.Address)
+	}
+	return writer.Flush()
+}
+
+// Sets the state of every storage server named by the arguments
+func (cli *CLI) setState(name string, state pb.NodeState, args []string) error {
+	aliases, err := parseFlags(flag.NewFlagSet(name, flag.ContinueOnError), args, 1, -1)
+	if err != nil {
+		return err
+	}
+
+	type change struct {
+		Alias string `json:"alias"`
+		State string `json:"state"`
+	}
+	changes := []*change{}
+	for _, alias := range aliases {
+		response, err := cli.Admin.SetNodeState(context.Background(), &pb.SetNodeStateRequest{Alias: alias, State: state})
+		if err == nil {
+			err = statusError(response.ErrorStatus)
+		}
+		if err != nil {
+			cli.fail(alias, err)
+			continue
+		}
+		changes = append(changes, &change{Alias: alias, State: stateName(state)})
+	}
+
+	if cli.JSON {
+		cli.printJSON(changes)
+	}
+	return nil
+}
+
+func (cli *CLI) drain(args []string) error {
+	return cli.setState("drain", pb.NodeState_NODE_DRAINING, args)
+}
+
+func (cli *CLI) decommission(args []string) error {
+	return cli.setState("decommission", pb.NodeState_NODE_DECOMMISSIONING, args)
+}
+
+func (cli *CLI) activate(args []string) error {
+	return cli.setState("activate", pb.NodeState_NODE_ACTIVE, args)
+}
+
+// Replica change as printed by rebalance
+type replicaChange struct {
+	Action string `json:"action"` // "copy" or "remove"
+	Path   string `json:"path"`
+	FileId uint64 `json:"fileId"`
+	Size   int64  `json:"size"`
+	Alias  string `json:"alias"`
+	Source string `json:"source,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func newReplicaChange(c *pb.ReplicaChange) *replicaChange {
+	change := &replicaChange{
+		Action: "copy",
+		Path:   c.Path,
+		FileId: c.FileId,
+		Size:   c.Size,
+		Alias:  c.Alias,
+		Source: c.Source,
+	}
+	if c.Removed {
+		change.Action = "remove"
+	}
+	return change
+}
+
+func (change *replicaChange) String() string {
+	if change.Action == "remove" {
+		return fmt.Sprintf("remove %s (file %d) from %s", change.Path, change.FileId, change.Alias)
+	}
+	return fmt.Sprintf("copy   %s (file %d, %d bytes) from %s to %s", change.Path, change.FileId, change.Size, change.Source, change.Alias)
+}
+
+func (cli *CLI) rebalance(args []string) error {
+	flags := flag.NewFlagSet("rebalance", flag.ContinueOnError)
+	dryRun := flags.Bool("n", false, "print the planned changes without making them")
+	_, err := parseFlags(flags, args, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	response, err := cli.Admin.Rebalance(context.Background(), &pb.RebalanceRequest{DryRun: *dryRun})
+	if err == nil {
+		err = statusError(response.ErrorStatus)
+	}
+	if err != nil {
+		cli.fail("", err)
+		return nil
+	}
+
+	result := struct {
+		Changes  []*replicaChange `json:"changes"`
+		Failures []*replicaChange `json:"failures"`
+	}{Changes: []*replicaChange{}, Failures: []*replicaChange{}}
+	for _, c := range response.Changes {
+		change := newReplicaChange(c)
+		result.Changes = append(result.Changes, change)
+		if !cli.JSON {
+			fmt.Fprintln(cli.Stdout, change)
+		}
+	}
+	for _, failure := range response.Failures {
+		change := newReplicaChange(failure.Change)
+		err := statusError(failure.ErrorStatus)
+		change.Error = err.Error()
+		result.Failures = append(result.Failures, change)
+		cli.status = dfs.ExitCode(err)
+		if !cli.JSON {
+			fmt.Fprintf(cli.Stderr, "dfsadmin: failed to %s: %s\n", change, err)
+		}
+	}
+
+	if cli.JSON {
+		cli.printJSON(result)
+	}
+	return nil
+}
+
+// File as printed by under-replicated
+type underReplicated struct {
+	Path     string   `json:"path"`
+	FileId   uint64   `json:"fileId"`
+	Size     int64    `json:"size"`
+	Missing  uint32   `json:"missing"`
+	Replicas []string `json:"replicas"`
+}
+
+func (cli *CLI) underReplicated(args []string) error {
+	_, err := parseFlags(flag.NewFlagSet("under-replicated", flag.ContinueOnError), args, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	response, err := cli.Admin.ListUnderReplicated(context.Background(), &pb.ListUnderReplicatedRequest{})
+	if err == nil {
+		err = statusError(response.ErrorStatus)
+	}
+	if err != nil {
+		cli.fail("", err)
+		return nil
+	}
+
+	files := make([]*underReplicated, 0, len(response.Files))
+	for _, f := range response.Files {
+		replicas := f.Aliases
+		if replicas == nil {
+			replicas = []string{}
+		}
+		files = append(files, &underReplicated{
+			Path:     f.Path,
+			FileId:   f.FileId,
+			Size:     f.Size,
+			Missing:  f.Missing,
+			Replicas: replicas,
+		})
+	}
+	if cli.JSON {
+		cli.printJSON(files)
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(cli.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "PATH\tFILE ID\tSIZE\tMISSING\tREPLICAS")
+	for _, f := range files {
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%d/%d\t%s\n", f.Path, f.FileId, f.Size,
+			f.Missing, response.ReplicationFactor, strings.Join(f.Replicas, ","))
+	}
+	return writer.Flush()
+}
+
+// Entry of the index as printed by dump
+type indexEntry struct {
+	Name     string        `json:"name"`
+	Type     string        `json:"type"`
+	FileId   uint64        `json:"fileId"`
+	Size     int64         `json:"size"`
+	Version  uint64        `json:"version"`
+	Links    uint32        `json:"links"`
+	Owner    string        `json:"owner,omitempty"`
+	Target   string        `json:"target,omitempty"`
+	Storages []string      `json:"storages,omitempty"`
+	Children []*indexEntry `json:"children,omitempty"`
+}
+
+func newIndexEntry(e *pb.IndexEntry) *indexEntry {
+	entry := &indexEntry{
+		Name:     e.Name,
+		Type:     "file",
+		FileId:   e.FileId,
+		Size:     e.Size,
+		Version:  e.Version,
+		Links:    e.Links,
+		Owner:    e.Owner,
+		Target:   e.Target,
+		Storages: e.Storages,
+	}
+	switch e.Mode {
+	case pb.NodeMode_DIRECTORY:
+		entry.Type = "directory"
+	case pb.NodeMode_SYMLINK:
+		entry.Type = "symlink"
+	}
+	for _, child := range e.Children {
+		entry.Children = append(entry.Children, newIndexEntry(child))
+	}
+	return entry
+}
+
+// Details of the entry printed next to its name
+func (entry *indexEntry) details() string {
+	details := fmt.Sprintf("[id %d", entry.FileId)
+	switch entry.Type {
+	case "file":
+		details += fmt.Sprintf(", %d bytes, version %d, links %d, on %s", entry.Size, entry.Version, entry.Links, strings.Join(entry.Storages, ","))
+	case "symlink":
+		details += ", -> " + entry.Target
+	}
+	if entry.Owner != "" {
+		details += ", owner " + entry.Owner
+	}
+	return details + "]"
+}
+
+func (cli *CLI) printIndex(entry *indexEntry, prefix string) {
+	for i, child := range entry.Children {
+		branch, indent := "├── ", "│   "
+		if i == len(entry.Children)-1 {
+			branch, indent = "└── ", "    "
+		}
+		fmt.Fprintln(cli.Stdout, prefix+branch+child.Name, child.details())
+		cli.printIndex(child, prefix+indent)
+	}
+}
+
+func (cli *CLI) dump(args []string) error {
+	paths, err := parseFlags(flag.NewFlagSet("dump", flag.ContinueOnError), args, 0, 1)
+	if err != nil {
+		return err
+	}
+	p := "/"
+	if len(paths) > 0 {
+		p = paths[0]
+	}
+
+	// the root of the index is the empty path
+	response, err := cli.Admin.DumpIndex(context.Background(), &pb.DumpIndexRequest{Path: strings.TrimSuffix(p, "/")})
+	if err == nil {
+		err = statusError(response.ErrorStatus)
+	}
+	if err != nil {
+		cli.fail(p, err)
+		return nil
+	}
+
+	root := newIndexEntry(response.Root)
+	if cli.JSON {
+		cli.printJSON(root)
+		return nil
+	}
+	fmt.Fprintln(cli.Stdout, p, root.details())
+	cli.printIndex(root, "")
+	return nil
+}
+
+// Exit codes of fsck, following fsck(8)
+const (
+	FsckClean       = 0
+	FsckRepaired    = 1
+	FsckUncorrected = 4
+)
+
+// Problem as printed by fsck
+type fsckProblem struct {
+	Kind         string `json:"kind"`
+	Alias        string `json:"alias,omitempty"`
+	FileId       uint64 `json:"fileId,omitempty"`
+	Path         string `json:"path,omitempty"`
+	Version      uint64 `json:"version,omitempty"`
+	IndexVersion uint64 `json:"indexVersion,omitempty"`
+	Repaired     bool   `json:"repaired"`
+	Error        string `json:"error,omitempty"`
+}
+
+func (cli *CLI) fsck(args []string) error {
+	flags := flag.NewFlagSet("fsck", flag.ContinueOnError)
+	repair := flags.Bool("repair", false, "delete orphaned replicas and fetch missing or stale ones")
+	_, err := parseFlags(flags, args, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	response, err := cli.Admin.Fsck(context.Background(), &pb.FsckRequest{Repair: *repair})
+	if err == nil {
+		err = statusError(response.ErrorStatus)
+	}
+	if err != nil {
+		cli.fail("", err)
+		return nil
+	}
+
+	result := struct {
+		CheckedServers uint32         `json:"checkedServers"`
+		CheckedFiles   uint64         `json:"checkedFiles"`
+		Problems       []*fsckProblem `json:"problems"`
+	}{response.CheckedServers, response.CheckedFiles, []*fsckProblem{}}
+	repaired := 0
+	for _, p := range response.Problems {
+		problem := &fsckProblem{
+			Kind:         strings.ToLower(strings.TrimPrefix(p.Kind.String(), "FSCK_")),
+			Alias:        p.Alias,
+			FileId:       p.FileId,
+			Path:         p.Path,
+			Version:      p.Version,
+			IndexVersion: p.IndexVersion,
+			Repaired:     p.Repaired,
+		}
+		if err := statusError(p.ErrorStatus); err != nil {
+			problem.Error = err.Error()
+		}
+		if problem.Repaired {
+			repaired++
+		}
+		result.Problems = append(result.Problems, problem)
+	}
+
+	cli.status = FsckClean
+	if repaired < len(result.Problems) {
+		cli.status = FsckUncorrected
+	} else if repaired > 0 {
+		cli.status = FsckRepaired
+	}
+
+	if cli.JSON {
+		cli.printJSON(result)
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(cli.Stdout, 0, 8, 2, ' ', 0)
+	if len(result.Problems) > 0 {
+		fmt.Fprintln(writer, "PROBLEM\tALIAS\tFILE ID\tVERSION\tINDEX VERSION\tPATH\tSTATUS")
+	}
+	for _, p := range result.Problems {
+		status := ""
+		if p.Repaired {
+			status = "repaired"
+		} else if p.Error != "" {
+			status = p.Error
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%d\t%d\t%d\t%s\t%s\n", p.Kind, p.Alias, p.FileId, p.Version, p.IndexVersion, p.Path, status)
+	}
+	err = writer.Flush()
+	fmt.Fprintf(cli.Stdout, "Checked %d replicas on %d storage servers: %d problems, %d repaired\n",
+		result.CheckedFiles, result.CheckedServers, len(result.Problems), repaired)
+	return err
+}
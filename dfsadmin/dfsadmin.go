@@ -0,0 +1,176 @@
+package dfsadmin
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"google.golang.org/grpc"
+	"io"
+	"io/ioutil"
+	"os"
+	utils "project-dfs"
+	"project-dfs/dfs"
+	"project-dfs/pb"
+	"strings"
+	"syscall"
+)
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(cli *CLI, args []string) error
+}
+
+var commands = []*command{
+	{"nodes", "", "list storage servers with their capacity and liveness", (*CLI).nodes},
+	{"drain", "alias...", "move replicas away from storage servers", (*CLI).drain},
+	{"decommission", "alias...", "drain storage servers and remove them from the cluster", (*CLI).decommission},
+	{"activate", "alias...", "let storage servers receive replicas again", (*CLI).activate},
+	{"rebalance", "[-n]", "restore replication and even out stored bytes (-n only plans)", (*CLI).rebalance},
+	{"under-replicated", "", "list files with too few replicas", (*CLI).underReplicated},
+	{"dump", "[path]", "print the index tree", (*CLI).dump},
+	{"fsck", "[-repair]", "compare replicas on storage servers with the index", (*CLI).fsck},
+}
+
+// State of a single invocation of the tool
+type CLI struct {
+	Admin  pb.AdminClient
+	JSON   bool
+	Stdout io.Writer
+	Stderr io.Writer
+
+	status int // exit code of the last failure
+}
+
+func usage(output io.Writer) {
+	fmt.Fprintln(output, "Usage: dfsadmin [-naming address] [-json] command [arguments]")
+	fmt.Fprintln(output, "\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(output, "  %-16s %-10s %s\n", c.name, c.args, c.summary)
+	}
+	fmt.Fprintln(output, "\nExit codes follow the ones of dfs: 64 usage, 66 unknown node, 69 naming server unavailable,")
+	fmt.Fprintln(output, "74 storage failure, 75 temporary failure, 1 other. fsck exits like fsck(8): 0 clean,")
+	fmt.Fprintln(output, "1 problems repaired, 4 problems left.")
+}
+
+func Run() {
+	os.Exit(Main(os.Args[1:]))
+}
+
+// Runs the tool with the arguments and returns its exit code
+func Main(args []string) int {
+	// Obtain default address of the naming server from environment
+	namingAddress := os.Getenv("NAMING_SERVER_ADDRESS")
+	if namingAddress == "" {
+		namingAddress = "localhost:5678"
+	}
+
+	flags := flag.NewFlagSet("dfsadmin", flag.ContinueOnError)
+	flags.Usage = func() { usage(os.Stderr) }
+	flags.StringVar(&namingAddress, "naming", namingAddress, "address of the naming server")
+	jsonOutput := flags.Bool("json", false, "print results as JSON")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return dfs.ExitOK
+		}
+		return dfs.ExitUsage
+	}
+	if flags.NArg() == 0 {
+		usage(os.Stderr)
+		return dfs.ExitUsage
+	}
+
+	var cmd *command
+	for _, c := range commands {
+		if c.name == flags.Arg(0) {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintln(os.Stderr, "dfsadmin: unknown command", flags.Arg(0))
+		usage(os.Stderr)
+		return dfs.ExitUsage
+	}
+
+	conn, err := grpc.Dial(namingAddress, utils.DialOptions()...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dfsadmin: cannot connect to naming server:", err)
+		return dfs.ExitCode(err)
+	}
+	defer conn.Close()
+
+	cli := &CLI{
+		Admin:  pb.NewAdminClient(conn),
+		JSON:   *jsonOutput,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+	err = cmd.run(cli, flags.Args()[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dfsadmin %s: %s\nUsage: dfsadmin %s %s\n", cmd.name, err, cmd.name, cmd.args)
+		return dfs.ExitUsage
+	}
+	return cli.status
+}
+
+// Parses the flags of the command, failing on a wrong number of arguments
+func parseFlags(flags *flag.FlagSet, args []string, min int, max int) ([]string, error) {
+	flags.SetOutput(ioutil.Discard)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() < min || max >= 0 && flags.NArg() > max {
+		return nil, errors.New("wrong number of arguments")
+	}
+	return flags.Args(), nil
+}
+
+// Converts the status returned by the naming server into an error carrying its errno
+func statusError(status *pb.ErrorStatus) error {
+	if status == nil || status.Code == 0 {
+		return nil
+	}
+	errno := syscall.Errno(status.Code)
+	if status.Description == "" || status.Description == errno.Error() {
+		return errno
+	}
+	return fmt.Errorf("%s: %w", status.Description, errno)
+}
+
+// Reports the failure of the operation on the target, which is a storage server or a path,
+// and keeps its exit code. The command goes on with the remaining targets.
+func (cli *CLI) fail(target string, err error) {
+	cli.status = dfs.ExitCode(err)
+
+	if !cli.JSON {
+		if target == "" {
+			fmt.Fprintln(cli.Stderr, "dfsadmin:", err)
+		} else {
+			fmt.Fprintf(cli.Stderr, "dfsadmin: %s: %s\n", target, err)
+		}
+		return
+	}
+	failure := struct {
+		Target string `json:"target,omitempty"`
+		Errno  uint32 `json:"errno,omitempty"`
+		Error  string `json:"error"`
+	}{Target: target, Error: err.Error()}
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		failure.Errno = uint32(errno)
+	}
+	_ = json.NewEncoder(cli.Stderr).Encode(failure)
+}
+
+func (cli *CLI) printJSON(v interface{}) {
+	encoder := json.NewEncoder(cli.Stdout)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(v)
+}
+
+// Name of the state as printed by the commands, e.g. "draining"
+func stateName(state pb.NodeState) string {
+	return strings.ToLower(strings.TrimPrefix(state.String(), "NODE_"))
+}
@@ -0,0 +1,7 @@
+package main
+
+import "project-dfs/dfsadmin"
+
+func main() {
+	dfsadmin.Run()
+}
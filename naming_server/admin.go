@@ -0,0 +1,616 @@
+package naming_server
+
+import (
+	"context"
+	"fmt"
+	utils "project-dfs"
+	"project-dfs/pb"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// Number of replicas files are created with, and rebalancing restores
+const ReplicationFactor = 2
+
+// Limits the replicas moved between active servers by a single rebalancing pass
+const maxBalancingMoves = 100
+
+// Returns the state of the storage server set by administrators
+func (server *NamingServer) NodeState(alias string) pb.NodeState {
+	server.storageAddressesMutex.Lock()
+	defer server.storageAddressesMutex.Unlock()
+	return server.NodeStates[alias]
+}
+
+// Whether new replicas may be placed on the storage server. Caller has to hold the storage addresses mutex.
+func (server *NamingServer) acceptsReplicas(alias string) bool {
+	info, ok := server.StorageAddresses[alias]
+	return ok && server.NodeStates[alias] == pb.NodeState_NODE_ACTIVE &&
+		time.Since(info.lastHeartbeat) < server.LeaseDuration
+}
+
+func (server *NamingServer) AcceptsReplicas(alias string) bool {
+	server.storageAddressesMutex.Lock()
+	defer server.storageAddressesMutex.Unlock()
+	return server.acceptsReplicas(alias)
+}
+
+// Changes the state of the storage server. Draining and decommissioning servers receive no new replicas
+// and have theirs moved away by rebalancing; decommissioned ones can only be made active again.
+func (server *NamingServer) SetNodeState(alias string, state pb.NodeState) syscall.Errno {
+	server.storageAddressesMutex.Lock()
+	defer server.storageAddressesMutex.Unlock()
+
+	_, registered := server.StorageAddresses[alias]
+	_, known := server.NodeStates[alias]
+	if !registered && !known {
+		return syscall.ENOENT
+	}
+
+	switch state {
+	case pb.NodeState_NODE_ACTIVE:
+		delete(server.NodeStates, alias)
+	case pb.NodeState_NODE_DRAINING, pb.NodeState_NODE_DECOMMISSIONING:
+		if !registered {
+			// the server has to register again before it can be drained
+			return syscall.ENXIO
+		}
+		server.NodeStates[alias] = state
+	default:
+		return syscall.EINVAL
+	}
+	fmt.Println("Storage server", alias, "is now", state)
+	return 0
+}
+
+// Whether some storage server has replicas to be moved away
+func (server *NamingServer) HasDrainingNodes() bool {
+	server.storageAddressesMutex.Lock()
+	defer server.storageAddressesMutex.Unlock()
+	for _, state := range server.NodeStates {
+		if state == pb.NodeState_NODE_DRAINING || state == pb.NodeState_NODE_DECOMMISSIONING {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the files of snapshots by ID along with a path of each. Caller has to hold the index mutex.
+func (server *NamingServer) snapshotFiles() (map[uint64]*Inode, map[uint64]string) {
+	inodes := make(map[uint64]*Inode)
+	paths := make(map[uint64]string)
+	var walk func(n *Node, path string)
+	walk = func(n *Node, path string) {
+		if n.Type == FILE {
+			if _, ok := inodes[n.ID]; !ok {
+				inodes[n.ID] = n.Inode
+				paths[n.ID] = path
+			}
+			return
+		}
+		for _, child := range n.Children {
+			walk(child, path+"/"+child.Name)
+		}
+	}
+	for name, snapshot := range server.Snapshots {
+		walk(snapshot.Root, SnapshotsDirectory+"/"+name)
+	}
+	return inodes, paths
+}
+
+// Returns the inodes storage servers hold replicas of, the ones of snapshots included.
+// Caller has to hold the index mutex.
+func (server *NamingServer) replicatedInodes() map[uint64]*Inode {
+	inodes, _ := server.snapshotFiles()
+	for id, inode := range server.Inodes {
+		inodes[id] = inode
+	}
+	return inodes
+}
+
+// Returns the inode of the live or snapshot file, or nil. Caller has to hold the index mutex.
+func (server *NamingServer) replicatedInode(id uint64) *Inode {
+	if inode, ok := server.Inodes[id]; ok {
+		return inode
+	}
+	inodes, _ := server.snapshotFiles()
+	return inodes[id]
+}
+
+// Returns the versions of the file saved on the holders of its replicas
+func (inode *Inode) savedVersions() []uint64 {
+	var versions []uint64
+	for _, version := range inode.History {
+		versions = append(versions, version.Version)
+	}
+	return versions
+}
+
+// Returns the size and the number of replicas every storage server holds according to the index.
+// Caller has to hold the index mutex.
+func (server *NamingServer) storedBytes() (map[string]int64, map[string]uint64) {
+	bytes := make(map[string]int64)
+	replicas := make(map[string]uint64)
+	for _, inode := range server.replicatedInodes() {
+		for _, storage := range inode.Storages {
+			bytes[storage.Alias] += inode.Size
+			replicas[storage.Alias]++
+		}
+	}
+	return bytes, replicas
+}
+
+// Returns the registered storage servers along with the decommissioned ones, sorted by alias
+func (server *NamingServer) ListNodes() []*pb.StorageNode {
+	server.indexMutex.Lock()
+	bytes, replicas := server.storedBytes()
+	server.indexMutex.Unlock()
+
+	server.storageAddressesMutex.Lock()
+	defer server.storageAddressesMutex.Unlock()
+
+	aliases := StorageServerInfoKeys(server.StorageAddresses)
+	for alias := range server.NodeStates {
+		if _, ok := server.StorageAddresses[alias]; !ok {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+
+	nodes := make([]*pb.StorageNode, 0, len(aliases))
+	for _, alias := range aliases {
+		node := &pb.StorageNode{
+			Alias:       alias,
+			State:       server.NodeStates[alias],
+			StoredBytes: bytes[alias],
+			Replicas:    replicas[alias],
+		}
+		if info, ok := server.StorageAddresses[alias]; ok {
+			node.Address = info.privateAddress
+			node.PublicAddress = info.publicAddress
+			node.Alive = time.Since(info.lastHeartbeat) < server.LeaseDuration
+			node.LastHeartbeat = info.lastHeartbeat.UnixNano() / int64(time.Millisecond)
+			node.CapacityBytes = info.capacity
+			node.AvailableBytes = info.available
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// Returns a path of every file of the live tree by ID. Caller has to hold the index mutex.
+func (server *NamingServer) filePaths() map[uint64]string {
+	paths := make(map[uint64]string)
+	var walk func(n *Node, path string)
+	walk = func(n *Node, path string) {
+		if n.Type == FILE {
+			if _, ok := paths[n.ID]; !ok {
+				paths[n.ID] = path
+			}
+			return
+		}
+		for _, child := range n.Children {
+			walk(child, path+"/"+child.Name)
+		}
+	}
+	walk(server.RootIndexNode, "")
+	return paths
+}
+
+// Returns the files with fewer replicas on live active storage servers than the replication factor
+func (server *NamingServer) UnderReplicatedFiles() []*pb.UnderReplicatedFile {
+	server.indexMutex.Lock()
+	defer server.indexMutex.Unlock()
+
+	files := make([]*pb.UnderReplicatedFile, 0)
+	for id, path := range server.filePaths() {
+		inode := server.Inodes[id]
+		var aliases []string
+		usable := 0
+		for _, storage := range inode.Storages {
+			aliases = append(aliases, storage.Alias)
+			if server.AcceptsReplicas(storage.Alias) {
+				usable++
+			}
+		}
+		if usable >= ReplicationFactor {
+			continue
+		}
+		files = append(files, &pb.UnderReplicatedFile{
+			FileId:  id,
+			Path:    path,
+			Size:    inode.Size,
+			Aliases: aliases,
+			Missing: uint32(ReplicationFactor - usable),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files
+}
+
+// Returns the subtree of the index at the path
+func (server *NamingServer) DumpIndex(path string) (*pb.IndexEntry, syscall.Errno) {
+	server.indexMutex.Lock()
+	defer server.indexMutex.Unlock()
+
+	var node *Node
+	if IsInSnapshot(path) {
+		found, ok := server.FindSnapshotNode(path)
+		if !ok {
+			return nil, syscall.ENOENT
+		}
+		node = found
+	} else {
+		found, errno := server.LookupNode(path)
+		if errno != 0 {
+			return nil, errno
+		}
+		node = found
+	}
+	return dumpNode(node), 0
+}
+
+func dumpNode(n *Node) *pb.IndexEntry {
+	entry := &pb.IndexEntry{
+		Name:    n.Name,
+		Mode:    n.Mode(),
+		FileId:  n.ID,
+		Size:    n.Size,
+		Version: n.Version,
+		Owner:   n.Owner,
+		Target:  n.Target,
+		Links:   uint32(n.LinkCount()),
+	}
+	for _, storage := range n.Storages {
+		entry.Storages = append(entry.Storages, storage.Alias)
+	}
+	for _, child := range n.Children {
+		entry.Children = append(entry.Children, dumpNode(child))
+	}
+	return entry
+}
+
+// Replica added to or removed from a storage server by rebalancing
+type replicaChange struct {
+	fileID   uint64
+	version  uint64
+	size     int64
+	alias    string
+	source   string   // alias of the replica an added replica is copied from
+	versions []uint64 // saved versions copied along with an added replica
+	removed  bool
+}
+
+// Load of a registered storage server as seen while planning
+type nodeLoad struct {
+	alias    string
+	alive    bool
+	usable   bool // alive and active, so that it can receive replicas
+	draining bool
+	stored   int64
+}
+
+// Plans the changes restoring the replication factor of files, the ones of snapshots included,
+// and moving replicas away from draining servers. With balance set, replicas are also moved from the active servers
+// storing the most bytes to the ones storing the fewest. Caller has to hold the index mutex.
+func (server *NamingServer) planRebalance(balance bool) []replicaChange {
+	inodes := server.replicatedInodes()
+	bytes, _ := server.storedBytes()
+	nodes := make(map[string]*nodeLoad)
+	server.storageAddressesMutex.Lock()
+	for alias, info := range server.StorageAddresses {
+		nodes[alias] = &nodeLoad{
+			alias:    alias,
+			alive:    time.Since(info.lastHeartbeat) < server.LeaseDuration,
+			usable:   server.acceptsReplicas(alias),
+			draining: server.NodeStates[alias] != pb.NodeState_NODE_ACTIVE,
+			stored:   bytes[alias],
+		}
+	}
+	server.storageAddressesMutex.Unlock()
+
+	// least loaded usable server not holding any of the replicas
+	target := func(holders []string) *nodeLoad {
+		var best *nodeLoad
+		for _, node := range nodes {
+			if !node.usable || utils.Contains(holders, node.alias) {
+				continue
+			}
+			if best == nil || node.stored < best.stored || node.stored == best.stored && node.alias < best.alias {
+				best = node
+			}
+		}
+		return best
+	}
+
+	ids := make([]uint64, 0, len(inodes))
+	for id, inode := range inodes {
+		if len(inode.Storages) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var changes []replicaChange
+	holders := make(map[uint64][]string)
+	for _, id := range ids {
+		inode := inodes[id]
+		source := ""
+		usable := 0
+		for _, storage := range inode.Storages {
+			holders[id] = append(holders[id], storage.Alias)
+			node := nodes[storage.Alias]
+			if node == nil || !node.alive {
+				continue
+			}
+			if source == "" {
+				source = storage.Alias
+			}
+			if node.usable {
+				usable++
+			}
+		}
+		if source == "" {
+			// no replica can be copied from
+			continue
+		}
+
+		for usable < ReplicationFactor {
+			node := target(holders[id])
+			if node == nil {
+				break
+			}
+			changes = append(changes, replicaChange{fileID: id, version: inode.Version, size: inode.Size, alias: node.alias, source: source, versions: inode.savedVersions()})
+			holders[id] = append(holders[id], node.alias)
+			node.stored += inode.Size
+			usable++
+		}
+		if usable < ReplicationFactor {
+			// replicas of draining servers are kept until enough active servers hold the file
+			continue
+		}
+		for _, storage := range inode.Storages {
+			node := nodes[storage.Alias]
+			if node != nil && node.draining {
+				changes = append(changes, replicaChange{fileID: id, version: inode.Version, size: inode.Size, alias: storage.Alias, removed: true})
+				node.stored -= inode.Size
+			}
+		}
+	}
+
+	if balance {
+		changes = append(changes, planBalancing(nodes, inodes, ids, holders, changes)...)
+	}
+	return changes
+}
+
+// Moves replicas between usable servers, each move narrowing the gap between the most
+// and the least loaded of them. Files already changed by the plan are left alone.
+func planBalancing(nodes map[string]*nodeLoad, inodes map[uint64]*Inode, ids []uint64, holders map[uint64][]string, planned []replicaChange) []replicaChange {
+	changed := make(map[uint64]bool)
+	for _, change := range planned {
+		changed[change.fileID] = true
+	}
+
+	var changes []replicaChange
+	for moves := 0; moves < maxBalancingMoves; moves++ {
+		var most, least *nodeLoad
+		for _, node := range nodes {
+			if !node.usable {
+				continue
+			}
+			if most == nil || node.stored > most.stored || node.stored == most.stored && node.alias < most.alias {
+				most = node
+			}
+			if least == nil || node.stored < least.stored || node.stored == least.stored && node.alias < least.alias {
+				least = node
+			}
+		}
+		if most == nil || most == least {
+			break
+		}
+
+		// the largest file that does not overshoot the middle
+		gap := most.stored - least.stored
+		var best uint64
+		for _, id := range ids {
+			inode := inodes[id]
+			if changed[id] || inode.Size <= 0 || 2*inode.Size > gap ||
+				!utils.Contains(holders[id], most.alias) || utils.Contains(holders[id], least.alias) {
+				continue
+			}
+			if best == 0 || inode.Size > inodes[best].Size {
+				best = id
+			}
+		}
+		if best == 0 {
+			break
+		}
+
+		inode := inodes[best]
+		changes = append(changes,
+			replicaChange{fileID: best, version: inode.Version, size: inode.Size, alias: least.alias, source: most.alias, versions: inode.savedVersions()},
+			replicaChange{fileID: best, version: inode.Version, size: inode.Size, alias: most.alias, removed: true})
+		changed[best] = true
+		most.stored -= inode.Size
+		least.stored += inode.Size
+	}
+	return changes
+}
+
+// Runs a rebalancing pass and returns the changes made along with the ones that failed.
+// Replicas are added before any is removed, and a replica is removed only while enough
+// other replicas remain on live active servers. With dryRun set, the plan is returned as is.
+func (server *NamingServer) Rebalance(ctx context.Context, balance bool, dryRun bool) ([]*pb.ReplicaChange, []*pb.FailedChange) {
+	server.rebalanceMutex.Lock()
+	defer server.rebalanceMutex.Unlock()
+
+	server.indexMutex.Lock()
+	planned := server.planRebalance(balance)
+	paths := server.filePaths()
+	_, snapshotPaths := server.snapshotFiles()
+	for id, path := range snapshotPaths {
+		paths[id] = path
+	}
+	server.indexMutex.Unlock()
+
+	describe := func(change replicaChange) *pb.ReplicaChange {
+		return &pb.ReplicaChange{
+			FileId:  change.fileID,
+			Path:    paths[change.fileID],
+			Alias:   change.alias,
+			Removed: change.removed,
+			Source:  change.source,
+			Size:    change.size,
+		}
+	}
+	done := make([]*pb.ReplicaChange, 0)
+	failures := make([]*pb.FailedChange, 0)
+	if dryRun {
+		for _, change := range planned {
+			done = append(done, describe(change))
+		}
+		return done, failures
+	}
+
+	for _, change := range planned {
+		if change.removed {
+			continue
+		}
+		status := server.copyReplica(ctx, change)
+		if status != nil {
+			println("Error copying replica of file", change.fileID, "to", change.alias, ":", status.Description)
+			failures = append(failures, &pb.FailedChange{Change: describe(change), ErrorStatus: status})
+			continue
+		}
+		done = append(done, describe(change))
+	}
+
+	var ops []StorageOp
+	var removed []replicaChange
+	for _, change := range planned {
+		if !change.removed {
+			continue
+		}
+		server.indexMutex.Lock()
+		ok := server.removeReplica(change.fileID, change.alias)
+		server.indexMutex.Unlock()
+		if !ok {
+			failures = append(failures, &pb.FailedChange{Change: describe(change), ErrorStatus: &pb.ErrorStatus{
+				Code:        uint32(syscall.EAGAIN),
+				Description: "Not enough other replicas on active storage servers",
+			}})
+			continue
+		}
+		ops = append(ops, StorageOp{Alias: change.alias, Kind: RemoveOp, FileID: change.fileID})
+		removed = append(removed, change)
+	}
+
+	// the index no longer refers to replicas that failed to be deleted; they are only wasting space
+	failed, statuses := server.ApplyStorageOps(ctx, ops)
+	for _, change := range removed {
+		done = append(done, describe(change))
+	}
+	for i, op := range failed {
+		failures = append(failures, &pb.FailedChange{
+			Change:      describe(replicaChange{fileID: op.FileID, alias: op.Alias, removed: true}),
+			ErrorStatus: statuses[i],
+		})
+	}
+
+	server.finishDecommissions()
+	return done, failures
+}
+
+// Makes the storage server fetch the file along with its saved versions from the source replica
+// and adds the replica to the index. Writes committed in the meantime are caught up by reconciliation.
+func (server *NamingServer) copyReplica(ctx context.Context, change replicaChange) *pb.ErrorStatus {
+	status := server.fetchReplica(ctx, change.fileID, change.version, change.versions, change.alias, change.source)
+	if status != nil {
+		return status
+	}
+
+	server.indexMutex.Lock()
+	inode := server.replicatedInode(change.fileID)
+	if inode != nil && !inode.HasStorage(change.alias) {
+		inode.Storages = append(inode.Storages, &StorageInfo{Alias: change.alias})
+	}
+	server.indexMutex.Unlock()
+	if inode == nil {
+		// the file was deleted while it was being copied
+		server.ApplyStorageOps(ctx, []StorageOp{{Alias: change.alias, Kind: RemoveOp, FileID: change.fileID}})
+		return &pb.ErrorStatus{Code: uint32(syscall.ENOENT), Description: "No such file"}
+	}
+	fmt.Println("Replica of file", change.fileID, "copied from", change.source, "to", change.alias)
+	return nil
+}
+
+// Makes the storage server replace its replica of the file with the one of the source server
+// and fetch the saved versions, failing if the source is behind the version
+func (server *NamingServer) fetchReplica(ctx context.Context, fileID uint64, version uint64, versions []uint64, alias string, source string) *pb.ErrorStatus {
+	sourceInfo, ok := server.GetAddress(source)
+	target, targetOk := server.GetAddress(alias)
+	if !ok || !targetOk {
+		return &pb.ErrorStatus{Code: uint32(syscall.ENXIO), Description: "Storage server is not registered"}
+	}
+	ss := server.GetStorageServer(target.privateAddress)
+	if ss == nil {
+		return &pb.ErrorStatus{Code: uint32(syscall.EIO), Description: "No connection to storage server " + alias}
+	}
+
+	response, err := ss.FetchFile(ctx, &pb.FetchFileArgs{
+		FileId:        fileID,
+		SourceAddress: sourceInfo.privateAddress,
+		Version:       version,
+		SavedVersions: versions,
+	})
+	if err != nil {
+		return &pb.ErrorStatus{Code: uint32(syscall.EIO), Description: err.Error()}
+	}
+	if response.ErrorStatus.Code != 0 {
+		return response.ErrorStatus
+	}
+	return nil
+}
+
+// Removes the replica from the index if enough other replicas remain on live active servers.
+// Caller has to hold the index mutex.
+func (server *NamingServer) removeReplica(fileID uint64, alias string) bool {
+	inode := server.replicatedInode(fileID)
+	if inode == nil || !inode.HasStorage(alias) {
+		return false
+	}
+
+	usable := 0
+	for _, storage := range inode.Storages {
+		if storage.Alias != alias && server.AcceptsReplicas(storage.Alias) {
+			usable++
+		}
+	}
+	if usable < ReplicationFactor {
+		return false
+	}
+	inode.RemoveReplica(alias)
+	fmt.Println("Replica of file", fileID, "removed from", alias)
+	return true
+}
+
+// Unregisters the decommissioning storage servers that no longer hold replicas of live or snapshot files
+func (server *NamingServer) finishDecommissions() {
+	server.indexMutex.Lock()
+	defer server.indexMutex.Unlock()
+	_, replicas := server.storedBytes()
+
+	server.storageAddressesMutex.Lock()
+	for alias, state := range server.NodeStates {
+		if state == pb.NodeState_NODE_DECOMMISSIONING && replicas[alias] == 0 {
+			server.NodeStates[alias] = pb.NodeState_NODE_DECOMMISSIONED
+			delete(server.StorageAddresses, alias)
+			fmt.Println("Storage server", alias, "is decommissioned")
+		}
+	}
+	server.storageAddressesMutex.Unlock()
+}
@@ -0,0 +1,119 @@
+package naming_server
+
+import (
+	"context"
+	"fmt"
+	"project-dfs/pb"
+)
+
+// AdminController serves the administrative API used by dfsadmin on the port of the naming service
+type AdminController struct {
+	pb.UnimplementedAdminServer
+	Server *NamingServer
+}
+
+func NewAdminController(server *NamingServer) *AdminController {
+	return &AdminController{
+		Server: server,
+	}
+}
+
+func (ctlr *AdminController) ListNodes(ctx context.Context, request *pb.ListNodesRequest) (*pb.ListNodesResponse, error) {
+	fmt.Println("ListNodes:", request)
+
+	return &pb.ListNodesResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Nodes: ctlr.Server.ListNodes(),
+	}, nil
+}
+
+func (ctlr *AdminController) SetNodeState(ctx context.Context, request *pb.SetNodeStateRequest) (*pb.SetNodeStateResponse, error) {
+	fmt.Println("SetNodeState:", request)
+
+	// draining starts right away; the reconciliation loop finishes what a pass leaves behind
+
+	errno := ctlr.Server.SetNodeState(request.Alias, request.State)
+	if errno != 0 {
+		return &pb.SetNodeStateResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+	if request.State != pb.NodeState_NODE_ACTIVE {
+		go ctlr.Server.Rebalance(context.Background(), false, false)
+	}
+
+	return &pb.SetNodeStateResponse{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "",
+	}}, nil
+}
+
+func (ctlr *AdminController) Rebalance(ctx context.Context, request *pb.RebalanceRequest) (*pb.RebalanceResponse, error) {
+	fmt.Println("Rebalance:", request)
+
+	// restore replication factors, evacuate draining servers and even out stored bytes
+
+	changes, failures := ctlr.Server.Rebalance(ctx, true, request.DryRun)
+	return &pb.RebalanceResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Changes:  changes,
+		Failures: failures,
+	}, nil
+}
+
+func (ctlr *AdminController) ListUnderReplicated(ctx context.Context, request *pb.ListUnderReplicatedRequest) (*pb.ListUnderReplicatedResponse, error) {
+	fmt.Println("ListUnderReplicated:", request)
+
+	return &pb.ListUnderReplicatedResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Files:             ctlr.Server.UnderReplicatedFiles(),
+		ReplicationFactor: ReplicationFactor,
+	}, nil
+}
+
+func (ctlr *AdminController) DumpIndex(ctx context.Context, request *pb.DumpIndexRequest) (*pb.DumpIndexResponse, error) {
+	fmt.Println("DumpIndex:", request)
+
+	root, errno := ctlr.Server.DumpIndex(request.Path)
+	if errno != 0 {
+		return &pb.DumpIndexResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	return &pb.DumpIndexResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Root: root,
+	}, nil
+}
+
+func (ctlr *AdminController) Fsck(ctx context.Context, request *pb.FsckRequest) (*pb.FsckResponse, error) {
+	fmt.Println("Fsck:", request)
+
+	// collect inventories of storage servers, compare them with the index and repair if asked to
+
+	problems, servers, files := ctlr.Server.Fsck(ctx, request.Repair)
+	return &pb.FsckResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Problems:       problems,
+		CheckedServers: servers,
+		CheckedFiles:   files,
+	}, nil
+}
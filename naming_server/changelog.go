@@ -0,0 +1,288 @@
+package naming_server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"project-dfs/pb"
+	"syscall"
+	"time"
+)
+
+// Events are also appended to the changelog on disk, one JSON record per line,
+// so that consumers can read them from any retained sequence number even after a restart.
+type changeRecord struct {
+	Sequence uint64
+	Type     pb.EventType
+	Path     string
+	NewPath  string `json:",omitempty"`
+	Mode     pb.NodeMode
+	Time     int64
+}
+
+// First line of a compacted changelog, so that sequence numbers go on from the dropped records
+// even if none are retained
+type changelogHeader struct {
+	Dropped uint64 // sequence number of the last dropped record
+}
+
+func (record *changeRecord) Event() *pb.Event {
+	return &pb.Event{
+		Sequence: record.Sequence,
+		Type:     record.Type,
+		Path:     record.Path,
+		NewPath:  record.NewPath,
+		Mode:     record.Mode,
+		Time:     record.Time,
+	}
+}
+
+const (
+	DefaultChangesLimit = 1000
+	MaxChangesLimit     = 10000
+)
+
+// Opens the changelog and restores the sequence number and the retained events from it.
+// Records following the ones reflected by the loaded metadata are dropped, as the mutations they report
+// were lost along with the index.
+func (server *NamingServer) OpenChangelog() error {
+	fd, err := os.OpenFile(server.ChangelogPath, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+
+	server.eventsMutex.Lock()
+	defer server.eventsMutex.Unlock()
+
+	reader := bufio.NewReader(fd)
+	offset := int64(0)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// a record cut short by a crash is dropped
+			break
+		}
+		if err != nil {
+			fd.Close()
+			return err
+		}
+
+		if offset == 0 {
+			var header changelogHeader
+			err = json.Unmarshal(line, &header)
+			if err == nil && header.Dropped > 0 {
+				server.eventCounter = header.Dropped
+				offset += int64(len(line))
+				continue
+			}
+		}
+
+		var record changeRecord
+		err = json.Unmarshal(line, &record)
+		if err != nil {
+			fd.Close()
+			return err
+		}
+		if server.savedSequence != nil && record.Sequence > *server.savedSequence {
+			fmt.Println("Dropping changelog records following", *server.savedSequence, "that the metadata does not reflect")
+			break
+		}
+		server.changelogOffsets = append(server.changelogOffsets, offset)
+		offset += int64(len(line))
+
+		server.eventCounter = record.Sequence
+		server.Events = append(server.Events, record.Event())
+		if len(server.Events) > server.EventBuffer {
+			server.Events = server.Events[len(server.Events)-server.EventBuffer:]
+		}
+	}
+
+	err = fd.Truncate(offset)
+	if err == nil {
+		_, err = fd.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		fd.Close()
+		return err
+	}
+	server.changelogFile = fd
+	server.changelogSize = offset
+	return nil
+}
+
+// Appends the event to the changelog and syncs it to disk. Caller has to hold the events mutex.
+func (server *NamingServer) appendChange(event *pb.Event) error {
+	if server.changelogFile == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(&changeRecord{
+		Sequence: event.Sequence,
+		Type:     event.Type,
+		Path:     event.Path,
+		NewPath:  event.NewPath,
+		Mode:     event.Mode,
+		Time:     event.Time,
+	})
+	if err != nil {
+		return err
+	}
+	n, err := server.changelogFile.Write(append(data, '\n'))
+	if err == nil {
+		err = server.changelogFile.Sync()
+	}
+	if err != nil {
+		// the partial record is overwritten by the next one
+		_ = server.changelogFile.Truncate(server.changelogSize)
+		_, _ = server.changelogFile.Seek(server.changelogSize, io.SeekStart)
+		return err
+	}
+	server.changelogOffsets = append(server.changelogOffsets, server.changelogSize)
+	server.changelogSize += int64(n)
+	return nil
+}
+
+// Sequence number of the oldest event in the changelog. Caller has to hold the events mutex.
+func (server *NamingServer) firstChange() uint64 {
+	return server.eventCounter - uint64(len(server.changelogOffsets)) + 1
+}
+
+// Reads up to limit events following the sequence number from the changelog.
+// Returns the events along with the oldest retained and the latest sequence numbers.
+// Fails with ERANGE if the events are no longer retained.
+func (server *NamingServer) ReadChanges(sequence uint64, limit int) ([]*pb.Event, uint64, uint64, error) {
+	server.eventsMutex.Lock()
+	defer server.eventsMutex.Unlock()
+
+	first := server.firstChange()
+	last := server.eventCounter
+	if sequence == 0 {
+		sequence = first - 1
+	}
+	if sequence+1 < first || sequence > last {
+		return nil, first, last, syscall.ERANGE
+	}
+
+	count := int(last - sequence)
+	if count > limit {
+		count = limit
+	}
+	if count == 0 {
+		return nil, first, last, nil
+	}
+
+	start := server.changelogOffsets[sequence+1-first]
+	end := server.changelogSize
+	if sequence+uint64(count) < last {
+		end = server.changelogOffsets[sequence+uint64(count)+1-first]
+	}
+	buf := make([]byte, end-start)
+	_, err := server.changelogFile.ReadAt(buf, start)
+	if err != nil {
+		return nil, first, last, err
+	}
+
+	events := make([]*pb.Event, 0, count)
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	for decoder.More() {
+		var record changeRecord
+		err = decoder.Decode(&record)
+		if err != nil {
+			return nil, first, last, err
+		}
+		events = append(events, record.Event())
+	}
+	return events, first, last, nil
+}
+
+// Drops records older than the retention period from the changelog
+func (server *NamingServer) CompactChangelog() {
+	server.eventsMutex.Lock()
+	defer server.eventsMutex.Unlock()
+
+	if server.changelogFile == nil || server.ChangelogRetention == 0 || len(server.changelogOffsets) == 0 {
+		return
+	}
+
+	// records are ordered by time, so the expired ones are at the beginning
+	cutoff := time.Now().Add(-server.ChangelogRetention).UnixNano() / int64(time.Millisecond)
+	first := server.firstChange()
+	expired := 0
+	for expired < len(server.changelogOffsets) {
+		record, err := server.readChange(first + uint64(expired))
+		if err != nil {
+			println("Error reading changelog:", err.Error())
+			return
+		}
+		if record.Time >= cutoff {
+			break
+		}
+		expired++
+	}
+	if expired == 0 {
+		return
+	}
+
+	// the retained records are copied to a new file that is renamed over the changelog
+	start := server.changelogSize
+	if expired < len(server.changelogOffsets) {
+		start = server.changelogOffsets[expired]
+	}
+	header, err := json.Marshal(&changelogHeader{Dropped: first + uint64(expired) - 1})
+	if err != nil {
+		println("Error compacting changelog:", err.Error())
+		return
+	}
+	header = append(header, '\n')
+	tmpPath := server.ChangelogPath + ".tmp"
+	fd, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		println("Error compacting changelog:", err.Error())
+		return
+	}
+	_, err = fd.Write(header)
+	if err == nil {
+		_, err = io.Copy(fd, io.NewSectionReader(server.changelogFile, start, server.changelogSize-start))
+	}
+	if err == nil {
+		err = fd.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, server.ChangelogPath)
+	}
+	if err != nil {
+		println("Error compacting changelog:", err.Error())
+		fd.Close()
+		_ = os.Remove(tmpPath)
+		return
+	}
+
+	server.changelogFile.Close()
+	server.changelogFile = fd
+	offsets := make([]int64, 0, len(server.changelogOffsets)-expired)
+	for _, offset := range server.changelogOffsets[expired:] {
+		offsets = append(offsets, offset-start+int64(len(header)))
+	}
+	server.changelogOffsets = offsets
+	server.changelogSize += int64(len(header)) - start
+	fmt.Println("Dropped", expired, "expired records from the changelog")
+}
+
+func (server *NamingServer) readChange(sequence uint64) (*changeRecord, error) {
+	i := sequence - server.firstChange()
+	end := server.changelogSize
+	if int(i)+1 < len(server.changelogOffsets) {
+		end = server.changelogOffsets[i+1]
+	}
+	buf := make([]byte, end-server.changelogOffsets[i])
+	_, err := server.changelogFile.ReadAt(buf, server.changelogOffsets[i])
+	if err != nil {
+		return nil, err
+	}
+	var record changeRecord
+	err = json.Unmarshal(buf, &record)
+	return &record, err
+}
@@ -0,0 +1,105 @@
+package naming_server
+
+import (
+	utils "project-dfs"
+	"project-dfs/pb"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func (n *Node) Mode() pb.NodeMode {
+	switch n.Type {
+	case DIR:
+		return pb.NodeMode_DIRECTORY
+	case SYMLINK:
+		return pb.NodeMode_SYMLINK
+	}
+	return pb.NodeMode_REGULAR_FILE
+}
+
+// Records the change of the namespace and wakes up watchers. Caller has to hold the index mutex,
+// so that events are numbered in the order the changes are applied.
+func (server *NamingServer) Notify(t pb.EventType, mode pb.NodeMode, path string, newPath string) {
+	// the trash is internal; moving nodes in and out of it deletes and creates them
+	fromTrash := IsInTrash(path)
+	toTrash := newPath != "" && IsInTrash(newPath)
+	switch {
+	case fromTrash && (newPath == "" || toTrash):
+		return
+	case fromTrash:
+		t, path, newPath = pb.EventType_EVENT_CREATE, newPath, ""
+	case toTrash:
+		t, newPath = pb.EventType_EVENT_DELETE, ""
+	}
+
+	server.eventsMutex.Lock()
+	defer server.eventsMutex.Unlock()
+
+	event := &pb.Event{
+		Sequence: server.eventCounter + 1,
+		Type:     t,
+		Path:     path,
+		NewPath:  newPath,
+		Mode:     mode,
+		Time:     time.Now().UnixNano() / int64(time.Millisecond),
+	}
+	// the sequence number is taken only once the event is in the changelog, which has no gaps
+	err := server.appendChange(event)
+	if err != nil {
+		println("Error appending to changelog; event of", path, "dropped:", err.Error())
+		return
+	}
+	server.eventCounter++
+	server.Events = append(server.Events, event)
+	if len(server.Events) > server.EventBuffer {
+		server.Events = server.Events[len(server.Events)-server.EventBuffer:]
+	}
+
+	close(server.eventsAdded)
+	server.eventsAdded = make(chan struct{})
+}
+
+// Notifies of a change of the file identified by the inode
+func (server *NamingServer) NotifyModified(inode *Inode) {
+	path := server.PathOf(inode)
+	if path != "" && !IsInTrash(path) {
+		server.Notify(pb.EventType_EVENT_MODIFY, pb.NodeMode_REGULAR_FILE, path, "")
+	}
+}
+
+func (server *NamingServer) LastSequence() uint64 {
+	server.eventsMutex.Lock()
+	defer server.eventsMutex.Unlock()
+	return server.eventCounter
+}
+
+// Returns events following the sequence number and the channel closed once more events are recorded.
+// Fails with ERANGE if some of the events are no longer retained.
+func (server *NamingServer) EventsAfter(sequence uint64) ([]*pb.Event, chan struct{}, syscall.Errno) {
+	server.eventsMutex.Lock()
+	defer server.eventsMutex.Unlock()
+
+	oldest := server.eventCounter - uint64(len(server.Events))
+	if sequence < oldest || sequence > server.eventCounter {
+		return nil, nil, syscall.ERANGE
+	}
+	events := server.Events[len(server.Events)-int(server.eventCounter-sequence):]
+	return events, server.eventsAdded, 0
+}
+
+// Reports whether the watch of the path sees the change at the event path
+func watches(path string, recursive bool, eventPath string) bool {
+	if eventPath == path {
+		return true
+	}
+	if recursive {
+		return strings.HasPrefix(eventPath, path+"/")
+	}
+	return eventPath != "" && utils.DirPart(eventPath) == path
+}
+
+func eventMatches(request *pb.WatchRequest, event *pb.Event) bool {
+	return watches(request.Path, request.Recursive, event.Path) ||
+		event.NewPath != "" && watches(request.Path, request.Recursive, event.NewPath)
+}
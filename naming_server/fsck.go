@@ -0,0 +1,202 @@
+package naming_server
+
+import (
+	"context"
+	"fmt"
+	utils "project-dfs"
+	"project-dfs/pb"
+	"sort"
+	"syscall"
+)
+
+// Replicas storage servers hold according to the index, including the ones preserved for snapshots
+type replicaView struct {
+	holders  map[uint64][]string // key:value = fileId:aliases
+	versions map[uint64]uint64   // key:value = fileId:version
+	paths    map[uint64]string   // key:value = fileId:path
+	lastID   uint64              // greatest ID allocated when the view was taken
+}
+
+// Takes the view of the replicas. Caller has to hold the index mutex.
+func (server *NamingServer) replicaView() *replicaView {
+	view := &replicaView{
+		holders:  make(map[uint64][]string),
+		versions: make(map[uint64]uint64),
+		paths:    server.filePaths(),
+		lastID:   server.inodeCounter,
+	}
+	add := func(inode *Inode) {
+		if len(inode.Storages) == 0 {
+			return
+		}
+		view.holders[inode.ID] = nil
+		for _, storage := range inode.Storages {
+			view.holders[inode.ID] = append(view.holders[inode.ID], storage.Alias)
+		}
+		view.versions[inode.ID] = inode.Version
+	}
+
+	for _, inode := range server.Inodes {
+		add(inode)
+	}
+	for name, snapshot := range server.Snapshots {
+		var walk func(n *Node, path string)
+		walk = func(n *Node, path string) {
+			if n.Type == FILE {
+				add(n.Inode)
+				if _, ok := view.paths[n.ID]; !ok {
+					view.paths[n.ID] = path
+				}
+			}
+			for _, child := range n.Children {
+				walk(child, path+"/"+child.Name)
+			}
+		}
+		walk(snapshot.Root, SnapshotsDirectory+"/"+name)
+	}
+	return view
+}
+
+func (view *replicaView) holds(fileId uint64, alias string) bool {
+	return utils.Contains(view.holders[fileId], alias)
+}
+
+// Compares the inventories of the registered storage servers with the index.
+// With repair set, replicas the index does not refer to are deleted, and missing or stale
+// replicas are fetched from up-to-date ones. Servers referred to by the index but not
+// registered are only reported, as they may not have registered since a restart.
+//
+// Storage servers are listed while the index keeps changing, so a replica is only reported
+// as missing or orphaned if it is missing or orphaned both before and after they are listed.
+func (server *NamingServer) Fsck(ctx context.Context, repair bool) ([]*pb.FsckProblem, uint32, uint64) {
+	// rebalancing adds replicas to servers before the index
+	server.rebalanceMutex.Lock()
+	defer server.rebalanceMutex.Unlock()
+
+	server.indexMutex.Lock()
+	before := server.replicaView()
+	server.indexMutex.Unlock()
+
+	server.storageAddressesMutex.Lock()
+	addresses := make(map[string]string, len(server.StorageAddresses))
+	for alias, info := range server.StorageAddresses {
+		addresses[alias] = info.privateAddress
+	}
+	server.storageAddressesMutex.Unlock()
+
+	problems := make([]*pb.FsckProblem, 0)
+	inventories := make(map[string]map[uint64]uint64) // key:value = alias:(fileId:version)
+	for alias, address := range addresses {
+		status := &pb.ErrorStatus{Code: uint32(syscall.EIO), Description: "No connection to storage server " + alias}
+		if ss := server.GetStorageServer(address); ss != nil {
+			response, err := ss.GetInventory(ctx, &pb.InventoryArgs{})
+			if err != nil {
+				status = &pb.ErrorStatus{Code: uint32(syscall.EIO), Description: err.Error()}
+			} else {
+				status = response.ErrorStatus
+			}
+			if err == nil && status.Code == 0 {
+				inventory := make(map[uint64]uint64, len(response.Files))
+				for _, file := range response.Files {
+					inventory[file.FileId] = file.Version
+				}
+				inventories[alias] = inventory
+				continue
+			}
+		}
+		problems = append(problems, &pb.FsckProblem{Kind: pb.FsckProblemKind_FSCK_UNREACHABLE, Alias: alias, ErrorStatus: status})
+	}
+
+	server.indexMutex.Lock()
+	after := server.replicaView()
+	server.indexMutex.Unlock()
+
+	// replicas the index refers to
+	for fileId, aliases := range after.holders {
+		version := after.versions[fileId]
+		var damaged []*pb.FsckProblem
+		var upToDate []string
+		unchecked := false
+		for _, alias := range aliases {
+			inventory, checked := inventories[alias]
+			if !checked {
+				unchecked = true
+				if _, registered := addresses[alias]; !registered {
+					problems = append(problems, &pb.FsckProblem{Kind: pb.FsckProblemKind_FSCK_UNREGISTERED, Alias: alias, FileId: fileId, IndexVersion: version})
+				}
+				continue
+			}
+			held, ok := inventory[fileId]
+			switch {
+			case !ok && before.holds(fileId, alias):
+				damaged = append(damaged, &pb.FsckProblem{Kind: pb.FsckProblemKind_FSCK_MISSING, Alias: alias, FileId: fileId, IndexVersion: version})
+			case ok && held < version:
+				damaged = append(damaged, &pb.FsckProblem{Kind: pb.FsckProblemKind_FSCK_STALE, Alias: alias, FileId: fileId, Version: held, IndexVersion: version})
+			case ok:
+				upToDate = append(upToDate, alias)
+			}
+		}
+		if len(damaged) == 0 {
+			continue
+		}
+		if len(upToDate) == 0 && !unchecked {
+			problems = append(problems, &pb.FsckProblem{Kind: pb.FsckProblemKind_FSCK_LOST, FileId: fileId, IndexVersion: version})
+		}
+
+		for _, problem := range damaged {
+			if repair && len(upToDate) > 0 {
+				fmt.Println("Fsck: fetching replica of file", fileId, "on", problem.Alias, "from", upToDate[0])
+				problem.ErrorStatus = server.fetchReplica(ctx, fileId, version, nil, problem.Alias, upToDate[0])
+				problem.Repaired = problem.ErrorStatus == nil
+			}
+			problems = append(problems, problem)
+		}
+	}
+
+	// replicas no file of the index refers to
+	var checkedFiles uint64
+	for alias, inventory := range inventories {
+		checkedFiles += uint64(len(inventory))
+		for fileId, held := range inventory {
+			if before.holds(fileId, alias) || after.holds(fileId, alias) {
+				continue
+			}
+			if fileId > before.lastID && fileId <= after.lastID {
+				// the file was created while inventories were collected
+				continue
+			}
+			if _, isFile := after.paths[fileId]; isFile && len(before.holders[fileId]) == 0 {
+				// the file is being created, and its replicas are not yet in the index
+				continue
+			}
+
+			problem := &pb.FsckProblem{Kind: pb.FsckProblemKind_FSCK_ORPHAN, Alias: alias, FileId: fileId, Version: held}
+			if repair {
+				fmt.Println("Fsck: removing orphaned replica of file", fileId, "from", alias)
+				status := server.applyStorageOp(ctx, StorageOp{Alias: alias, Kind: RemoveOp, FileID: fileId})
+				if status != nil && status.Code != 0 {
+					problem.ErrorStatus = status
+				}
+				problem.Repaired = problem.ErrorStatus == nil
+			}
+			problems = append(problems, problem)
+		}
+	}
+
+	for _, problem := range problems {
+		problem.Path = after.paths[problem.FileId]
+		if problem.Path == "" {
+			problem.Path = before.paths[problem.FileId]
+		}
+	}
+	sort.Slice(problems, func(i, j int) bool {
+		if problems[i].FileId != problems[j].FileId {
+			return problems[i].FileId < problems[j].FileId
+		}
+		if problems[i].Alias != problems[j].Alias {
+			return problems[i].Alias < problems[j].Alias
+		}
+		return problems[i].Kind < problems[j].Kind
+	})
+	return problems, uint32(len(inventories)), checkedFiles
+}
@@ -0,0 +1,154 @@
+package naming_server
+
+import (
+	"io/ioutil"
+	"os"
+	"project-dfs/pb"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// IDs are reserved on disk in blocks before they are handed out, as the metadata is saved only periodically.
+// After a crash, allocation resumes past the reserved block, so IDs given out since the last save are not reused.
+const idBlock = 1024
+
+// Every inode gets an ID that never changes and is never reused.
+// Storage servers keep the contents of files under their IDs, so changes of the namespace are metadata-only.
+// Caller has to hold the index mutex.
+func (server *NamingServer) allocateID() uint64 {
+	if server.inodeCounter >= server.idLimit {
+		err := server.reserveIDs(server.inodeCounter + idBlock)
+		if err != nil {
+			// handing out IDs that are not reserved could give them out again after a restart
+			println("Error reserving inode IDs:", err.Error())
+			os.Exit(1)
+		}
+	}
+	server.inodeCounter++
+	return server.inodeCounter
+}
+
+func (server *NamingServer) reservedIDsPath() string {
+	return server.MetadataPath + ".ids"
+}
+
+// Saves the limit of the IDs that may have been handed out, synced to disk
+func (server *NamingServer) reserveIDs(limit uint64) error {
+	err := writeFileSynced(server.reservedIDsPath(), []byte(strconv.FormatUint(limit, 10)+"\n"))
+	if err != nil {
+		return err
+	}
+	server.idLimit = limit
+	return nil
+}
+
+// Skips the IDs reserved before the naming server stopped, if any
+func (server *NamingServer) loadReservedIDs() error {
+	data, err := ioutil.ReadFile(server.reservedIDsPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	limit, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return err
+	}
+	if limit > server.inodeCounter {
+		server.inodeCounter = limit
+		server.idLimit = limit
+	}
+	return nil
+}
+
+// Creates a node with a new inode registered in the inode table
+func (server *NamingServer) NewNode(name string, t NodeType) *Node {
+	node := NewNode(name, t)
+	node.ID = server.allocateID()
+	server.Inodes[node.ID] = node.Inode
+	return node
+}
+
+// Calls visit for every node of the subtree, parents before their children
+func (n *Node) Walk(visit func(node *Node)) {
+	visit(n)
+	for _, child := range n.Children {
+		child.Walk(visit)
+	}
+}
+
+// Forgets the inodes of the subtree no longer referred to by any entry.
+// Returns the removals of their contents that storage servers have to apply.
+func (server *NamingServer) ReleaseInodes(n *Node) []StorageOp {
+	var ops []StorageOp
+	n.Walk(func(node *Node) {
+		if node.Links > 0 || node.Opens > 0 {
+			// files deleted while open are released once their last handle is closed
+			return
+		}
+		ops = append(ops, server.releaseInode(node.Inode)...)
+	})
+	return ops
+}
+
+func (server *NamingServer) releaseInode(inode *Inode) []StorageOp {
+	if _, ok := server.Inodes[inode.ID]; !ok {
+		// hard links of the subtree share the inode
+		return nil
+	}
+	delete(server.Inodes, inode.ID)
+	server.DropLease(inode.ID)
+
+	var ops []StorageOp
+	for _, storage := range inode.Storages {
+		ops = append(ops, StorageOp{Alias: storage.Alias, Kind: RemoveOp, FileID: inode.ID})
+	}
+	return ops
+}
+
+// Returns the path of an entry referring to the inode, preferring one outside the trash, or "" if there is none
+func (server *NamingServer) PathOf(inode *Inode) string {
+	found := ""
+	for _, entry := range inode.entries {
+		path := entry.Path()
+		if !IsInTrash(path) {
+			return path
+		}
+		if found == "" {
+			found = path
+		}
+	}
+	return found
+}
+
+// Returns the path of the node in the index
+func (n *Node) Path() string {
+	path := ""
+	for node := n; node.parent != nil; node = node.parent {
+		path = "/" + node.Name + path
+	}
+	return path
+}
+
+// Returns the ID of the file at the path
+func (server *NamingServer) FileID(path string) (uint64, *pb.ErrorStatus) {
+	if IsInSnapshot(path) {
+		return 0, &pb.ErrorStatus{
+			Code:        uint32(syscall.EROFS),
+			Description: "Snapshots are read-only",
+		}
+	}
+	server.indexMutex.Lock()
+	defer server.indexMutex.Unlock()
+
+	node, ok := server.FindNode(path)
+	if !ok || node.Type != FILE {
+		return 0, &pb.ErrorStatus{
+			Code:        uint32(syscall.ENOENT),
+			Description: "No such file",
+		}
+	}
+	return node.ID, nil
+}
@@ -0,0 +1,104 @@
+package naming_server
+
+import (
+	"fmt"
+	"project-dfs/pb"
+	"syscall"
+	"time"
+)
+
+// Lease makes one of the replicas the primary of the file until it expires
+type Lease struct {
+	Holder  string // alias of the primary storage server
+	Expires time.Time
+}
+
+func (lease *Lease) IsValid() bool {
+	return time.Now().Before(lease.Expires)
+}
+
+// Returns the valid lease on the file or grants a new one.
+// The requesting storage server is preferred as the primary if it holds a replica.
+func (server *NamingServer) GrantLease(fileId uint64, inode *Inode, requester string) (Lease, bool) {
+	server.leasesMutex.Lock()
+	defer server.leasesMutex.Unlock()
+
+	lease, ok := server.Leases[fileId]
+	if ok && lease.IsValid() && inode.HasStorage(lease.Holder) {
+		return *lease, true
+	}
+
+	holder := ""
+	if inode.HasStorage(requester) {
+		holder = requester
+	} else {
+		for _, storage := range inode.Storages {
+			if server.IsAlive(storage.Alias) {
+				holder = storage.Alias
+				break
+			}
+		}
+	}
+	if holder == "" {
+		return Lease{}, false
+	}
+
+	lease = &Lease{
+		Holder:  holder,
+		Expires: time.Now().Add(server.LeaseDuration),
+	}
+	server.Leases[fileId] = lease
+	fmt.Println("Granted lease on file", fileId, "to", holder)
+	return *lease, true
+}
+
+// Returns the primary replica of the file, granting a lease if there is none
+func (server *NamingServer) PrimaryOf(fileId uint64, requester string) (Lease, *StorageServerInfo, *pb.ErrorStatus) {
+	server.indexMutex.Lock()
+	inode, ok := server.Inodes[fileId]
+	if !ok {
+		server.indexMutex.Unlock()
+		return Lease{}, nil, &pb.ErrorStatus{
+			Code:        uint32(syscall.ENOENT),
+			Description: "No such file",
+		}
+	}
+	lease, ok := server.GrantLease(fileId, inode, requester)
+	server.indexMutex.Unlock()
+
+	var info *StorageServerInfo
+	if ok {
+		info, ok = server.GetAddress(lease.Holder)
+	}
+	if !ok {
+		return Lease{}, nil, &pb.ErrorStatus{
+			Code:        uint32(syscall.EAGAIN),
+			Description: "No replica available to become primary",
+		}
+	}
+	return lease, info, nil
+}
+
+// Extends the leases held by the storage server. Returns the IDs of the files with renewed leases.
+func (server *NamingServer) RenewLeases(holder string, fileIds []uint64) []uint64 {
+	server.leasesMutex.Lock()
+	defer server.leasesMutex.Unlock()
+
+	var renewed []uint64
+	for _, fileId := range fileIds {
+		lease, ok := server.Leases[fileId]
+		if !ok || lease.Holder != holder || !lease.IsValid() {
+			continue
+		}
+		lease.Expires = time.Now().Add(server.LeaseDuration)
+		renewed = append(renewed, fileId)
+	}
+	return renewed
+}
+
+// Revokes the lease on the file
+func (server *NamingServer) DropLease(fileId uint64) {
+	server.leasesMutex.Lock()
+	defer server.leasesMutex.Unlock()
+	delete(server.Leases, fileId)
+}
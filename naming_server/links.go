@@ -0,0 +1,230 @@
+package naming_server
+
+import (
+	"context"
+	utils "project-dfs"
+	"project-dfs/pb"
+	"strings"
+	"syscall"
+)
+
+// Finds the directory that is going to hold the new entry, checking that the entry does not exist yet
+func (server *NamingServer) lookupNewEntry(path string) (*Node, syscall.Errno) {
+	if path == "" {
+		return nil, syscall.EEXIST
+	}
+	if IsInSnapshot(path) {
+		return nil, syscall.EROFS
+	}
+
+	parent, errno := server.LookupNode(utils.DirPart(path))
+	if errno != 0 {
+		return nil, errno
+	}
+	if parent.Type != DIR {
+		return nil, syscall.ENOTDIR
+	}
+	if parent.GetChild(utils.NamePart(path)) != nil {
+		return nil, syscall.EEXIST
+	}
+	return parent, 0
+}
+
+// Creates a symbolic link at the path. The target is kept as is and is resolved by clients.
+func (server *NamingServer) Symlink(target string, path string, owner string) syscall.Errno {
+	if target == "" {
+		return syscall.ENOENT
+	}
+	parent, errno := server.lookupNewEntry(path)
+	if errno != 0 {
+		return errno
+	}
+	errno = server.CheckQuotas(path, owner, int64(len(target)), 1)
+	if errno != 0 {
+		return errno
+	}
+
+	node := server.NewNode(utils.NamePart(path), SYMLINK)
+	node.Owner = owner
+	node.Target = target
+	node.Size = int64(len(target))
+	parent.AddChild(node)
+	server.Notify(pb.EventType_EVENT_CREATE, pb.NodeMode_SYMLINK, path, "")
+	return 0
+}
+
+// Adds an entry at the new path for the inode of the file.
+// Storage servers keep the file by ID, so they are not involved.
+func (server *NamingServer) Link(path string, newPath string) syscall.Errno {
+	if IsInSnapshot(path) {
+		return syscall.EXDEV
+	}
+	node, errno := server.LookupNode(path)
+	if errno != 0 {
+		return errno
+	}
+	if node.Type == DIR {
+		return syscall.EPERM
+	}
+	parent, errno := server.lookupNewEntry(newPath)
+	if errno != 0 {
+		return errno
+	}
+	errno = server.CheckMoveQuotas(node, path, newPath)
+	if errno != 0 {
+		return errno
+	}
+
+	link := &Node{
+		Name:     utils.NamePart(newPath),
+		Type:     node.Type,
+		Children: make([]*Node, 0),
+		Inode:    node.Inode,
+	}
+	node.Links++
+	parent.AddChild(link)
+	server.Notify(pb.EventType_EVENT_CREATE, link.Mode(), newPath, "")
+	return 0
+}
+
+// Copies the node to the new path; the copy is owned by the owner.
+// Replicas are copied by storage servers without the index locked, and the copy enters the index
+// once they are in place. Returns the copies of replicas that failed along with their errors.
+func (server *NamingServer) Copy(ctx context.Context, path string, newPath string, owner string) ([]StorageOp, []*pb.ErrorStatus, syscall.Errno) {
+	server.indexMutex.Lock()
+	node, ok := server.FindNode(path)
+	if !ok {
+		server.indexMutex.Unlock()
+		return nil, nil, syscall.ENOENT
+	}
+	errno := server.checkCopy(node, path, newPath, owner)
+	if errno != 0 {
+		server.indexMutex.Unlock()
+		return nil, nil, errno
+	}
+	var ops []StorageOp
+	copied := server.copyNode(node, owner, &ops)
+	copied.Name = utils.NamePart(newPath)
+	server.indexMutex.Unlock()
+
+	var failed []StorageOp
+	var statuses []*pb.ErrorStatus
+	copies := make(map[uint64]map[string]*pb.StoredFile) // key:value = fileId of the copy:(alias:copied file)
+	for _, op := range ops {
+		file, status := server.copyFile(ctx, op)
+		if status != nil && status.Code != 0 {
+			println("Error copying file", op.FileID, "on", op.Alias, ":", status.Description)
+			failed = append(failed, op)
+			statuses = append(statuses, status)
+			continue
+		}
+		if copies[op.NewFileID] == nil {
+			copies[op.NewFileID] = make(map[string]*pb.StoredFile)
+		}
+		copies[op.NewFileID][op.Alias] = file
+	}
+
+	server.indexMutex.Lock()
+	for _, op := range failed {
+		if inode, ok := server.Inodes[op.NewFileID]; ok {
+			inode.RemoveReplica(op.Alias)
+		}
+	}
+	var removals []StorageOp // copies of replicas left out of the index
+	copied.Walk(func(n *Node) {
+		holders, ok := copies[n.ID]
+		if !ok {
+			return
+		}
+		// the source may have been written meanwhile, so the copies are at the version they were made from
+		var latest *pb.StoredFile
+		for _, file := range holders {
+			if latest == nil || file.Version > latest.Version {
+				latest = file
+			}
+		}
+		n.Version = latest.Version
+		n.Size = latest.Size
+		for alias, file := range holders {
+			if file.Version < latest.Version {
+				n.RemoveReplica(alias)
+				removals = append(removals, StorageOp{Alias: alias, Kind: RemoveOp, FileID: n.ID})
+			}
+		}
+	})
+
+	// the index may have changed while the replicas were copied
+	parent, errno := server.lookupNewEntry(newPath)
+	if errno == 0 {
+		errno = server.checkCopy(copied, path, newPath, owner)
+	}
+	if errno != 0 {
+		copied.AdjustLinks(-1)
+		removals = append(removals, server.ReleaseInodes(copied)...)
+	} else {
+		parent.AddChild(copied)
+		server.Notify(pb.EventType_EVENT_CREATE, copied.Mode(), newPath, "")
+	}
+	server.indexMutex.Unlock()
+
+	server.ApplyStorageOps(ctx, removals)
+	if errno != 0 {
+		return nil, nil, errno
+	}
+	return failed, statuses, 0
+}
+
+// Checks that the subtree of the node can be copied to the new path
+func (server *NamingServer) checkCopy(node *Node, path string, newPath string, owner string) syscall.Errno {
+	_, errno := server.lookupNewEntry(newPath)
+	if errno != 0 {
+		return errno
+	}
+	// a directory cannot be copied into its own subtree
+	if strings.HasPrefix(newPath, path+"/") {
+		return syscall.EINVAL
+	}
+	usage := node.Usage("")
+	return server.CheckQuotas(newPath, owner, usage.Bytes, usage.Inodes)
+}
+
+// Makes the storage server copy the replica. Returns the copy along with the version it was made at.
+func (server *NamingServer) copyFile(ctx context.Context, op StorageOp) (*pb.StoredFile, *pb.ErrorStatus) {
+	info, ok := server.GetAddress(op.Alias)
+	if !ok {
+		return nil, &pb.ErrorStatus{
+			Code:        uint32(syscall.ENXIO),
+			Description: "Storage server " + op.Alias + " is not registered",
+		}
+	}
+	ss := server.GetStorageServer(info.privateAddress)
+	if ss == nil {
+		return nil, &pb.ErrorStatus{
+			Code:        uint32(syscall.EIO),
+			Description: "No connection to storage server " + op.Alias,
+		}
+	}
+
+	response, err := ss.Copy(ctx, &pb.CopyArgs{FileId: op.FileID, NewFileId: op.NewFileID})
+	if err != nil {
+		return nil, &pb.ErrorStatus{Code: uint32(syscall.EIO), Description: err.Error()}
+	}
+	return response.File, response.ErrorStatus
+}
+
+func (server *NamingServer) copyNode(n *Node, owner string, ops *[]StorageOp) *Node {
+	copied := server.NewNode(n.Name, n.Type)
+	copied.Owner = owner
+	copied.Version = n.Version
+	copied.Size = n.Size
+	copied.Target = n.Target
+
+	for _, storage := range n.Storages {
+		copied.Storages = append(copied.Storages, &StorageInfo{Alias: storage.Alias})
+		*ops = append(*ops, StorageOp{Alias: storage.Alias, Kind: CopyOp, FileID: n.ID, NewFileID: copied.ID})
+	}
+	for _, child := range n.Children {
+		copied.AddChild(server.copyNode(child, owner, ops))
+	}
+	return copied
+}
@@ -0,0 +1,115 @@
+package naming_server
+
+import (
+	"math"
+	"project-dfs/pb"
+	"syscall"
+)
+
+// Advisory lock of a session on the byte range [Start, End) of a file
+type RangeLock struct {
+	Session   *Session
+	Exclusive bool
+	Start     int64
+	End       int64 // math.MaxInt64 if the lock extends to the end of the file
+}
+
+func (lock *RangeLock) Overlaps(start int64, end int64) bool {
+	return lock.Start < end && start < lock.End
+}
+
+func (lock *RangeLock) Info() *pb.FileLock {
+	info := &pb.FileLock{
+		Type:      pb.LockType_F_RDLCK,
+		Start:     lock.Start,
+		SessionId: lock.Session.ID,
+		User:      lock.Session.User,
+	}
+	if lock.Exclusive {
+		info.Type = pb.LockType_F_WRLCK
+	}
+	if lock.End != math.MaxInt64 {
+		info.Length = lock.End - lock.Start
+	}
+	return info
+}
+
+// Converts the range of a request to [start, end); zero length extends the range to the end of the file
+func lockRange(start int64, length int64) (int64, int64, syscall.Errno) {
+	if start < 0 || length < 0 || (length > 0 && start > math.MaxInt64-length) {
+		return 0, 0, syscall.EINVAL
+	}
+	if length == 0 {
+		return start, math.MaxInt64, 0
+	}
+	return start, start + length, 0
+}
+
+// Returns a lock of another session that conflicts with the lock. Caller has to hold the index mutex.
+func (server *NamingServer) FindConflict(fileId uint64, session *Session, exclusive bool, start int64, end int64) *RangeLock {
+	for _, lock := range server.Locks[fileId] {
+		if lock.Session != session && lock.Overlaps(start, end) && (exclusive || lock.Exclusive) {
+			return lock
+		}
+	}
+	return nil
+}
+
+// Places the lock of the session through the handle, replacing locks the session holds on the range.
+// Returns the conflicting lock if the lock cannot be placed. Caller has to hold the index mutex.
+func (server *NamingServer) SetLock(session *Session, handle *Handle, exclusive bool, start int64, end int64) *RangeLock {
+	fileId := handle.Inode.ID
+	conflict := server.FindConflict(fileId, session, exclusive, start, end)
+	if conflict != nil {
+		return conflict
+	}
+
+	server.Unlock(session, fileId, start, end)
+	server.Locks[fileId] = append(server.Locks[fileId], &RangeLock{
+		Session:   session,
+		Exclusive: exclusive,
+		Start:     start,
+		End:       end,
+	})
+	return nil
+}
+
+// Releases locks of the session on the range, splitting the ones that extend beyond it.
+// Caller has to hold the index mutex.
+func (server *NamingServer) Unlock(session *Session, fileId uint64, start int64, end int64) {
+	var kept []*RangeLock
+	for _, lock := range server.Locks[fileId] {
+		if lock.Session != session || !lock.Overlaps(start, end) {
+			kept = append(kept, lock)
+			continue
+		}
+		if lock.Start < start {
+			kept = append(kept, &RangeLock{Session: session, Exclusive: lock.Exclusive, Start: lock.Start, End: start})
+		}
+		if lock.End > end {
+			kept = append(kept, &RangeLock{Session: session, Exclusive: lock.Exclusive, Start: end, End: lock.End})
+		}
+	}
+
+	if len(kept) == 0 {
+		delete(server.Locks, fileId)
+	} else {
+		server.Locks[fileId] = kept
+	}
+	server.notifyLockWaiters()
+}
+
+// Wakes up requests waiting for conflicting locks to be released
+func (server *NamingServer) notifyLockWaiters() {
+	close(server.locksChanged)
+	server.locksChanged = make(chan struct{})
+}
+
+// Checks that the handle of the session allows a lock of the type, like fcntl(2) does for file descriptors
+func checkLockAccess(handle *Handle, exclusive bool) syscall.Errno {
+	readable := handle.Flags&uint32(pb.OpenFlag_O_WRONLY) == 0
+	if exclusive && !handle.IsWritable() || !exclusive && !readable {
+		return syscall.EBADF
+	}
+	return 0
+}
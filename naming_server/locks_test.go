@@ -0,0 +1,215 @@
+package naming_server
+
+import (
+	"context"
+	"math"
+	"project-dfs/pb"
+	"syscall"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Opens the file for reading and writing in a new session of the user
+func openTestHandle(t *testing.T, server *NamingServer, user string, path string) (*Session, *Handle) {
+	session := server.OpenSession(user)
+	handle, errno := server.OpenFile(session, path, uint32(pb.OpenFlag_O_RDWR))
+	if errno != 0 {
+		t.Fatal(errno)
+	}
+	return session, handle
+}
+
+func TestLockConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		exclusive bool // of both locks
+		start     int64
+		end       int64
+		conflicts bool
+	}{
+		{"shared over shared", false, 50, 150, false},
+		{"exclusive over exclusive", true, 50, 150, true},
+		{"range before", true, 0, 100, false},
+		{"range after", true, 200, 300, false},
+		{"overlapping start", true, 0, 101, true},
+		{"overlapping end", true, 199, 300, true},
+		{"to end of file", true, 150, math.MaxInt64, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newTestServer(t)
+			createPaths(t, server, "alice", "/f")
+			alice, aliceHandle := openTestHandle(t, server, "alice", "/f")
+			bob, bobHandle := openTestHandle(t, server, "bob", "/f")
+
+			// a session replaces its own locks instead of conflicting with them
+			for _, exclusive := range []bool{!test.exclusive, test.exclusive} {
+				if conflict := server.SetLock(alice, aliceHandle, exclusive, 100, 200); conflict != nil {
+					t.Fatalf("lock of the only session conflicts with %+v", conflict)
+				}
+			}
+			if locks := server.Locks[aliceHandle.Inode.ID]; len(locks) != 1 || locks[0].Exclusive != test.exclusive {
+				t.Fatalf("locks of the session = %v; want the one replacing the others", locks)
+			}
+			conflict := server.SetLock(bob, bobHandle, test.exclusive, test.start, test.end)
+			if (conflict != nil) != test.conflicts {
+				t.Fatalf("lock of [%d, %d) conflicts with %+v; want conflict %v", test.start, test.end, conflict, test.conflicts)
+			}
+			if conflict != nil && conflict.Session != alice {
+				t.Fatalf("conflicting lock of session %d; want %d", conflict.Session.ID, alice.ID)
+			}
+		})
+	}
+}
+
+func TestUnlockSplitsRange(t *testing.T) {
+	server := newTestServer(t)
+	createPaths(t, server, "alice", "/f")
+	alice, aliceHandle := openTestHandle(t, server, "alice", "/f")
+	bob, bobHandle := openTestHandle(t, server, "bob", "/f")
+	server.SetLock(alice, aliceHandle, true, 0, 300)
+	server.Unlock(alice, aliceHandle.Inode.ID, 100, 200)
+
+	if conflict := server.FindConflict(bobHandle.Inode.ID, bob, true, 100, 200); conflict != nil {
+		t.Fatalf("unlocked range conflicts with %+v", conflict)
+	}
+	for _, start := range []int64{0, 200} {
+		if conflict := server.FindConflict(bobHandle.Inode.ID, bob, true, start, start+100); conflict == nil {
+			t.Fatalf("range [%d, %d) left locked is free", start, start+100)
+		}
+	}
+}
+
+func TestLocksReleasedOnSessionExpiry(t *testing.T) {
+	server := newTestServer(t)
+	createPaths(t, server, "alice", "/f")
+	alice, aliceHandle := openTestHandle(t, server, "alice", "/f")
+	bob, bobHandle := openTestHandle(t, server, "bob", "/f")
+	server.SetLock(alice, aliceHandle, true, 0, math.MaxInt64)
+
+	alice.Expires = time.Now().Add(-time.Second)
+	server.ExpireSessions()
+	if _, errno := server.FindSession(alice.ID); errno != syscall.ESTALE {
+		t.Fatalf("expired session is found: %v", errno)
+	}
+	if locks := server.Locks[aliceHandle.Inode.ID]; len(locks) != 0 {
+		t.Fatalf("locks %v of the expired session are kept", locks)
+	}
+	if conflict := server.SetLock(bob, bobHandle, true, 0, math.MaxInt64); conflict != nil {
+		t.Fatalf("lock conflicts with %+v of the expired session", conflict)
+	}
+}
+
+// Lock request stream delivering the responses to the test
+type lockStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses chan *pb.LockResponse
+}
+
+func (stream *lockStream) Send(response *pb.LockResponse) error {
+	stream.responses <- response
+	return nil
+}
+
+func (stream *lockStream) Context() context.Context {
+	return stream.ctx
+}
+
+// Starts a request waiting for the lock of the whole file
+func waitForLock(ctlr *NamingServerController, session *Session, handle *Handle) (*lockStream, chan error) {
+	stream := &lockStream{ctx: context.Background(), responses: make(chan *pb.LockResponse, 2)}
+	done := make(chan error, 1)
+	go func() {
+		done <- ctlr.Lock(&pb.LockRequest{
+			SessionId: session.ID,
+			Handle:    handle.ID,
+			Type:      pb.LockType_F_WRLCK,
+			Wait:      true,
+		}, stream)
+	}()
+	return stream, done
+}
+
+func receiveLockResponse(t *testing.T, stream *lockStream) *pb.LockResponse {
+	select {
+	case response := <-stream.responses:
+		return response
+	case <-time.After(5 * time.Second):
+		t.Fatal("no response to the lock request")
+		return nil
+	}
+}
+
+func TestBlockedLockWakes(t *testing.T) {
+	for _, release := range []string{"unlock", "close", "expiry"} {
+		t.Run(release, func(t *testing.T) {
+			server := newTestServer(t)
+			ctlr := &NamingServerController{Server: server}
+			createPaths(t, server, "alice", "/f")
+			alice, aliceHandle := openTestHandle(t, server, "alice", "/f")
+			bob, bobHandle := openTestHandle(t, server, "bob", "/f")
+			server.SetLock(alice, aliceHandle, false, 0, 100)
+
+			stream, done := waitForLock(ctlr, bob, bobHandle)
+			response := receiveLockResponse(t, stream)
+			if response.Granted || response.Conflict == nil || response.Conflict.SessionId != alice.ID {
+				t.Fatalf("first response = %v; want the conflict with session %d", response, alice.ID)
+			}
+
+			server.indexMutex.Lock()
+			switch release {
+			case "unlock":
+				server.Unlock(alice, aliceHandle.Inode.ID, 0, math.MaxInt64)
+			case "close":
+				server.CloseHandle(alice, aliceHandle)
+			case "expiry":
+				alice.Expires = time.Now().Add(-time.Second)
+			}
+			server.indexMutex.Unlock()
+			if release == "expiry" {
+				server.ExpireSessions()
+			}
+
+			response = receiveLockResponse(t, stream)
+			if !response.Granted || response.ErrorStatus.Code != 0 {
+				t.Fatalf("response after the %s = %v; want the lock granted", release, response)
+			}
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+			if locks := server.Locks[bobHandle.Inode.ID]; len(locks) != 1 || locks[0].Session != bob {
+				t.Fatalf("locks of the file = %v; want the one of session %d", locks, bob.ID)
+			}
+		})
+	}
+}
+
+func TestBlockedLockGivesUp(t *testing.T) {
+	server := newTestServer(t)
+	ctlr := &NamingServerController{Server: server}
+	createPaths(t, server, "alice", "/f")
+	alice, aliceHandle := openTestHandle(t, server, "alice", "/f")
+	bob, bobHandle := openTestHandle(t, server, "bob", "/f")
+	server.SetLock(alice, aliceHandle, true, 0, math.MaxInt64)
+
+	// the waiting session expires
+	stream, done := waitForLock(ctlr, bob, bobHandle)
+	receiveLockResponse(t, stream)
+	server.indexMutex.Lock()
+	bob.Expires = time.Now().Add(-time.Second)
+	server.indexMutex.Unlock()
+	server.ExpireSessions()
+	response := receiveLockResponse(t, stream)
+	if response.Granted || response.ErrorStatus.Code != uint32(syscall.ESTALE) {
+		t.Fatalf("response after the session expired = %v; want ESTALE", response)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if locks := server.Locks[aliceHandle.Inode.ID]; len(locks) != 1 || locks[0].Session != alice {
+		t.Fatalf("locks of the file = %v; want the one of session %d", locks, alice.ID)
+	}
+}
@@ -1,6 +1,7 @@
 package naming_server
 
 import (
+	"context"
 	"fmt"
 	"google.golang.org/grpc"
 	"math/rand"
@@ -8,8 +9,11 @@ import (
 	"os"
 	utils "project-dfs"
 	"project-dfs/pb"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 )
 
 type StorageInfo struct {
@@ -23,15 +27,40 @@ func (info *StorageInfo) String() string {
 type NodeType int32
 
 const (
-	DIR  NodeType = 1
-	FILE NodeType = 2
+	DIR     NodeType = 1
+	FILE    NodeType = 2
+	SYMLINK NodeType = 3
 )
 
+// Inode is the object behind a directory entry. Hard links are nodes sharing the same inode.
+type Inode struct {
+	ID       uint64 // stable ID under which storage servers keep the contents of the file
+	Storages []*StorageInfo
+	Version  uint64 // bumped on every committed write to the file
+	Size     int64
+	History  []FileVersion // saved versions of the file, oldest first
+	Owner    string
+	Reserved int64  // size the file is about to grow to, reserved by its primary replica
+	Links    int    // number of directory entries referring to the inode, including the ones in the trash
+	Target   string // path the symbolic link points to
+	Xattrs   map[string][]byte
+	Opens    int `json:"-"` // number of open handles; a file unlinked while open keeps its contents until they are closed
+
+	trackers []*usageTracker // usage of the directories with an entry of the file
+	entries  []*Node         // entries of the index referring to the inode
+}
+
 type Node struct {
 	Name     string // name of a file or directory
 	Type     NodeType
 	Children []*Node
-	Storages []*StorageInfo
+	*Inode
+
+	Versioning *VersioningPolicy // set on directories keeping versions of their files
+	Quota      *Quota            // set on directories limiting usage of their subtree
+
+	parent *Node
+	usage  *usageTracker // kept for the root and for directories with a quota
 }
 
 func (n *Node) GetChildrenNames() []string {
@@ -52,6 +81,15 @@ func (n *Node) GetChild(name string) *Node {
 	return nil
 }
 
+func (inode *Inode) HasStorage(alias string) bool {
+	for _, storage := range inode.Storages {
+		if storage.Alias == alias {
+			return true
+		}
+	}
+	return false
+}
+
 func (n *Node) RemoveChild(name string) {
 	index := -1
 	for i, child := range n.Children {
@@ -63,11 +101,16 @@ func (n *Node) RemoveChild(name string) {
 		println("Couldn't remove child", name, "from node", n.Name, "as it doesn't exist")
 		return
 	}
+	child := n.Children[index]
 	n.Children = append(n.Children[:index], n.Children[index+1:]...)
+	n.trackSubtree(child, -1)
+	child.parent = nil
 }
 
 func (n *Node) AddChild(node *Node) {
 	n.Children = append(n.Children, node)
+	node.parent = n
+	n.trackSubtree(node, 1)
 }
 
 func NewNode(name string, t NodeType) *Node {
@@ -75,11 +118,40 @@ func NewNode(name string, t NodeType) *Node {
 		Name:     name,
 		Type:     t,
 		Children: make([]*Node, 0),
-		Storages: make([]*StorageInfo, 0),
+		Inode: &Inode{
+			Storages: make([]*StorageInfo, 0),
+			Links:    1,
+		},
 	}
 }
 
+// Counts the entries of the subtree in or out of the link counts of their inodes
+func (n *Node) AdjustLinks(delta int) {
+	n.Links += delta
+	for _, child := range n.Children {
+		child.AdjustLinks(delta)
+	}
+}
+
+// Link count as reported by stat(2); a directory is referred to by its entry, by itself and by its subdirectories
+func (n *Node) LinkCount() int {
+	if n.Type != DIR {
+		return n.Links
+	}
+	count := 2
+	for _, child := range n.Children {
+		if child.Type == DIR {
+			count++
+		}
+	}
+	return count
+}
+
 func (server *NamingServer) FindNode(path string) (*Node, bool) {
+	if IsInSnapshot(path) {
+		return server.FindSnapshotNode(path)
+	}
+
 	node := server.RootIndexNode
 
 	segments := strings.Split(path, "/")[1:]
@@ -94,19 +166,25 @@ func (server *NamingServer) FindNode(path string) (*Node, bool) {
 			}
 		}
 		if !updated {
-			println("warning: no node found in FindNode for path " + path)
 			return nil, false
 		}
 	}
 
-	fmt.Println("FindNode returned storages", node.Storages, "for", path, "; segments are:", segments)
 	return node, true
 }
 
-func (server *NamingServer) CreateNodeIfNotExists(path string, lastNodeIsFile bool) *Node {
+// Creates the missing nodes of the path, the last one being a file if set. Fails if a component
+// on the way is not a directory.
+func (server *NamingServer) CreateNodeIfNotExists(path string, lastNodeIsFile bool, owner string) (*Node, syscall.Errno) {
 	segments := strings.Split(path, "/")[1:]
 	node := server.RootIndexNode
+	nodePath := ""
 	for _, s := range segments {
+		if node.Type != DIR {
+			// symbolic links are not followed; clients resolve them
+			return nil, syscall.ENOTDIR
+		}
+		nodePath += "/" + s
 		exists := false
 		for _, child := range node.Children {
 			if child.Name == s {
@@ -116,39 +194,337 @@ func (server *NamingServer) CreateNodeIfNotExists(path string, lastNodeIsFile bo
 			}
 		}
 		if !exists {
-			fmt.Println("Creating new node", path)
-
 			t := DIR
 			if lastNodeIsFile && s == segments[len(segments)-1] {
 				t = FILE
 			}
 
-			n := &Node{
-				Name:     s,
-				Type:     t,
-				Children: []*Node{},
-				Storages: []*StorageInfo{},
-			}
-			node.Children = append(node.Children, n)
+			n := server.NewNode(s, t)
+			n.Owner = owner
+			node.AddChild(n)
 			node = n
+			server.Notify(pb.EventType_EVENT_CREATE, n.Mode(), nodePath, "")
+		}
+	}
+
+	return node, 0
+}
+
+// Adds the new file to the index, creating missing directories on the way. Fails if the path exists.
+func (server *NamingServer) CreateFile(path string, owner string) (*Node, syscall.Errno) {
+	if IsInSnapshot(path) {
+		return nil, syscall.EROFS
+	}
+	if _, exists := server.FindNode(path); exists {
+		return nil, syscall.EEXIST
+	}
+	errno := server.CheckQuotas(path, owner, 0, server.MissingNodes(path))
+	if errno != 0 {
+		return nil, errno
+	}
+	return server.CreateNodeIfNotExists(path, true, owner)
+}
+
+// Creates replicas of the new file on two storage servers
+func (server *NamingServer) PlaceFile(ctx context.Context, node *Node) {
+	for _, s := range server.Get2RandomStorageServers() {
+		fmt.Println("Sending create file request to storage server", s.Alias)
+		ss := server.GetStorageServer(s.Address)
+		response, err := ss.CreateFile(ctx, &pb.CreateFileArgs{FileId: node.ID})
+		if err != nil {
+			println("Error creating file:", err.Error())
+			continue
+		}
+		if response.ErrorStatus.Code != 0 {
+			println("Error during file creation:", response.ErrorStatus.Description)
+			continue
+		}
+		server.indexMutex.Lock()
+		node.Storages = append(node.Storages, &StorageInfo{Alias: s.Alias})
+		server.indexMutex.Unlock()
+		fmt.Println("Storage", s.Alias, "added to node", node.Name)
+	}
+}
+
+// Finds the node by path, telling apart a missing path from a path that goes through a file
+func (server *NamingServer) LookupNode(path string) (*Node, syscall.Errno) {
+	node := server.RootIndexNode
+	if path == "" {
+		return node, 0
+	}
+
+	for _, s := range strings.Split(path, "/")[1:] {
+		if node.Type != DIR {
+			// symbolic links are not followed; clients resolve them
+			return nil, syscall.ENOTDIR
+		}
+		node = node.GetChild(s)
+		if node == nil {
+			return nil, syscall.ENOENT
 		}
 	}
+	return node, 0
+}
 
-	fmt.Println("Returning node", node.Name, "with children", node.Children)
-	return node
+// Returns aliases of all storage servers holding files of the subtree
+func (n *Node) SubtreeStorages() []string {
+	var aliases []string
+	n.collectStorages(&aliases)
+	return aliases
+}
+
+func (n *Node) collectStorages(aliases *[]string) {
+	for _, storage := range n.Storages {
+		if !utils.Contains(*aliases, storage.Alias) {
+			*aliases = append(*aliases, storage.Alias)
+		}
+	}
+	for _, child := range n.Children {
+		child.collectStorages(aliases)
+	}
+}
+
+type StorageOpKind int
+
+const (
+	RemoveOp StorageOpKind = iota
+	CopyOp
+)
+
+// Change of the files on a storage server that follows a change of the index
+type StorageOp struct {
+	Alias     string
+	Kind      StorageOpKind
+	FileID    uint64
+	NewFileID uint64
+}
+
+// Renames the node in the index following rename(2) semantics.
+// Returns the removals of the replaced file that storage servers have to apply.
+func (server *NamingServer) Rename(path string, newPath string, flags uint32) ([]StorageOp, syscall.Errno) {
+	noReplace := flags&uint32(pb.RenameFlag_RENAME_NOREPLACE) != 0
+	exchange := flags&uint32(pb.RenameFlag_RENAME_EXCHANGE) != 0
+	if (noReplace && exchange) || flags&^uint32(pb.RenameFlag_RENAME_NOREPLACE|pb.RenameFlag_RENAME_EXCHANGE) != 0 {
+		return nil, syscall.EINVAL
+	}
+	if path == "" || newPath == "" {
+		return nil, syscall.EBUSY
+	}
+	if IsInSnapshot(path) || IsInSnapshot(newPath) {
+		return nil, syscall.EROFS
+	}
+
+	oldParent, errno := server.LookupNode(utils.DirPart(path))
+	if errno != 0 {
+		return nil, errno
+	}
+	newParent, errno := server.LookupNode(utils.DirPart(newPath))
+	if errno != 0 {
+		return nil, errno
+	}
+	if oldParent.Type != DIR || newParent.Type != DIR {
+		return nil, syscall.ENOTDIR
+	}
+
+	oldName := utils.NamePart(path)
+	newName := utils.NamePart(newPath)
+	node := oldParent.GetChild(oldName)
+	if node == nil {
+		return nil, syscall.ENOENT
+	}
+	target := newParent.GetChild(newName)
+	if exchange && target == nil {
+		return nil, syscall.ENOENT
+	}
+	if path == newPath {
+		return nil, 0
+	}
+
+	// a directory cannot be moved into its own subtree
+	if strings.HasPrefix(newPath, path+"/") || (exchange && strings.HasPrefix(path, newPath+"/")) {
+		return nil, syscall.EINVAL
+	}
+
+	errno = server.CheckMoveQuotas(node, path, newPath)
+	if errno == 0 && exchange {
+		errno = server.CheckMoveQuotas(target, newPath, path)
+	}
+	if errno != 0 {
+		return nil, errno
+	}
+
+	if exchange {
+		oldParent.RemoveChild(oldName)
+		newParent.RemoveChild(newName)
+		node.Name = newName
+		target.Name = oldName
+		newParent.AddChild(node)
+		oldParent.AddChild(target)
+		server.Notify(pb.EventType_EVENT_MOVE, node.Mode(), path, newPath)
+		server.Notify(pb.EventType_EVENT_MOVE, target.Mode(), newPath, path)
+		return nil, 0
+	}
+
+	var ops []StorageOp
+	if target != nil {
+		if noReplace {
+			return nil, syscall.EEXIST
+		}
+		if node.Type == DIR && target.Type != DIR {
+			return nil, syscall.ENOTDIR
+		}
+		if node.Type != DIR && target.Type == DIR {
+			return nil, syscall.EISDIR
+		}
+		if target.Type == DIR && len(target.Children) > 0 {
+			return nil, syscall.ENOTEMPTY
+		}
+		newParent.RemoveChild(newName)
+		target.AdjustLinks(-1)
+		ops = server.ReleaseInodes(target)
+	}
+
+	oldParent.RemoveChild(oldName)
+	node.Name = newName
+	newParent.AddChild(node)
+	server.Notify(pb.EventType_EVENT_MOVE, node.Mode(), path, newPath)
+	return ops, 0
+}
+
+// Removes the node from the index after checking that it exists and has the expected type
+func (server *NamingServer) Unlink(path string, t NodeType, recursive bool) (*Node, syscall.Errno) {
+	if path == "" {
+		return nil, syscall.EBUSY
+	}
+	if IsInSnapshot(path) {
+		return nil, syscall.EROFS
+	}
+
+	parent, errno := server.LookupNode(utils.DirPart(path))
+	if errno != 0 {
+		return nil, errno
+	}
+	if parent.Type != DIR {
+		return nil, syscall.ENOTDIR
+	}
+
+	name := utils.NamePart(path)
+	node := parent.GetChild(name)
+	if node == nil {
+		return nil, syscall.ENOENT
+	}
+	if t == FILE && node.Type == DIR {
+		return nil, syscall.EISDIR
+	}
+	if t == DIR && node.Type != DIR {
+		return nil, syscall.ENOTDIR
+	}
+	if len(node.Children) > 0 && !recursive {
+		return nil, syscall.ENOTEMPTY
+	}
+
+	parent.RemoveChild(name)
+	node.AdjustLinks(-1)
+	server.Notify(pb.EventType_EVENT_DELETE, node.Mode(), path, "")
+	return node, 0
+}
+
+// Applies the changes to storage servers. Returns the failed changes along with their errors.
+func (server *NamingServer) ApplyStorageOps(ctx context.Context, ops []StorageOp) ([]StorageOp, []*pb.ErrorStatus) {
+	var failed []StorageOp
+	var statuses []*pb.ErrorStatus
+
+	for _, op := range ops {
+		status := server.applyStorageOp(ctx, op)
+		if status != nil && status.Code != 0 {
+			println("Error applying storage operation on", op.Alias, ":", status.Description)
+			failed = append(failed, op)
+			statuses = append(statuses, status)
+		}
+	}
+	return failed, statuses
+}
+
+func (server *NamingServer) applyStorageOp(ctx context.Context, op StorageOp) *pb.ErrorStatus {
+	info, ok := server.GetAddress(op.Alias)
+	if !ok {
+		return &pb.ErrorStatus{
+			Code:        uint32(syscall.ENXIO),
+			Description: "Storage server " + op.Alias + " is not registered",
+		}
+	}
+	ss := server.GetStorageServer(info.privateAddress)
+	if ss == nil {
+		return &pb.ErrorStatus{
+			Code:        uint32(syscall.EIO),
+			Description: "No connection to storage server " + op.Alias,
+		}
+	}
+
+	if op.Kind == CopyOp {
+		response, err := ss.Copy(ctx, &pb.CopyArgs{FileId: op.FileID, NewFileId: op.NewFileID})
+		if err != nil {
+			return &pb.ErrorStatus{Code: uint32(syscall.EIO), Description: err.Error()}
+		}
+		return response.ErrorStatus
+	}
+
+	response, err := ss.Remove(ctx, &pb.RemoveArgs{FileId: op.FileID})
+	if err != nil {
+		return &pb.ErrorStatus{Code: uint32(syscall.EIO), Description: err.Error()}
+	}
+	return response.ErrorStatus
 }
 
 type StorageServerInfo struct {
 	privateAddress string
 	publicAddress  string
+	lastHeartbeat  time.Time
+	capacity       int64 // size of the file system of the server, as of the last heartbeat
+	available      int64 // space left in it
 }
 
 type NamingServer struct {
 	storageAddressesMutex sync.Mutex
 	StorageAddresses      map[string]*StorageServerInfo // key:value = serverAlias:serverAddress
+	NodeStates            map[string]pb.NodeState       // key:value = serverAlias:state; active servers are left out
+	rebalanceMutex        sync.Mutex
 	LocalAddress          string
+	indexMutex            sync.Mutex
 	RootIndexNode         *Node
+	storageServersMutex   sync.Mutex
 	StorageServers        map[string]pb.StorageClient
+	ReconcileInterval     time.Duration
+	leasesMutex           sync.Mutex
+	Leases                map[uint64]*Lease // key:value = fileId:lease
+	LeaseDuration         time.Duration
+	Snapshots             map[string]*Snapshot   // key:value = snapshotName:snapshot
+	Trash                 map[uint64]*TrashEntry // key:value = entryId:entry
+	trashCounter          uint64
+	TrashRetention        time.Duration     // deleted nodes are removed at once if zero
+	UserQuotas            map[string]*Quota // key:value = user:quota
+	MetadataPath          string
+	SaveInterval          time.Duration
+	Inodes                map[uint64]*Inode // key:value = inodeId:inode
+	inodeCounter          uint64
+	idLimit               uint64              // IDs up to the limit are reserved on disk
+	Sessions              map[uint64]*Session // key:value = sessionId:session
+	sessionCounter        uint64
+	handleCounter         uint64
+	SessionTimeout        time.Duration
+	Locks                 map[uint64][]*RangeLock // key:value = fileId:locks
+	locksChanged          chan struct{}           // closed whenever locks are released
+	eventsMutex           sync.Mutex
+	Events                []*pb.Event // most recent changes of the namespace, oldest first
+	eventCounter          uint64
+	EventBuffer           int           // number of events retained for watchers to resume from
+	eventsAdded           chan struct{} // closed whenever an event is recorded
+	ChangelogPath         string
+	ChangelogRetention    time.Duration // changelog records are kept forever if zero
+	changelogFile         *os.File
+	changelogOffsets      []int64 // offsets of the retained records in the changelog
+	changelogSize         int64
+	savedSequence         *uint64 // last changelog record the loaded metadata reflects, if it is known
 }
 
 func (server *NamingServer) SetAddressMap(newKey string, newValue *StorageServerInfo) {
@@ -157,6 +533,34 @@ func (server *NamingServer) SetAddressMap(newKey string, newValue *StorageServer
 	server.StorageAddresses[newKey] = newValue
 }
 
+func (server *NamingServer) GetAddress(alias string) (*StorageServerInfo, bool) {
+	server.storageAddressesMutex.Lock()
+	defer server.storageAddressesMutex.Unlock()
+	info, ok := server.StorageAddresses[alias]
+	return info, ok
+}
+
+// Records a heartbeat of the storage server along with its free space. Returns false if the server is not registered.
+func (server *NamingServer) Heartbeat(alias string, capacity int64, available int64) bool {
+	server.storageAddressesMutex.Lock()
+	defer server.storageAddressesMutex.Unlock()
+	info, ok := server.StorageAddresses[alias]
+	if ok {
+		info.lastHeartbeat = time.Now()
+		info.capacity = capacity
+		info.available = available
+	}
+	return ok
+}
+
+// Storage server is considered alive if it has sent a heartbeat within the lease duration
+func (server *NamingServer) IsAlive(alias string) bool {
+	server.storageAddressesMutex.Lock()
+	defer server.storageAddressesMutex.Unlock()
+	info, ok := server.StorageAddresses[alias]
+	return ok && time.Since(info.lastHeartbeat) < server.LeaseDuration
+}
+
 func StorageServerInfoKeys(m map[string]*StorageServerInfo) []string {
 	keys := make([]string, len(m))
 
@@ -169,23 +573,27 @@ func StorageServerInfoKeys(m map[string]*StorageServerInfo) []string {
 	return keys
 }
 
-// Returns 2 random storage servers. That's it.
+// Returns 2 random storage servers that accept new replicas, or fewer if there are not enough of them.
 func (server *NamingServer) Get2RandomStorageServers() []*pb.DiscoveredStorage {
+	server.storageAddressesMutex.Lock()
+	defer server.storageAddressesMutex.Unlock()
 	servers := server.StorageAddresses
-	keys := StorageServerInfoKeys(servers)
+	var keys []string
+	for _, alias := range StorageServerInfoKeys(servers) {
+		if server.acceptsReplicas(alias) {
+			keys = append(keys, alias)
+		}
+	}
 	var result []*pb.DiscoveredStorage
 
 	var aliases []string
-	for {
-		index := rand.Intn(len(servers))
+	for len(aliases) < ReplicationFactor && len(aliases) < len(keys) {
+		index := rand.Intn(len(keys))
 		alias := keys[index]
 		if utils.Contains(aliases, alias) {
 			continue
 		}
 		aliases = append(aliases, alias)
-		if len(aliases) == 2 {
-			break
-		}
 	}
 
 	for _, alias := range aliases {
@@ -202,9 +610,12 @@ func (server *NamingServer) Get2RandomStorageServers() []*pb.DiscoveredStorage {
 }
 
 func (server *NamingServer) GetStorageServer(address string) pb.StorageClient {
+	server.storageServersMutex.Lock()
+	defer server.storageServersMutex.Unlock()
+
 	ss, ok := server.StorageServers[address]
 	if !ok {
-		conn, err := grpc.Dial(address, grpc.WithInsecure())
+		conn, err := grpc.Dial(address, utils.DialOptions()...)
 		if err != nil {
 			println("GetStorageServer: error dialing storage server:", err.Error())
 			return nil
@@ -223,23 +634,118 @@ func initNamingServer() *NamingServer {
 		fmt.Println("ADDRESS variable not specified; falling back to", address)
 	}
 
-	rootNode := &Node{
-		Name:     "",
-		Children: make([]*Node, 0),
+	// Obtain replica reconciliation interval (in seconds) from environment
+	reconcileInterval, err := strconv.Atoi(os.Getenv("RECONCILE_INTERVAL"))
+	if err != nil || reconcileInterval <= 0 {
+		reconcileInterval = 30
+		fmt.Println("RECONCILE_INTERVAL variable not specified; falling back to", reconcileInterval)
+	}
+
+	// Obtain primary lease duration (in seconds) from environment
+	leaseDuration, err := strconv.Atoi(os.Getenv("LEASE_DURATION"))
+	if err != nil || leaseDuration <= 0 {
+		leaseDuration = 60
+		fmt.Println("LEASE_DURATION variable not specified; falling back to", leaseDuration)
+	}
+
+	// Obtain trash retention period (in seconds) from environment; zero disables the trash
+	trashRetention, err := strconv.Atoi(os.Getenv("TRASH_RETENTION"))
+	if err != nil || trashRetention < 0 {
+		trashRetention = 7 * 24 * 60 * 60
+		fmt.Println("TRASH_RETENTION variable not specified; falling back to", trashRetention)
 	}
 
-	return &NamingServer{
+	// Obtain path of the file the metadata is saved to from environment
+	metadataPath := os.Getenv("METADATA_PATH")
+	if metadataPath == "" {
+		metadataPath = "metadata.json"
+		fmt.Println("METADATA_PATH variable not specified; falling back to", metadataPath)
+	}
+
+	// Obtain metadata save interval (in seconds) from environment
+	saveInterval, err := strconv.Atoi(os.Getenv("SAVE_INTERVAL"))
+	if err != nil || saveInterval <= 0 {
+		saveInterval = 5
+		fmt.Println("SAVE_INTERVAL variable not specified; falling back to", saveInterval)
+	}
+
+	// Obtain client session timeout (in seconds) from environment
+	sessionTimeout, err := strconv.Atoi(os.Getenv("SESSION_TIMEOUT"))
+	if err != nil || sessionTimeout <= 0 {
+		sessionTimeout = 30
+		fmt.Println("SESSION_TIMEOUT variable not specified; falling back to", sessionTimeout)
+	}
+
+	// Obtain number of events retained for watchers from environment
+	eventBuffer, err := strconv.Atoi(os.Getenv("WATCH_BUFFER"))
+	if err != nil || eventBuffer <= 0 {
+		eventBuffer = 10000
+		fmt.Println("WATCH_BUFFER variable not specified; falling back to", eventBuffer)
+	}
+
+	// Obtain path of the changelog from environment
+	changelogPath := os.Getenv("CHANGELOG_PATH")
+	if changelogPath == "" {
+		changelogPath = "changelog.jsonl"
+		fmt.Println("CHANGELOG_PATH variable not specified; falling back to", changelogPath)
+	}
+
+	// Obtain changelog retention period (in seconds) from environment; zero keeps the records forever
+	changelogRetention, err := strconv.Atoi(os.Getenv("CHANGELOG_RETENTION"))
+	if err != nil || changelogRetention < 0 {
+		changelogRetention = 7 * 24 * 60 * 60
+		fmt.Println("CHANGELOG_RETENTION variable not specified; falling back to", changelogRetention)
+	}
+
+	server := &NamingServer{
 		storageAddressesMutex: sync.Mutex{},
 		StorageAddresses:      make(map[string]*StorageServerInfo),
+		NodeStates:            make(map[string]pb.NodeState),
 		LocalAddress:          address,
-		RootIndexNode:         rootNode,
 		StorageServers:        make(map[string]pb.StorageClient),
+		ReconcileInterval:     time.Duration(reconcileInterval) * time.Second,
+		Leases:                make(map[uint64]*Lease),
+		LeaseDuration:         time.Duration(leaseDuration) * time.Second,
+		Snapshots:             make(map[string]*Snapshot),
+		Trash:                 make(map[uint64]*TrashEntry),
+		TrashRetention:        time.Duration(trashRetention) * time.Second,
+		UserQuotas:            make(map[string]*Quota),
+		MetadataPath:          metadataPath,
+		SaveInterval:          time.Duration(saveInterval) * time.Second,
+		Inodes:                make(map[uint64]*Inode),
+		Sessions:              make(map[uint64]*Session),
+		SessionTimeout:        time.Duration(sessionTimeout) * time.Second,
+		Locks:                 make(map[uint64][]*RangeLock),
+		locksChanged:          make(chan struct{}),
+		EventBuffer:           eventBuffer,
+		eventsAdded:           make(chan struct{}),
+		ChangelogPath:         changelogPath,
+		ChangelogRetention:    time.Duration(changelogRetention) * time.Second,
 	}
+	err = server.loadReservedIDs()
+	if err != nil {
+		println("Error loading reserved inode IDs:", err.Error())
+		os.Exit(1)
+	}
+	server.RootIndexNode = server.NewNode("", DIR)
+	server.TrackUsage()
+	return server
 }
 
 func Run() {
 	server := initNamingServer()
 
+	err := server.LoadMetadata()
+	if err != nil {
+		println("Error loading metadata:", err.Error())
+		os.Exit(1)
+	}
+	err = server.OpenChangelog()
+	if err != nil {
+		println("Error opening changelog:", err.Error())
+		os.Exit(1)
+	}
+
 	println("Initialized metadata: ")
 	fmt.Printf("%+v\n", server)
 
@@ -250,9 +756,15 @@ func Run() {
 	}
 	println("Listening on " + server.LocalAddress)
 
+	go server.ReconcileLoop()
+	go server.PurgeLoop()
+	go server.SaveLoop()
+	go server.SessionLoop()
+
 	namingController := NewNamingServiceController(server)
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(utils.ServerOptions()...)
 	pb.RegisterNamingServer(grpcServer, namingController)
+	pb.RegisterAdminServer(grpcServer, NewAdminController(server))
 	err = grpcServer.Serve(listener)
 	if err != nil {
 		println("Error serving:", err.Error())
@@ -7,9 +7,11 @@ import (
 	"google.golang.org/grpc/peer"
 	utils "project-dfs"
 	"project-dfs/pb"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 )
 
 type NamingServerController struct {
@@ -26,7 +28,8 @@ func NewNamingServiceController(server *NamingServer) *NamingServerController {
 
 // update address map on the NAMING Server
 func (ctlr *NamingServerController) Register(ctx context.Context, request *pb.RegRequest) (*pb.RegResponse, error) {
-	fmt.Println("Register:", request)
+	// the inventory may list millions of replicas
+	fmt.Println("Register:", request.ServerAlias, request.PublicHostname, request.Port, "with", len(request.Inventory), "replicas")
 
 	otherPeer, ok := peer.FromContext(ctx)
 	if !ok {
@@ -34,6 +37,12 @@ func (ctlr *NamingServerController) Register(ctx context.Context, request *pb.Re
 		return &pb.RegResponse{Status: pb.Status_DECLINE}, errors.New("other peer not found")
 	}
 
+	// decommissioned servers stay out of the cluster until an administrator makes them active
+	if ctlr.Server.NodeState(request.ServerAlias) == pb.NodeState_NODE_DECOMMISSIONED {
+		println("Registration of decommissioned storage server", request.ServerAlias, "declined")
+		return &pb.RegResponse{Status: pb.Status_DECLINE}, nil
+	}
+
 	// add a new Server to the list of known Storage Servers
 	peerAddress := otherPeer.Addr.String()
 	// Remove local port
@@ -44,9 +53,13 @@ func (ctlr *NamingServerController) Register(ctx context.Context, request *pb.Re
 	ctlr.Server.SetAddressMap(request.ServerAlias, &StorageServerInfo{
 		privateAddress: peerAddress,
 		publicAddress:  request.PublicHostname + ":" + strconv.Itoa(int(request.Port)),
+		lastHeartbeat:  time.Now(),
 	})
 
-	return &pb.RegResponse{Status: pb.Status_ACCEPT}, nil
+	// the server fetches only the replicas assigned to it that it lacks or holds in older versions
+	fetch := ctlr.Server.ReconcileInventory(request.ServerAlias, request.Inventory)
+
+	return &pb.RegResponse{Status: pb.Status_ACCEPT, Fetch: fetch}, nil
 }
 
 // key is the file's path
@@ -56,7 +69,8 @@ func (ctlr *NamingServerController) Discover(ctx context.Context, request *pb.Di
 	storages := make([]*pb.DiscoveredStorage, 0)
 
 	// if path == "" return ALL storage servers
-	if request.Path == "" {
+	if request.Path == "" && request.FileId == 0 {
+		ctlr.Server.storageAddressesMutex.Lock()
 		for alias, info := range ctlr.Server.StorageAddresses {
 			storages = append(storages, &pb.DiscoveredStorage{
 				Alias:         alias,
@@ -64,32 +78,52 @@ func (ctlr *NamingServerController) Discover(ctx context.Context, request *pb.Di
 				PublicAddress: info.publicAddress,
 			})
 		}
+		ctlr.Server.storageAddressesMutex.Unlock()
 		return &pb.DiscoverResponse{StorageInfo: storages}, nil
 	}
 
-	node, ok := ctlr.Server.FindNode(request.Path)
-	if !ok {
+	ctlr.Server.indexMutex.Lock()
+	defer ctlr.Server.indexMutex.Unlock()
+
+	// storage servers look files up by ID, clients by path
+	var inode *Inode
+	if request.FileId != 0 {
+		inode = ctlr.Server.Inodes[request.FileId]
+	} else if node, ok := ctlr.Server.FindNode(request.Path); ok {
+		inode = node.Inode
+	}
+	if inode == nil {
 		fmt.Println("Node not found! Returning empty list")
 		return &pb.DiscoverResponse{
 			StorageInfo: []*pb.DiscoveredStorage{},
 		}, nil
 	}
 
-	for _, storage := range node.Storages {
-		if request.GetExcludeStorageName() == storage.Alias {
+	storages = ctlr.Server.DiscoverReplicas(inode, request.GetExcludeStorageName())
+
+	fmt.Println("Returning storages:", storages)
+	return &pb.DiscoverResponse{StorageInfo: storages, Version: inode.Version, FileId: inode.ID, Size: inode.Size, Links: uint32(inode.Links)}, nil
+}
+
+// Returns addresses of the storage servers holding replicas of the file
+func (server *NamingServer) DiscoverReplicas(inode *Inode, exclude string) []*pb.DiscoveredStorage {
+	storages := make([]*pb.DiscoveredStorage, 0)
+	for _, storage := range inode.Storages {
+		if exclude == storage.Alias {
 			continue
 		}
 
-		info := ctlr.Server.StorageAddresses[storage.Alias]
+		info, ok := server.GetAddress(storage.Alias)
+		if !ok {
+			continue
+		}
 		storages = append(storages, &pb.DiscoveredStorage{
 			Alias:         storage.Alias,
 			Address:       info.privateAddress,
 			PublicAddress: info.publicAddress,
 		})
 	}
-
-	fmt.Println("Returning storages:", storages)
-	return &pb.DiscoverResponse{StorageInfo: storages}, nil
+	return storages
 }
 
 // ---
@@ -108,24 +142,20 @@ func (ctlr *NamingServerController) CreateFile(ctx context.Context, request *pb.
 	//	_ = os.MkdirAll(dir, 0777)
 	//}
 
-	node := ctlr.Server.CreateNodeIfNotExists(request.Path, true)
-	servers := ctlr.Server.Get2RandomStorageServers()
-	for _, s := range servers {
-		fmt.Println("Sending create file request to storage server", s.Alias)
-		server := ctlr.Server.GetStorageServer(s.Address)
-		response, err := server.CreateFile(ctx, &pb.CreateFileArgs{Path: request.Path})
-		if err != nil {
-			println("Error creating file:", err.Error())
-			continue
-		}
-		if response.ErrorStatus.Code != 0 {
-			println("Error during file creation:", response.ErrorStatus.Description)
-			continue
-		}
-		node.Storages = append(node.Storages, &StorageInfo{Alias: s.Alias})
-		fmt.Println("Storage", s.Alias, "added to node", node.Name)
+	// an existing file is never created again, as that would truncate its replicas
+
+	ctlr.Server.indexMutex.Lock()
+	node, errno := ctlr.Server.CreateFile(request.Path, UserName(request.User))
+	ctlr.Server.indexMutex.Unlock()
+	if errno != 0 {
+		return &pb.CreateFileResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
 	}
 
+	ctlr.Server.PlaceFile(ctx, node)
+
 	return &pb.CreateFileResponse{ErrorStatus: &pb.ErrorStatus{
 		Code:        0,
 		Description: "",
@@ -136,38 +166,31 @@ func (ctlr *NamingServerController) Move(ctx context.Context, request *pb.MoveRe
 	fmt.Println("Move:", request)
 
 	// client sends paths: old and new
-	// traverse index tree and find node
+	// traverse index tree and find both nodes
+	// check rename(2) conditions and update the index at once
 
-	// find storages with the file
-	// contact them to move the file
+	// storage servers keep files by ID, so only the replaced file has to be deleted from them
 
-	oldParent, ok := ctlr.Server.FindNode(utils.DirPart(request.Path))
-	if !ok {
+	// only entries of the own trash can be moved out of it, and nothing can be moved into it
+	user := UserName(request.User)
+	errno := CheckTrashAccess(request.Path, user)
+	if errno == 0 && IsInTrash(request.NewPath) {
+		errno = syscall.EPERM
+	}
+	var ops []StorageOp
+	if errno == 0 {
+		ctlr.Server.indexMutex.Lock()
+		ops, errno = ctlr.Server.Rename(request.Path, request.NewPath, request.Flags)
+		ctlr.Server.indexMutex.Unlock()
+	}
+	if errno != 0 {
 		return &pb.MoveResponse{ErrorStatus: &pb.ErrorStatus{
-			Code:        1,
-			Description: "Old parent node does not exist",
+			Code:        uint32(errno),
+			Description: errno.Error(),
 		}}, nil
 	}
-	oldName := utils.NamePart(request.Path)
-	newName := utils.NamePart(request.NewPath)
-	node := oldParent.GetChild(oldName)
-
-	oldParent.RemoveChild(oldName)
 
-	node.Name = newName
-
-	newParentPath := utils.DirPart(request.NewPath)
-	newParent := ctlr.Server.CreateNodeIfNotExists(newParentPath, false)
-	newParent.AddChild(node)
-
-	for _, storage := range node.Storages {
-		info := ctlr.Server.StorageAddresses[storage.Alias]
-		ss := ctlr.Server.GetStorageServer(info.privateAddress)
-		_, _ = ss.Move(ctx, &pb.MoveArgs{
-			Path:    request.Path,
-			NewPath: request.NewPath,
-		})
-	}
+	ctlr.Server.ApplyStorageOps(ctx, ops)
 
 	return &pb.MoveResponse{ErrorStatus: &pb.ErrorStatus{
 		Code:        0,
@@ -179,64 +202,102 @@ func (ctlr *NamingServerController) DeleteFile(ctx context.Context, request *pb.
 	fmt.Println("DeleteFile:", request)
 
 	// client sends path
-	// traverse index tree and find node parent for the path
-	// delete child with file name
-	// find storages with the file
-	// contact them to delete the file
+	// traverse index tree and find the file node
+	// move it to the trash of the user or delete it from its parent
+	// contact storages to delete the file unless it is moved to the trash
 
-	parentPath := utils.DirPart(request.Path)
-	parent, ok := ctlr.Server.FindNode(parentPath)
-	if !ok {
+	return ctlr.deleteNode(ctx, request, FILE), nil
+}
+
+func (ctlr *NamingServerController) DeleteDirectory(ctx context.Context, request *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	fmt.Println("DeleteDirectory:", request)
+
+	// client sends path
+	// traverse index tree and find the directory node
+	// fail if it has contents and the removal is not recursive
+	// move it to the trash of the user or delete it from its parent
+	// contact storages to delete its files unless it is moved to the trash
+
+	return ctlr.deleteNode(ctx, request, DIR), nil
+}
+
+func (ctlr *NamingServerController) deleteNode(ctx context.Context, request *pb.DeleteRequest, t NodeType) *pb.DeleteResponse {
+	user := UserName(request.User)
+	if strings.Contains(user, "/") {
 		return &pb.DeleteResponse{
 			ErrorStatus: &pb.ErrorStatus{
-				Code:        uint32(syscall.ENOENT),
-				Description: "No parent directory found",
+				Code:        uint32(syscall.EINVAL),
+				Description: "Invalid user name",
 			},
-		}, nil
+		}
 	}
-	parent.RemoveChild(utils.NamePart(request.Path))
 
-	for _, info := range ctlr.Server.StorageAddresses {
-		server := ctlr.Server.GetStorageServer(info.privateAddress)
-		server.Remove(ctx, &pb.RemoveArgs{Path: request.Path})
+	errno := CheckTrashAccess(request.Path, user)
+	if errno != 0 {
+		return &pb.DeleteResponse{
+			ErrorStatus: &pb.ErrorStatus{
+				Code:        uint32(errno),
+				Description: "Entries of the trash of another user cannot be deleted",
+			},
+		}
 	}
 
-	return &pb.DeleteResponse{ErrorStatus: &pb.ErrorStatus{
-		Code:        0,
-		Description: "",
-	}}, nil
-}
-
-func (ctlr *NamingServerController) DeleteDirectory(ctx context.Context, request *pb.DeleteRequest) (*pb.DeleteResponse, error) {
-	fmt.Println("DeleteDirectory:", request)
-
-	// client sends path
-	// traverse index tree and find node parent for the path
-	// delete child with directory name
-	// find storages with the directory
-	// contact them to delete the directory
+	var ops []StorageOp
+	var entry *TrashEntry
 
-	parentPath := utils.DirPart(request.Path)
-	parent, ok := ctlr.Server.FindNode(parentPath)
-	if !ok {
+	ctlr.Server.indexMutex.Lock()
+	node, errno := ctlr.Server.Unlink(request.Path, t, request.Recursive)
+	if errno == 0 {
+		if ctlr.Server.TrashRetention > 0 && !IsInTrash(request.Path) {
+			entry = ctlr.Server.MoveToTrash(request.Path, node, user)
+		}
+		if entry == nil {
+			ops = ctlr.Server.ReleaseInodes(node)
+		}
+	}
+	ctlr.Server.indexMutex.Unlock()
+	if errno != 0 {
 		return &pb.DeleteResponse{
 			ErrorStatus: &pb.ErrorStatus{
-				Code:        uint32(syscall.ENOENT),
-				Description: "No parent directory found",
+				Code:        uint32(errno),
+				Description: errno.Error(),
 			},
-		}, nil
+		}
 	}
-	parent.RemoveChild(utils.NamePart(request.Path))
 
-	for _, info := range ctlr.Server.StorageAddresses {
-		server := ctlr.Server.GetStorageServer(info.privateAddress)
-		server.Remove(ctx, &pb.RemoveArgs{Path: request.Path})
+	response := ctlr.applyDeletion(ctx, ops)
+	if entry != nil {
+		response.TrashId = entry.ID
 	}
+	return response
+}
 
-	return &pb.DeleteResponse{ErrorStatus: &pb.ErrorStatus{
-		Code:        0,
-		Description: "",
-	}}, nil
+// Applies the deletion to the storage servers and reports the ones that failed to do so
+func (ctlr *NamingServerController) applyDeletion(ctx context.Context, ops []StorageOp) *pb.DeleteResponse {
+	failed, statuses := ctlr.Server.ApplyStorageOps(ctx, ops)
+	if len(failed) == 0 {
+		return &pb.DeleteResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		}}
+	}
+
+	var failures []*pb.ReplicaFailure
+	var failedAliases []string
+	for i, op := range failed {
+		failures = append(failures, &pb.ReplicaFailure{
+			Alias:       op.Alias,
+			ErrorStatus: statuses[i],
+		})
+		failedAliases = append(failedAliases, op.Alias)
+	}
+	return &pb.DeleteResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(syscall.EIO),
+			Description: "Removed from the index, but failed to delete replicas on " + strings.Join(failedAliases, ", "),
+		},
+		Failures: failures,
+	}
 }
 
 func (ctlr *NamingServerController) MakeDirectory(ctx context.Context, request *pb.MakeDirectoryRequest) (*pb.MakeDirectoryResponse, error) {
@@ -248,7 +309,27 @@ func (ctlr *NamingServerController) MakeDirectory(ctx context.Context, request *
 	// find 2 random storages
 	// contact them to make the directory
 
-	ctlr.Server.CreateNodeIfNotExists(request.Path, false)
+	if IsInSnapshot(request.Path) {
+		return &pb.MakeDirectoryResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(syscall.EROFS),
+			Description: "Snapshots are read-only",
+		}}, nil
+	}
+
+	user := UserName(request.User)
+	ctlr.Server.indexMutex.Lock()
+	errno := ctlr.Server.CheckQuotas(request.Path, user, 0, ctlr.Server.MissingNodes(request.Path))
+	if errno == 0 {
+		_, errno = ctlr.Server.CreateNodeIfNotExists(request.Path, false, user)
+	}
+	ctlr.Server.indexMutex.Unlock()
+	if errno != 0 {
+		return &pb.MakeDirectoryResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
 	return &pb.MakeDirectoryResponse{ErrorStatus: &pb.ErrorStatus{
 		Code:        0,
 		Description: "",
@@ -262,6 +343,9 @@ func (ctlr *NamingServerController) ListDirectory(ctx context.Context, request *
 	// traverse index tree and find node
 	// return all children of the node
 
+	ctlr.Server.indexMutex.Lock()
+	defer ctlr.Server.indexMutex.Unlock()
+
 	node, ok := ctlr.Server.FindNode(request.Path)
 	if !ok {
 		return &pb.ListDirectoryResponse{
@@ -276,15 +360,23 @@ func (ctlr *NamingServerController) ListDirectory(ctx context.Context, request *
 	var res []*pb.Node
 
 	for _, child := range node.Children {
-		mode := pb.NodeMode_REGULAR_FILE
-		if child.Type == DIR {
-			mode = pb.NodeMode_DIRECTORY
+		// trash is reachable only by its path
+		if node == ctlr.Server.RootIndexNode && "/"+child.Name == TrashDirectory {
+			continue
 		}
 
-		res = append(res, &pb.Node{
-			Mode: mode,
-			Name: child.Name,
-		})
+		entry := &pb.Node{
+			Mode:   child.Mode(),
+			Name:   child.Name,
+			Size:   child.Size,
+			Links:  uint32(child.LinkCount()),
+			FileId: child.ID,
+		}
+		if request.WithXattrs {
+			// the response is sent once the index is unlocked
+			entry.Xattrs = child.CopyXattrs()
+		}
+		res = append(res, entry)
 	}
 
 	fmt.Println("Returning", res)
@@ -299,5 +391,1198 @@ func (ctlr *NamingServerController) ListDirectory(ctx context.Context, request *
 }
 
 func (ctlr *NamingServerController) Copy(ctx context.Context, request *pb.CopyRequest) (*pb.CopyResponse, error) {
-	panic("no copy operation")
+	fmt.Println("Copy:", request)
+
+	// client sends paths: source and destination
+	// add copies of the nodes to the index
+	// contact storages holding the files to copy them
+
+	failed, _, errno := ctlr.Server.Copy(ctx, request.Path, request.NewPath, UserName(request.User))
+	if errno != 0 {
+		return &pb.CopyResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	if len(failed) > 0 {
+		var failedAliases []string
+		for _, op := range failed {
+			if !utils.Contains(failedAliases, op.Alias) {
+				failedAliases = append(failedAliases, op.Alias)
+			}
+		}
+		return &pb.CopyResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(syscall.EIO),
+			Description: "Copied in the index, but failed to copy replicas on " + strings.Join(failedAliases, ", "),
+		}}, nil
+	}
+
+	return &pb.CopyResponse{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "",
+	}}, nil
+}
+
+func (ctlr *NamingServerController) CommitWrite(ctx context.Context, request *pb.CommitWriteRequest) (*pb.CommitWriteResponse, error) {
+	fmt.Println("CommitWrite:", request)
+
+	// storage server sends ID of the file it has just written
+	// bump the version of the file in the index
+	// storage server stores the new version and passes it along the chain
+
+	ctlr.Server.indexMutex.Lock()
+	defer ctlr.Server.indexMutex.Unlock()
+
+	node, ok := ctlr.Server.Inodes[request.FileId]
+	if !ok {
+		return &pb.CommitWriteResponse{
+			ErrorStatus: &pb.ErrorStatus{
+				Code:        uint32(syscall.ENOENT),
+				Description: "No such file",
+			},
+		}, nil
+	}
+	node.Version++
+	node.resize(request.Size, 0)
+	ctlr.Server.NotifyModified(node)
+
+	return &pb.CommitWriteResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Version: node.Version,
+	}, nil
+}
+
+func (ctlr *NamingServerController) GrantLease(ctx context.Context, request *pb.LeaseRequest) (*pb.LeaseResponse, error) {
+	fmt.Println("GrantLease:", request)
+
+	// storage server sends ID of the file it is about to mutate
+	// return the current primary of the file, granting a new lease if there is none
+
+	lease, info, status := ctlr.Server.PrimaryOf(request.FileId, request.StorageName)
+	if status != nil {
+		return &pb.LeaseResponse{ErrorStatus: status}, nil
+	}
+
+	return &pb.LeaseResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Primary: &pb.DiscoveredStorage{
+			Alias:         lease.Holder,
+			Address:       info.privateAddress,
+			PublicAddress: info.publicAddress,
+		},
+		LeaseMillis: time.Until(lease.Expires).Milliseconds(),
+	}, nil
+}
+
+func (ctlr *NamingServerController) Heartbeat(ctx context.Context, request *pb.HeartbeatRequest) (*pb.HeartbeatResponse, error) {
+	// storage server reports it is alive along with the leases it wants to keep
+	// renew the leases that are still held by it
+
+	if !ctlr.Server.Heartbeat(request.ServerAlias, request.CapacityBytes, request.AvailableBytes) {
+		println("Heartbeat from unregistered storage server", request.ServerAlias)
+		return &pb.HeartbeatResponse{Status: pb.Status_DECLINE}, nil
+	}
+
+	return &pb.HeartbeatResponse{
+		Status:        pb.Status_ACCEPT,
+		RenewedLeases: ctlr.Server.RenewLeases(request.ServerAlias, request.Leases),
+		LeaseMillis:   ctlr.Server.LeaseDuration.Milliseconds(),
+	}, nil
+}
+
+func (ctlr *NamingServerController) Truncate(ctx context.Context, request *pb.TruncateRequest) (*pb.TruncateResponse, error) {
+	fmt.Println("Truncate:", request)
+
+	// client sends path and the new size
+	// find the primary replica of the file
+	// primary truncates the file, commits the new size and passes the call to other replicas
+
+	if request.Size < 0 {
+		return &pb.TruncateResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(syscall.EINVAL),
+			Description: "Negative size",
+		}}, nil
+	}
+
+	// open files are truncated by ID, so that a rename or replacement of the path does not affect them
+	fileId := request.FileId
+	var status *pb.ErrorStatus
+	if fileId != 0 {
+		ctlr.Server.indexMutex.Lock()
+		if _, ok := ctlr.Server.Inodes[fileId]; !ok {
+			status = &pb.ErrorStatus{
+				Code:        uint32(syscall.ENOENT),
+				Description: "No such file",
+			}
+		}
+		ctlr.Server.indexMutex.Unlock()
+	} else {
+		fileId, status = ctlr.Server.FileID(request.Path)
+	}
+	if status != nil {
+		return &pb.TruncateResponse{ErrorStatus: status}, nil
+	}
+	_, info, status := ctlr.Server.PrimaryOf(fileId, "")
+	if status != nil {
+		return &pb.TruncateResponse{ErrorStatus: status}, nil
+	}
+
+	response, err := ctlr.Server.GetStorageServer(info.privateAddress).Truncate(ctx, &pb.TruncateArgs{
+		FileId: fileId,
+		Size:   request.Size,
+	})
+	if err != nil {
+		return &pb.TruncateResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(syscall.EIO),
+			Description: err.Error(),
+		}}, nil
+	}
+
+	return &pb.TruncateResponse{ErrorStatus: response.ErrorStatus}, nil
+}
+
+func (ctlr *NamingServerController) Allocate(ctx context.Context, request *pb.AllocateRequest) (*pb.AllocateResponse, error) {
+	fmt.Println("Allocate:", request)
+
+	// client sends path and the range to preallocate
+	// find the primary replica of the file
+	// primary allocates the range, commits the new size and passes the call to other replicas
+
+	if request.Offset < 0 || request.Length <= 0 {
+		return &pb.AllocateResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(syscall.EINVAL),
+			Description: "Invalid range",
+		}}, nil
+	}
+
+	fileId, status := ctlr.Server.FileID(request.Path)
+	if status != nil {
+		return &pb.AllocateResponse{ErrorStatus: status}, nil
+	}
+	_, info, status := ctlr.Server.PrimaryOf(fileId, "")
+	if status != nil {
+		return &pb.AllocateResponse{ErrorStatus: status}, nil
+	}
+
+	response, err := ctlr.Server.GetStorageServer(info.privateAddress).Allocate(ctx, &pb.AllocateArgs{
+		FileId:   fileId,
+		Offset:   request.Offset,
+		Length:   request.Length,
+		KeepSize: request.KeepSize,
+	})
+	if err != nil {
+		return &pb.AllocateResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(syscall.EIO),
+			Description: err.Error(),
+		}}, nil
+	}
+
+	return &pb.AllocateResponse{ErrorStatus: response.ErrorStatus}, nil
+}
+
+func (ctlr *NamingServerController) Restore(ctx context.Context, request *pb.RestoreRequest) (*pb.RestoreResponse, error) {
+	fmt.Println("Restore:", request)
+
+	// client sends trash entry id and optionally the path to restore to
+	// move the node from the trash in the index unless the path is taken
+
+	ctlr.Server.indexMutex.Lock()
+	path, errno := ctlr.Server.Restore(request.TrashId, UserName(request.User), request.NewPath)
+	ctlr.Server.indexMutex.Unlock()
+	if errno != 0 {
+		return &pb.RestoreResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	return &pb.RestoreResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Path: path,
+	}, nil
+}
+
+func (ctlr *NamingServerController) ListTrash(ctx context.Context, request *pb.ListTrashRequest) (*pb.ListTrashResponse, error) {
+	fmt.Println("ListTrash:", request)
+
+	user := UserName(request.User)
+	var entries []*pb.TrashEntry
+
+	ctlr.Server.indexMutex.Lock()
+	for _, entry := range ctlr.Server.Trash {
+		if entry.User != user {
+			continue
+		}
+		entries = append(entries, &pb.TrashEntry{
+			Id:           entry.ID,
+			User:         entry.User,
+			OriginalPath: entry.OriginalPath,
+			DeletedAt:    entry.DeletedAt.Unix(),
+			PurgeAt:      entry.DeletedAt.Add(ctlr.Server.TrashRetention).Unix(),
+		})
+	}
+	ctlr.Server.indexMutex.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Id < entries[j].Id
+	})
+
+	return &pb.ListTrashResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Entries: entries,
+	}, nil
+}
+
+func (ctlr *NamingServerController) CreateSnapshot(ctx context.Context, request *pb.CreateSnapshotRequest) (*pb.CreateSnapshotResponse, error) {
+	fmt.Println("CreateSnapshot:", request)
+
+	// client sends path of the directory and name of the snapshot
+	// clone the subtree of the index
+	// contact storages with files of the subtree to preserve them
+
+	failed, statuses, errno := ctlr.Server.CreateSnapshot(ctx, request.Path, request.Name)
+	if errno != 0 {
+		return &pb.CreateSnapshotResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	response := &pb.CreateSnapshotResponse{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "",
+	}}
+	for i, op := range failed {
+		response.Failures = append(response.Failures, &pb.ReplicaFailure{
+			Alias:       op.Alias,
+			ErrorStatus: statuses[i],
+		})
+	}
+	return response, nil
+}
+
+func (ctlr *NamingServerController) ListSnapshots(ctx context.Context, request *pb.ListSnapshotsRequest) (*pb.ListSnapshotsResponse, error) {
+	fmt.Println("ListSnapshots:", request)
+
+	var snapshots []*pb.SnapshotInfo
+
+	ctlr.Server.indexMutex.Lock()
+	for _, snapshot := range ctlr.Server.Snapshots {
+		snapshots = append(snapshots, &pb.SnapshotInfo{
+			Name:      snapshot.Name,
+			Path:      snapshot.Path,
+			CreatedAt: snapshot.CreatedAt.Unix(),
+		})
+	}
+	ctlr.Server.indexMutex.Unlock()
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Name < snapshots[j].Name
+	})
+
+	return &pb.ListSnapshotsResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Snapshots: snapshots,
+	}, nil
+}
+
+func (ctlr *NamingServerController) DeleteSnapshot(ctx context.Context, request *pb.DeleteSnapshotRequest) (*pb.DeleteSnapshotResponse, error) {
+	fmt.Println("DeleteSnapshot:", request)
+
+	errno := ctlr.Server.DeleteSnapshot(ctx, request.Name)
+	if errno != 0 {
+		return &pb.DeleteSnapshotResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	return &pb.DeleteSnapshotResponse{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "",
+	}}, nil
+}
+
+func (ctlr *NamingServerController) SetVersioning(ctx context.Context, request *pb.SetVersioningRequest) (*pb.SetVersioningResponse, error) {
+	fmt.Println("SetVersioning:", request)
+
+	// client sends path of the directory and the retention of versions
+	// store the policy in the directory node
+
+	ctlr.Server.indexMutex.Lock()
+	defer ctlr.Server.indexMutex.Unlock()
+
+	node, errno := ctlr.Server.LookupNode(request.Path)
+	if errno == 0 && node.Type != DIR {
+		errno = syscall.ENOTDIR
+	}
+	if errno == 0 && (IsInSnapshot(request.Path) || request.MaxAge < 0) {
+		errno = syscall.EINVAL
+	}
+	if errno != 0 {
+		return &pb.SetVersioningResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	node.Versioning = &VersioningPolicy{
+		Enabled:     request.Enabled,
+		MaxVersions: int(request.MaxVersions),
+		MaxAge:      time.Duration(request.MaxAge) * time.Second,
+	}
+
+	return &pb.SetVersioningResponse{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "",
+	}}, nil
+}
+
+func (ctlr *NamingServerController) CommitFile(ctx context.Context, request *pb.CommitFileRequest) (*pb.CommitFileResponse, error) {
+	fmt.Println("CommitFile:", request)
+
+	// client sends path of the file it has finished modifying
+	// if the directory keeps versions, contact storages with the file to save its contents
+
+	version, status := ctlr.Server.CommitFile(ctx, request.Path)
+	if status != nil {
+		return &pb.CommitFileResponse{ErrorStatus: status}, nil
+	}
+
+	return &pb.CommitFileResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Version: version,
+	}, nil
+}
+
+func (ctlr *NamingServerController) ListVersions(ctx context.Context, request *pb.ListVersionsRequest) (*pb.ListVersionsResponse, error) {
+	fmt.Println("ListVersions:", request)
+
+	ctlr.Server.indexMutex.Lock()
+	defer ctlr.Server.indexMutex.Unlock()
+
+	node, errno := ctlr.Server.LookupNode(request.Path)
+	if errno == 0 && node.Type == DIR {
+		errno = syscall.EISDIR
+	}
+	if errno != 0 {
+		return &pb.ListVersionsResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	var versions []*pb.FileVersion
+	for _, version := range node.History {
+		versions = append(versions, &pb.FileVersion{
+			Version: version.Version,
+			Size:    version.Size,
+			SavedAt: version.SavedAt.Unix(),
+		})
+	}
+
+	return &pb.ListVersionsResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Versions: versions,
+	}, nil
+}
+
+func (ctlr *NamingServerController) RestoreVersion(ctx context.Context, request *pb.RestoreVersionRequest) (*pb.RestoreVersionResponse, error) {
+	fmt.Println("RestoreVersion:", request)
+
+	// client sends path and the saved version
+	// find the primary replica of the file
+	// primary restores the version, commits it as a new one and passes the call to other replicas
+
+	ctlr.Server.indexMutex.Lock()
+	node, errno := ctlr.Server.LookupNode(request.Path)
+	if errno == 0 && node.Type == DIR {
+		errno = syscall.EISDIR
+	}
+	if errno == 0 {
+		errno = syscall.ENOENT
+		for _, version := range node.History {
+			if version.Version == request.Version {
+				errno = 0
+			}
+		}
+	}
+	ctlr.Server.indexMutex.Unlock()
+	if errno != 0 {
+		return &pb.RestoreVersionResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	fileId, status := ctlr.Server.FileID(request.Path)
+	if status != nil {
+		return &pb.RestoreVersionResponse{ErrorStatus: status}, nil
+	}
+	_, info, status := ctlr.Server.PrimaryOf(fileId, "")
+	if status != nil {
+		return &pb.RestoreVersionResponse{ErrorStatus: status}, nil
+	}
+
+	response, err := ctlr.Server.GetStorageServer(info.privateAddress).RestoreVersion(ctx, &pb.RestoreVersionArgs{
+		FileId:  fileId,
+		Version: request.Version,
+	})
+	if err != nil {
+		return &pb.RestoreVersionResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(syscall.EIO),
+			Description: err.Error(),
+		}}, nil
+	}
+
+	return &pb.RestoreVersionResponse{ErrorStatus: response.ErrorStatus}, nil
+}
+
+func (ctlr *NamingServerController) SetQuota(ctx context.Context, request *pb.SetQuotaRequest) (*pb.SetQuotaResponse, error) {
+	fmt.Println("SetQuota:", request)
+
+	// client sends either path of the directory or name of the user, and the limits
+	// limits are removed if both are zero
+
+	var quota *Quota
+	if request.MaxBytes < 0 || request.MaxInodes < 0 {
+		return &pb.SetQuotaResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(syscall.EINVAL),
+			Description: "Limits cannot be negative",
+		}}, nil
+	}
+	if request.MaxBytes != 0 || request.MaxInodes != 0 {
+		quota = &Quota{MaxBytes: request.MaxBytes, MaxInodes: request.MaxInodes}
+	}
+
+	ctlr.Server.indexMutex.Lock()
+	defer ctlr.Server.indexMutex.Unlock()
+
+	if request.User != "" {
+		if quota == nil {
+			delete(ctlr.Server.UserQuotas, request.User)
+		} else {
+			ctlr.Server.UserQuotas[request.User] = quota
+		}
+		return &pb.SetQuotaResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		}}, nil
+	}
+
+	node, errno := ctlr.Server.LookupNode(request.Path)
+	if errno == 0 && node.Type != DIR {
+		errno = syscall.ENOTDIR
+	}
+	if errno == 0 && IsInSnapshot(request.Path) {
+		errno = syscall.EROFS
+	}
+	if errno != 0 {
+		return &pb.SetQuotaResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+	node.Quota = quota
+	node.trackUsage(quota != nil || node == ctlr.Server.RootIndexNode)
+
+	return &pb.SetQuotaResponse{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "",
+	}}, nil
+}
+
+func (ctlr *NamingServerController) GetUsage(ctx context.Context, request *pb.UsageRequest) (*pb.UsageResponse, error) {
+	fmt.Println("GetUsage:", request)
+
+	ctlr.Server.indexMutex.Lock()
+	defer ctlr.Server.indexMutex.Unlock()
+
+	var report []QuotaReport
+	switch {
+	case request.User != "":
+		entry := QuotaReport{User: request.User, Usage: ctlr.Server.RootIndexNode.usage.ownerUsage(request.User)}
+		if quota, ok := ctlr.Server.UserQuotas[request.User]; ok {
+			entry.Quota = *quota
+		}
+		report = append(report, entry)
+	case request.Path != "":
+		node, errno := ctlr.Server.LookupNode(request.Path)
+		if errno == 0 && node.Type != DIR {
+			errno = syscall.ENOTDIR
+		}
+		if errno != 0 {
+			return &pb.UsageResponse{ErrorStatus: &pb.ErrorStatus{
+				Code:        uint32(errno),
+				Description: errno.Error(),
+			}}, nil
+		}
+		entry := QuotaReport{Path: request.Path, Usage: node.ContentsUsage()}
+		if node.Quota != nil {
+			entry.Quota = *node.Quota
+		}
+		report = append(report, entry)
+	default:
+		report = ctlr.Server.ReportUsage()
+	}
+
+	var usage []*pb.QuotaUsage
+	for _, entry := range report {
+		usage = append(usage, &pb.QuotaUsage{
+			Path:       entry.Path,
+			User:       entry.User,
+			UsedBytes:  entry.Usage.Bytes,
+			UsedInodes: entry.Usage.Inodes,
+			MaxBytes:   entry.Quota.MaxBytes,
+			MaxInodes:  entry.Quota.MaxInodes,
+		})
+	}
+
+	return &pb.UsageResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Usage: usage,
+	}, nil
+}
+
+func (ctlr *NamingServerController) ReserveSpace(ctx context.Context, request *pb.ReserveSpaceRequest) (*pb.ReserveSpaceResponse, error) {
+	fmt.Println("ReserveSpace:", request)
+
+	// primary replica sends ID of the file and the size the file grows to
+	// check quotas of the directories and of the owner before the write is applied
+	// a write that fails afterwards gives the space back
+
+	ctlr.Server.indexMutex.Lock()
+	var errno syscall.Errno
+	if request.Release {
+		errno = ctlr.Server.ReleaseSpace(request.FileId)
+	} else {
+		errno = ctlr.Server.ReserveSpace(request.FileId, request.Size)
+	}
+	ctlr.Server.indexMutex.Unlock()
+	if errno != 0 {
+		return &pb.ReserveSpaceResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	return &pb.ReserveSpaceResponse{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "",
+	}}, nil
+}
+
+func (ctlr *NamingServerController) Symlink(ctx context.Context, request *pb.SymlinkRequest) (*pb.SymlinkResponse, error) {
+	fmt.Println("Symlink:", request)
+
+	// client sends the target and path of the link
+	// symbolic links live only in the index
+
+	ctlr.Server.indexMutex.Lock()
+	errno := ctlr.Server.Symlink(request.Target, request.Path, UserName(request.User))
+	ctlr.Server.indexMutex.Unlock()
+	if errno != 0 {
+		return &pb.SymlinkResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	return &pb.SymlinkResponse{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "",
+	}}, nil
+}
+
+func (ctlr *NamingServerController) Readlink(ctx context.Context, request *pb.ReadlinkRequest) (*pb.ReadlinkResponse, error) {
+	fmt.Println("Readlink:", request)
+
+	ctlr.Server.indexMutex.Lock()
+	defer ctlr.Server.indexMutex.Unlock()
+
+	node, ok := ctlr.Server.FindNode(request.Path)
+	if !ok {
+		return &pb.ReadlinkResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(syscall.ENOENT),
+			Description: "No such file",
+		}}, nil
+	}
+	if node.Type != SYMLINK {
+		return &pb.ReadlinkResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(syscall.EINVAL),
+			Description: "Not a symbolic link",
+		}}, nil
+	}
+
+	return &pb.ReadlinkResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Target: node.Target,
+	}, nil
+}
+
+func (ctlr *NamingServerController) Link(ctx context.Context, request *pb.LinkRequest) (*pb.LinkResponse, error) {
+	fmt.Println("Link:", request)
+
+	// client sends path of the file and path of the new link
+	// add an entry sharing the inode of the file to the index
+
+	ctlr.Server.indexMutex.Lock()
+	errno := ctlr.Server.Link(request.Path, request.NewPath)
+	ctlr.Server.indexMutex.Unlock()
+	if errno != 0 {
+		return &pb.LinkResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	return &pb.LinkResponse{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "",
+	}}, nil
+}
+
+func (ctlr *NamingServerController) Getxattr(ctx context.Context, request *pb.GetxattrRequest) (*pb.GetxattrResponse, error) {
+	fmt.Println("Getxattr:", request)
+
+	ctlr.Server.indexMutex.Lock()
+	defer ctlr.Server.indexMutex.Unlock()
+
+	var value []byte
+	errno := syscall.ENOENT
+	node, ok := ctlr.Server.FindNode(request.Path)
+	if ok {
+		value, errno = node.GetXattr(request.Name)
+	}
+	if errno != 0 {
+		return &pb.GetxattrResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	return &pb.GetxattrResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Value: value,
+	}, nil
+}
+
+func (ctlr *NamingServerController) Setxattr(ctx context.Context, request *pb.SetxattrRequest) (*pb.SetxattrResponse, error) {
+	fmt.Println("Setxattr:", request.Path, request.Name)
+
+	ctlr.Server.indexMutex.Lock()
+	defer ctlr.Server.indexMutex.Unlock()
+
+	var node *Node
+	errno := syscall.EROFS
+	if !IsInSnapshot(request.Path) {
+		node, errno = ctlr.Server.LookupNode(request.Path)
+	}
+	if errno == 0 {
+		errno = node.SetXattr(request.Name, request.Value, request.Flags)
+	}
+	if errno == 0 {
+		ctlr.Server.Notify(pb.EventType_EVENT_MODIFY, node.Mode(), request.Path, "")
+	}
+	if errno != 0 {
+		return &pb.SetxattrResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	return &pb.SetxattrResponse{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "",
+	}}, nil
+}
+
+func (ctlr *NamingServerController) Listxattr(ctx context.Context, request *pb.ListxattrRequest) (*pb.ListxattrResponse, error) {
+	fmt.Println("Listxattr:", request)
+
+	ctlr.Server.indexMutex.Lock()
+	defer ctlr.Server.indexMutex.Unlock()
+
+	node, ok := ctlr.Server.FindNode(request.Path)
+	if !ok {
+		return &pb.ListxattrResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(syscall.ENOENT),
+			Description: "No such file or directory",
+		}}, nil
+	}
+
+	return &pb.ListxattrResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Names: node.ListXattrs(),
+	}, nil
+}
+
+func (ctlr *NamingServerController) Removexattr(ctx context.Context, request *pb.RemovexattrRequest) (*pb.RemovexattrResponse, error) {
+	fmt.Println("Removexattr:", request)
+
+	ctlr.Server.indexMutex.Lock()
+	defer ctlr.Server.indexMutex.Unlock()
+
+	var node *Node
+	errno := syscall.EROFS
+	if !IsInSnapshot(request.Path) {
+		node, errno = ctlr.Server.LookupNode(request.Path)
+	}
+	if errno == 0 {
+		errno = node.RemoveXattr(request.Name)
+	}
+	if errno == 0 {
+		ctlr.Server.Notify(pb.EventType_EVENT_MODIFY, node.Mode(), request.Path, "")
+	}
+	if errno != 0 {
+		return &pb.RemovexattrResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	return &pb.RemovexattrResponse{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "",
+	}}, nil
+}
+
+func (ctlr *NamingServerController) OpenSession(ctx context.Context, request *pb.OpenSessionRequest) (*pb.OpenSessionResponse, error) {
+	fmt.Println("OpenSession:", request)
+
+	ctlr.Server.indexMutex.Lock()
+	session := ctlr.Server.OpenSession(UserName(request.User))
+	ctlr.Server.indexMutex.Unlock()
+
+	return &pb.OpenSessionResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		SessionId:     session.ID,
+		TimeoutMillis: ctlr.Server.SessionTimeout.Milliseconds(),
+	}, nil
+}
+
+func (ctlr *NamingServerController) KeepAlive(ctx context.Context, request *pb.KeepAliveRequest) (*pb.KeepAliveResponse, error) {
+	ctlr.Server.indexMutex.Lock()
+	defer ctlr.Server.indexMutex.Unlock()
+
+	session, errno := ctlr.Server.FindSession(request.SessionId)
+	if errno != 0 {
+		return &pb.KeepAliveResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+	session.Expires = time.Now().Add(ctlr.Server.SessionTimeout)
+
+	return &pb.KeepAliveResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		TimeoutMillis: ctlr.Server.SessionTimeout.Milliseconds(),
+	}, nil
+}
+
+func (ctlr *NamingServerController) CloseSession(ctx context.Context, request *pb.CloseSessionRequest) (*pb.CloseSessionResponse, error) {
+	fmt.Println("CloseSession:", request)
+
+	ctlr.Server.indexMutex.Lock()
+	session, errno := ctlr.Server.FindSession(request.SessionId)
+	var ops []StorageOp
+	if errno == 0 {
+		ops = ctlr.Server.CloseSession(session)
+	}
+	ctlr.Server.indexMutex.Unlock()
+	if errno != 0 {
+		return &pb.CloseSessionResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	ctlr.Server.ApplyStorageOps(ctx, ops)
+
+	return &pb.CloseSessionResponse{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "",
+	}}, nil
+}
+
+func (ctlr *NamingServerController) Open(ctx context.Context, request *pb.OpenRequest) (*pb.OpenResponse, error) {
+	fmt.Println("Open:", request)
+
+	// client sends its session, path of the file and open(2) flags
+	// create or truncate the file if asked to
+	// return a handle along with the current version and replicas of the file
+
+	// the file is looked up and created at once, so that only one of concurrent exclusive opens succeeds
+	create := request.Flags&uint32(pb.OpenFlag_O_CREAT) != 0
+	ctlr.Server.indexMutex.Lock()
+	session, errno := ctlr.Server.FindSession(request.SessionId)
+	var node *Node
+	exists := false
+	if errno == 0 {
+		node, exists = ctlr.Server.FindNode(request.Path)
+	}
+	if errno == 0 && exists && create && request.Flags&uint32(pb.OpenFlag_O_EXCL) != 0 {
+		errno = syscall.EEXIST
+	}
+	var created *Node
+	if errno == 0 && !exists && create {
+		created, errno = ctlr.Server.CreateFile(request.Path, UserName(session.User))
+	}
+	ctlr.Server.indexMutex.Unlock()
+	if errno != 0 {
+		return &pb.OpenResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	if created != nil {
+		ctlr.Server.PlaceFile(ctx, created)
+	} else if exists && node.Size > 0 && request.Flags&uint32(pb.OpenFlag_O_TRUNC) != 0 {
+		truncated, _ := ctlr.Truncate(ctx, &pb.TruncateRequest{Path: request.Path, Size: 0})
+		if truncated.ErrorStatus.Code != 0 {
+			return &pb.OpenResponse{ErrorStatus: truncated.ErrorStatus}, nil
+		}
+	}
+
+	ctlr.Server.indexMutex.Lock()
+	defer ctlr.Server.indexMutex.Unlock()
+
+	// the session might have expired meanwhile
+	session, errno = ctlr.Server.FindSession(request.SessionId)
+	var handle *Handle
+	if errno == 0 {
+		handle, errno = ctlr.Server.OpenFile(session, request.Path, request.Flags)
+	}
+	if errno != 0 {
+		return &pb.OpenResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	return &pb.OpenResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Handle:      handle.ID,
+		FileId:      handle.Inode.ID,
+		Version:     handle.Inode.Version,
+		Size:        handle.Inode.Size,
+		StorageInfo: ctlr.Server.DiscoverReplicas(handle.Inode, ""),
+	}, nil
+}
+
+func (ctlr *NamingServerController) Close(ctx context.Context, request *pb.CloseRequest) (*pb.CloseResponse, error) {
+	fmt.Println("Close:", request)
+
+	// client sends its session and the handle
+	// close the handle and delete the file if it was the last handle of a deleted file
+	// save a version of a file that has been open for writing
+
+	ctlr.Server.indexMutex.Lock()
+	session, handle, errno := ctlr.Server.FindHandle(request.SessionId, request.Handle)
+	if errno != 0 {
+		ctlr.Server.indexMutex.Unlock()
+		return &pb.CloseResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	ops := ctlr.Server.CloseHandle(session, handle)
+	path := ""
+	if handle.IsWritable() && handle.Inode.Links > 0 {
+		path = ctlr.Server.PathOf(handle.Inode)
+	}
+	ctlr.Server.indexMutex.Unlock()
+
+	ctlr.Server.ApplyStorageOps(ctx, ops)
+
+	var version uint64
+	if path != "" {
+		var status *pb.ErrorStatus
+		version, status = ctlr.Server.CommitFile(ctx, path)
+		if status != nil {
+			return &pb.CloseResponse{ErrorStatus: status}, nil
+		}
+	}
+
+	return &pb.CloseResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Version: version,
+	}, nil
+}
+
+func (ctlr *NamingServerController) Lock(request *pb.LockRequest, stream pb.Naming_LockServer) error {
+	fmt.Println("Lock:", request)
+
+	// client sends its session, the handle, type and range of the lock
+	// place the lock unless a lock of another session conflicts with it
+	// a waiting request is notified of the conflict and retried whenever locks are released
+
+	start, end, errno := lockRange(request.Start, request.Length)
+	if errno != 0 {
+		return stream.Send(&pb.LockResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}})
+	}
+	if request.Type == pb.LockType_F_UNLCK {
+		unlocked, _ := ctlr.Unlock(stream.Context(), &pb.UnlockRequest{
+			SessionId: request.SessionId,
+			Handle:    request.Handle,
+			Start:     request.Start,
+			Length:    request.Length,
+		})
+		return stream.Send(&pb.LockResponse{ErrorStatus: unlocked.ErrorStatus})
+	}
+	exclusive := request.Type == pb.LockType_F_WRLCK
+
+	notified := false
+	for {
+		ctlr.Server.indexMutex.Lock()
+		session, handle, errno := ctlr.Server.FindHandle(request.SessionId, request.Handle)
+		if errno == 0 {
+			errno = checkLockAccess(handle, exclusive)
+		}
+		var conflict *pb.FileLock
+		if errno == 0 {
+			if lock := ctlr.Server.SetLock(session, handle, exclusive, start, end); lock != nil {
+				conflict = lock.Info()
+			}
+		}
+		released := ctlr.Server.locksChanged
+		ctlr.Server.indexMutex.Unlock()
+
+		switch {
+		case errno != 0:
+			return stream.Send(&pb.LockResponse{ErrorStatus: &pb.ErrorStatus{
+				Code:        uint32(errno),
+				Description: errno.Error(),
+			}})
+		case conflict == nil:
+			return stream.Send(&pb.LockResponse{
+				ErrorStatus: &pb.ErrorStatus{
+					Code:        0,
+					Description: "",
+				},
+				Granted: true,
+			})
+		case !request.Wait:
+			return stream.Send(&pb.LockResponse{
+				ErrorStatus: &pb.ErrorStatus{
+					Code:        uint32(syscall.EAGAIN),
+					Description: syscall.EAGAIN.Error(),
+				},
+				Conflict: conflict,
+			})
+		case !notified:
+			err := stream.Send(&pb.LockResponse{
+				ErrorStatus: &pb.ErrorStatus{
+					Code:        0,
+					Description: "",
+				},
+				Conflict: conflict,
+			})
+			if err != nil {
+				return err
+			}
+			notified = true
+		}
+
+		select {
+		case <-released:
+		case <-stream.Context().Done():
+			// client gave up waiting
+			return stream.Context().Err()
+		}
+	}
+}
+
+func (ctlr *NamingServerController) Unlock(ctx context.Context, request *pb.UnlockRequest) (*pb.UnlockResponse, error) {
+	fmt.Println("Unlock:", request)
+
+	start, end, errno := lockRange(request.Start, request.Length)
+	if errno == 0 {
+		ctlr.Server.indexMutex.Lock()
+		var session *Session
+		var handle *Handle
+		session, handle, errno = ctlr.Server.FindHandle(request.SessionId, request.Handle)
+		if errno == 0 {
+			ctlr.Server.Unlock(session, handle.Inode.ID, start, end)
+		}
+		ctlr.Server.indexMutex.Unlock()
+	}
+	if errno != 0 {
+		return &pb.UnlockResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	return &pb.UnlockResponse{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "",
+	}}, nil
+}
+
+func (ctlr *NamingServerController) TestLock(ctx context.Context, request *pb.LockRequest) (*pb.TestLockResponse, error) {
+	fmt.Println("TestLock:", request)
+
+	start, end, errno := lockRange(request.Start, request.Length)
+	var conflict *pb.FileLock
+	if errno == 0 {
+		ctlr.Server.indexMutex.Lock()
+		var session *Session
+		var handle *Handle
+		session, handle, errno = ctlr.Server.FindHandle(request.SessionId, request.Handle)
+		if errno == 0 && request.Type != pb.LockType_F_UNLCK {
+			exclusive := request.Type == pb.LockType_F_WRLCK
+			if lock := ctlr.Server.FindConflict(handle.Inode.ID, session, exclusive, start, end); lock != nil {
+				conflict = lock.Info()
+			}
+		}
+		ctlr.Server.indexMutex.Unlock()
+	}
+	if errno != 0 {
+		return &pb.TestLockResponse{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}}, nil
+	}
+
+	return &pb.TestLockResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Conflict: conflict,
+	}, nil
+}
+
+func (ctlr *NamingServerController) Watch(request *pb.WatchRequest, stream pb.Naming_WatchServer) error {
+	fmt.Println("Watch:", request)
+
+	// client sends the path to watch and the last event it has seen, if resuming
+	// replay retained events after it, then stream new ones as they are recorded
+
+	sequence := request.AfterSequence
+	if sequence == 0 {
+		sequence = ctlr.Server.LastSequence()
+	}
+
+	for {
+		events, added, errno := ctlr.Server.EventsAfter(sequence)
+		if errno != 0 {
+			return stream.Send(&pb.WatchResponse{ErrorStatus: &pb.ErrorStatus{
+				Code:        uint32(errno),
+				Description: "Events after sequence number are no longer retained",
+			}})
+		}
+
+		for _, event := range events {
+			sequence = event.Sequence
+			if !eventMatches(request, event) {
+				continue
+			}
+			err := stream.Send(&pb.WatchResponse{
+				ErrorStatus: &pb.ErrorStatus{
+					Code:        0,
+					Description: "",
+				},
+				Event: event,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-added:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+func (ctlr *NamingServerController) ReadChanges(ctx context.Context, request *pb.ReadChangesRequest) (*pb.ReadChangesResponse, error) {
+	fmt.Println("ReadChanges:", request)
+
+	limit := int(request.Limit)
+	if limit == 0 {
+		limit = DefaultChangesLimit
+	} else if limit > MaxChangesLimit {
+		limit = MaxChangesLimit
+	}
+
+	events, first, last, err := ctlr.Server.ReadChanges(request.AfterSequence, limit)
+	if err != nil {
+		errno, ok := err.(syscall.Errno)
+		if !ok {
+			errno = syscall.EIO
+		}
+		return &pb.ReadChangesResponse{
+			ErrorStatus: &pb.ErrorStatus{
+				Code:        uint32(errno),
+				Description: err.Error(),
+			},
+			FirstSequence: first,
+			LastSequence:  last,
+		}, nil
+	}
+
+	return &pb.ReadChangesResponse{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "",
+		},
+		Events:        events,
+		FirstSequence: first,
+		LastSequence:  last,
+	}, nil
 }
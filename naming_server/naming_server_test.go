@@ -0,0 +1,202 @@
+package naming_server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"project-dfs/pb"
+	"syscall"
+	"testing"
+)
+
+// Returns a naming server keeping its metadata and changelog in the directory, without storage servers
+func openTestServer(t *testing.T, dir string) *NamingServer {
+	os.Setenv("METADATA_PATH", filepath.Join(dir, "metadata.json"))
+	os.Setenv("CHANGELOG_PATH", filepath.Join(dir, "changelog.jsonl"))
+	defer os.Unsetenv("METADATA_PATH")
+	defer os.Unsetenv("CHANGELOG_PATH")
+
+	server := initNamingServer()
+	err := server.LoadMetadata()
+	if err == nil {
+		err = server.OpenChangelog()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.changelogFile.Close() })
+	return server
+}
+
+func newTestServer(t *testing.T) *NamingServer {
+	dir, err := ioutil.TempDir("", "dfs-naming-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return openTestServer(t, dir)
+}
+
+// Creates the files and directories of the paths, directories ending with a slash
+func createPaths(t *testing.T, server *NamingServer, owner string, paths ...string) {
+	for _, path := range paths {
+		isFile := path[len(path)-1] != '/'
+		if !isFile {
+			path = path[:len(path)-1]
+		}
+		if _, errno := server.LookupNode(path); errno == 0 {
+			continue
+		}
+		if _, errno := server.CreateNodeIfNotExists(path, isFile, owner); errno != 0 {
+			t.Fatalf("creating %s: %v", path, errno)
+		}
+	}
+}
+
+func TestIDsAreNotReusedAfterCrash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dfs-naming-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := openTestServer(t, dir)
+	server.SaveMetadata(nil)
+	createPaths(t, server, "alice", "/a", "/b", "/c")
+	node, _ := server.LookupNode("/c")
+	last := node.ID
+
+	// the naming server stops before the files are saved
+	restarted := openTestServer(t, dir)
+	if _, errno := restarted.LookupNode("/c"); errno == 0 {
+		t.Fatal("unsaved file survived the restart")
+	}
+	createPaths(t, restarted, "alice", "/d")
+	node, _ = restarted.LookupNode("/d")
+	if node.ID <= last {
+		t.Fatalf("ID %d of a file created after the restart was handed out before it (last %d)", node.ID, last)
+	}
+}
+
+func TestCreateThroughFile(t *testing.T) {
+	server := newTestServer(t)
+	createPaths(t, server, "alice", "/f")
+	if errno := server.Symlink("/f", "/l", "alice"); errno != 0 {
+		t.Fatal(errno)
+	}
+
+	for _, path := range []string{"/f/x", "/l/x", "/f/x/y"} {
+		if _, errno := server.CreateFile(path, "alice"); errno != syscall.ENOTDIR {
+			t.Errorf("CreateFile(%s) = %v; want ENOTDIR", path, errno)
+		}
+		if _, errno := server.CreateNodeIfNotExists(path, false, "alice"); errno != syscall.ENOTDIR {
+			t.Errorf("creating directory %s = %v; want ENOTDIR", path, errno)
+		}
+	}
+	for _, path := range []string{"/f", "/l"} {
+		node, _ := server.LookupNode(path)
+		if len(node.Children) != 0 {
+			t.Errorf("%s got children %v", path, node.Children)
+		}
+	}
+}
+
+func TestChangelogFollowsSavedMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dfs-naming-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := openTestServer(t, dir)
+	createPaths(t, server, "alice", "/a", "/b")
+	server.SaveMetadata(nil)
+	saved := server.LastSequence()
+	createPaths(t, server, "alice", "/c")
+	if server.LastSequence() == saved {
+		t.Fatal("creating /c recorded no change")
+	}
+
+	// the naming server stops before /c is saved
+	restarted := openTestServer(t, dir)
+	if sequence := restarted.LastSequence(); sequence != saved {
+		t.Fatalf("sequence after restart = %d; want %d of the saved metadata", sequence, saved)
+	}
+	events, _, _, err := restarted.ReadChanges(0, DefaultChangesLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != int(saved) || events[len(events)-1].Path != "/b" {
+		t.Fatalf("changes after restart = %v; want the ones up to /b", events)
+	}
+
+	createPaths(t, restarted, "alice", "/d")
+	events, _, _, err = restarted.ReadChanges(saved, DefaultChangesLimit)
+	if err != nil || len(events) != 1 || events[0].Path != "/d" || events[0].Sequence != saved+1 {
+		t.Fatalf("changes following the saved ones = %v, %v; want creation of /d", events, err)
+	}
+}
+
+// Checks that the paths exist, directories ending with a slash, and that the missing ones do not
+func checkPaths(t *testing.T, server *NamingServer, exist []string, missing []string) {
+	for _, path := range exist {
+		isDir := path[len(path)-1] == '/'
+		if isDir {
+			path = path[:len(path)-1]
+		}
+		node, errno := server.LookupNode(path)
+		if errno != 0 {
+			t.Errorf("%s: %v", path, errno)
+		} else if (node.Type == DIR) != isDir {
+			t.Errorf("%s has type %v", path, node.Type)
+		}
+	}
+	for _, path := range missing {
+		if _, errno := server.LookupNode(path); errno != syscall.ENOENT {
+			t.Errorf("%s is not removed: %v", path, errno)
+		}
+	}
+}
+
+func TestRename(t *testing.T) {
+	noReplace := uint32(pb.RenameFlag_RENAME_NOREPLACE)
+	exchange := uint32(pb.RenameFlag_RENAME_EXCHANGE)
+	tests := []struct {
+		name    string
+		path    string
+		newPath string
+		flags   uint32
+		errno   syscall.Errno
+		exist   []string
+		missing []string
+	}{
+		{"file", "/file", "/moved", 0, 0, []string{"/moved"}, []string{"/file"}},
+		{"same path", "/file", "/file", 0, 0, []string{"/file"}, nil},
+		{"replacing file", "/file", "/other", 0, 0, []string{"/other"}, []string{"/file"}},
+		{"directory", "/dir", "/full/dir", 0, 0, []string{"/full/dir/", "/full/dir/file"}, []string{"/dir"}},
+		{"replacing empty directory", "/dir", "/empty", 0, 0, []string{"/empty/file"}, []string{"/dir"}},
+		{"replacing non-empty directory", "/dir", "/full", 0, syscall.ENOTEMPTY, []string{"/dir/file", "/full/x"}, nil},
+		{"directory over file", "/dir", "/file", 0, syscall.ENOTDIR, []string{"/dir/", "/file"}, nil},
+		{"file over directory", "/file", "/empty", 0, syscall.EISDIR, []string{"/file", "/empty/"}, nil},
+		{"into own subtree", "/dir", "/dir/sub/dir", 0, syscall.EINVAL, []string{"/dir/sub/"}, []string{"/dir/sub/dir"}},
+		{"missing source", "/missing", "/moved", 0, syscall.ENOENT, nil, []string{"/moved"}},
+		{"missing target directory", "/file", "/missing/file", 0, syscall.ENOENT, []string{"/file"}, nil},
+		{"source under file", "/file/x", "/moved", 0, syscall.ENOTDIR, []string{"/file"}, []string{"/moved"}},
+		{"no replace", "/file", "/other", noReplace, syscall.EEXIST, []string{"/file", "/other"}, nil},
+		{"no replace to new name", "/file", "/moved", noReplace, 0, []string{"/moved"}, []string{"/file"}},
+		{"exchange", "/file", "/dir", exchange, 0, []string{"/file/", "/file/file", "/dir"}, nil},
+		{"exchange with missing", "/file", "/moved", exchange, syscall.ENOENT, []string{"/file"}, []string{"/moved"}},
+		{"exchange with own subtree", "/dir/sub", "/dir", exchange, syscall.EINVAL, []string{"/dir/sub/"}, nil},
+		{"no replace and exchange", "/file", "/other", noReplace | exchange, syscall.EINVAL, []string{"/file", "/other"}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newTestServer(t)
+			createPaths(t, server, "alice", "/dir/", "/dir/file", "/dir/sub/", "/empty/", "/full/", "/full/x", "/file", "/other")
+			if _, errno := server.Rename(test.path, test.newPath, test.flags); errno != test.errno {
+				t.Fatalf("Rename(%s, %s) = %v; want %v", test.path, test.newPath, errno, test.errno)
+			}
+			checkPaths(t, server, test.exist, test.missing)
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package naming_server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"project-dfs/pb"
+	"sort"
+	"time"
+)
+
+// Metadata is saved to disk as JSON, so that the index survives a restart of the naming server.
+// Inodes are saved in a separate table by ID, so that hard links still share them once loaded.
+type savedNode struct {
+	Name       string
+	Type       NodeType
+	Inode      uint64 // ID of the inode in the inode table
+	Children   []*savedNode
+	Versioning *VersioningPolicy
+	Quota      *Quota
+}
+
+type savedSnapshot struct {
+	Name      string
+	Path      string
+	Root      *savedNode
+	CreatedAt time.Time
+}
+
+type savedMetadata struct {
+	Inodes       []*Inode
+	Root         *savedNode
+	Snapshots    []*savedSnapshot
+	Trash        []*TrashEntry
+	TrashCounter uint64
+	InodeCounter uint64
+	UserQuotas   map[string]*Quota
+	NodeStates   map[string]pb.NodeState
+	Sequence     *uint64 // last changelog record the metadata reflects; missing in metadata saved before it
+}
+
+func saveNode(n *Node, inodes map[uint64]bool, table *[]*Inode) *savedNode {
+	if !inodes[n.ID] {
+		inodes[n.ID] = true
+		*table = append(*table, n.Inode)
+	}
+
+	saved := &savedNode{
+		Name:       n.Name,
+		Type:       n.Type,
+		Inode:      n.ID,
+		Versioning: n.Versioning,
+		Quota:      n.Quota,
+	}
+	for _, child := range n.Children {
+		saved.Children = append(saved.Children, saveNode(child, inodes, table))
+	}
+	return saved
+}
+
+func loadNode(saved *savedNode, table map[uint64]*Inode) *Node {
+	n := &Node{
+		Name:       saved.Name,
+		Type:       saved.Type,
+		Children:   make([]*Node, 0, len(saved.Children)),
+		Inode:      table[saved.Inode],
+		Versioning: saved.Versioning,
+		Quota:      saved.Quota,
+	}
+	for _, child := range saved.Children {
+		n.AddChild(loadNode(child, table))
+	}
+	return n
+}
+
+// Serializes the metadata. Caller has to hold the index mutex.
+func (server *NamingServer) MarshalMetadata() ([]byte, error) {
+	inodes := make(map[uint64]bool)
+	var table []*Inode
+
+	// mutations are recorded in the changelog under the index mutex, so the records up to the sequence
+	// number are the ones of the serialized index
+	sequence := server.LastSequence()
+	metadata := savedMetadata{
+		Sequence:     &sequence,
+		Root:         saveNode(server.RootIndexNode, inodes, &table),
+		TrashCounter: server.trashCounter,
+		InodeCounter: server.inodeCounter,
+		UserQuotas:   server.UserQuotas,
+		NodeStates:   make(map[string]pb.NodeState),
+	}
+	server.storageAddressesMutex.Lock()
+	for alias, state := range server.NodeStates {
+		metadata.NodeStates[alias] = state
+	}
+	server.storageAddressesMutex.Unlock()
+	// sorted, so that unchanged metadata is serialized to the same bytes
+	for _, name := range sortedSnapshotNames(server.Snapshots) {
+		snapshot := server.Snapshots[name]
+		metadata.Snapshots = append(metadata.Snapshots, &savedSnapshot{
+			Name:      snapshot.Name,
+			Path:      snapshot.Path,
+			Root:      saveNode(snapshot.Root, inodes, &table),
+			CreatedAt: snapshot.CreatedAt,
+		})
+	}
+	for _, entry := range server.Trash {
+		metadata.Trash = append(metadata.Trash, entry)
+	}
+	sort.Slice(metadata.Trash, func(i, j int) bool {
+		return metadata.Trash[i].ID < metadata.Trash[j].ID
+	})
+	metadata.Inodes = table
+
+	return json.Marshal(metadata)
+}
+
+func sortedSnapshotNames(snapshots map[string]*Snapshot) []string {
+	names := make([]string, 0, len(snapshots))
+	for name := range snapshots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Restores the metadata saved by MarshalMetadata
+func (server *NamingServer) UnmarshalMetadata(data []byte) error {
+	var metadata savedMetadata
+	err := json.Unmarshal(data, &metadata)
+	if err != nil {
+		return err
+	}
+
+	table := make(map[uint64]*Inode, len(metadata.Inodes))
+	for _, inode := range metadata.Inodes {
+		table[inode.ID] = inode
+	}
+
+	server.RootIndexNode = loadNode(metadata.Root, table)
+	server.TrackUsage()
+	server.trashCounter = metadata.TrashCounter
+	if metadata.InodeCounter > server.inodeCounter {
+		// IDs reserved on disk past the saved counter are skipped
+		server.inodeCounter = metadata.InodeCounter
+	}
+	server.savedSequence = metadata.Sequence
+	if metadata.UserQuotas != nil {
+		server.UserQuotas = metadata.UserQuotas
+	}
+	if metadata.NodeStates != nil {
+		server.NodeStates = metadata.NodeStates
+	}
+	for _, snapshot := range metadata.Snapshots {
+		server.Snapshots[snapshot.Name] = &Snapshot{
+			Name:      snapshot.Name,
+			Path:      snapshot.Path,
+			Root:      loadNode(snapshot.Root, table),
+			CreatedAt: snapshot.CreatedAt,
+		}
+	}
+	for _, entry := range metadata.Trash {
+		server.Trash[entry.ID] = entry
+	}
+
+	// only inodes of the live tree can be modified, the ones of snapshots are left out
+	server.Inodes = make(map[uint64]*Inode)
+	server.RootIndexNode.Walk(func(node *Node) {
+		server.Inodes[node.ID] = node.Inode
+	})
+	return nil
+}
+
+// Reads the metadata from disk, if it has been saved before
+func (server *NamingServer) LoadMetadata() error {
+	data, err := ioutil.ReadFile(server.MetadataPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return server.UnmarshalMetadata(data)
+}
+
+// Writes the file next to the previous one, syncs it and renames it over the previous one,
+// so that a crash never leaves it half-written
+func writeFileSynced(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	fd, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	_, err = fd.Write(data)
+	if err == nil {
+		err = fd.Sync()
+	}
+	closeErr := fd.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+	}
+	return err
+}
+
+func (server *NamingServer) SaveLoop() {
+	var saved []byte
+	for {
+		time.Sleep(server.SaveInterval)
+		saved = server.SaveMetadata(saved)
+	}
+}
+
+// Writes the metadata to disk unless it is the same as the previously saved one. Returns the saved metadata.
+func (server *NamingServer) SaveMetadata(previous []byte) []byte {
+	server.indexMutex.Lock()
+	data, err := server.MarshalMetadata()
+	server.indexMutex.Unlock()
+	if err != nil {
+		println("Error serializing metadata:", err.Error())
+		return previous
+	}
+	if bytes.Equal(data, previous) {
+		return previous
+	}
+
+	err = writeFileSynced(server.MetadataPath, data)
+	if err != nil {
+		println("Error saving metadata:", err.Error())
+		return previous
+	}
+	fmt.Println("Saved metadata to", server.MetadataPath)
+	return data
+}
@@ -0,0 +1,349 @@
+package naming_server
+
+import (
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// Limits on a directory subtree or on everything owned by a user; unlimited if zero
+type Quota struct {
+	MaxBytes  int64
+	MaxInodes int64
+}
+
+type Usage struct {
+	Bytes  int64
+	Inodes int64
+}
+
+func (q *Quota) Allows(usage Usage) bool {
+	return (q.MaxBytes == 0 || usage.Bytes <= q.MaxBytes) && (q.MaxInodes == 0 || usage.Inodes <= q.MaxInodes)
+}
+
+// Bytes charged for the file, including space reserved for a write in progress
+func (inode *Inode) charge() int64 {
+	if inode.Reserved > inode.Size {
+		return inode.Reserved
+	}
+	return inode.Size
+}
+
+// Usage of the node itself and everything under it, counting only nodes of the owner if set.
+// Hard links to the same file are counted once.
+func (n *Node) Usage(owner string) Usage {
+	var usage Usage
+	n.collectUsage(owner, &usage, make(map[*Inode]bool))
+	return usage
+}
+
+func (n *Node) collectUsage(owner string, usage *Usage, seen map[*Inode]bool) {
+	if (owner == "" || n.Owner == owner) && !seen[n.Inode] {
+		seen[n.Inode] = true
+		usage.Bytes += n.charge()
+		usage.Inodes++
+	}
+	for _, child := range n.Children {
+		child.collectUsage(owner, usage, seen)
+	}
+}
+
+// Usage of the contents of the directory, not counting the directory itself
+func (n *Node) ContentsUsage() Usage {
+	if n.usage != nil {
+		return n.usage.total
+	}
+	usage := n.Usage("")
+	usage.Bytes -= n.charge()
+	usage.Inodes--
+	return usage
+}
+
+// Usage of the contents of a directory kept up to date as entries are added and removed and files change size,
+// so that checking quotas never walks the subtree. Hard links are counted once, so the tracker counts
+// the entries referring to every inode.
+type usageTracker struct {
+	dir     *Node
+	entries map[*Inode]int    // key:value = inode:entries referring to it
+	owners  map[string]*Usage // key:value = owner:usage
+	total   Usage
+}
+
+// Starts or stops keeping usage of the directory, as it gets or loses its quota
+func (n *Node) trackUsage(enabled bool) {
+	if enabled && n.usage == nil {
+		tracker := &usageTracker{
+			dir:     n,
+			entries: make(map[*Inode]int),
+			owners:  make(map[string]*Usage),
+		}
+		n.usage = tracker
+		for _, child := range n.Children {
+			child.Walk(func(entry *Node) { tracker.count(entry, 1) })
+		}
+	}
+	if !enabled && n.usage != nil {
+		for inode := range n.usage.entries {
+			inode.dropTracker(n.usage)
+		}
+		n.usage = nil
+	}
+}
+
+// Keeps usage of the root, which gives usage of users, and of every directory with a quota
+func (server *NamingServer) TrackUsage() {
+	server.RootIndexNode.Walk(func(n *Node) {
+		if n.Type == DIR && (n.Quota != nil || n == server.RootIndexNode) {
+			n.trackUsage(true)
+		}
+	})
+}
+
+// Counts the entries of the subtree in or out of the usage of the directories above it
+func (n *Node) trackSubtree(node *Node, delta int) {
+	for dir := n; dir != nil; dir = dir.parent {
+		if tracker := dir.usage; tracker != nil {
+			node.Walk(func(entry *Node) { tracker.count(entry, delta) })
+		}
+	}
+}
+
+func (tracker *usageTracker) count(entry *Node, delta int) {
+	inode := entry.Inode
+	if tracker.dir.parent == nil {
+		// the root keeps the entries of every file, so that paths of files are found without a search
+		if delta > 0 {
+			inode.entries = append(inode.entries, entry)
+		} else {
+			inode.dropEntry(entry)
+		}
+	}
+
+	before := tracker.entries[inode]
+	after := before + delta
+	if after > 0 {
+		tracker.entries[inode] = after
+	} else {
+		delete(tracker.entries, inode)
+	}
+	if before == 0 && after > 0 {
+		tracker.charge(inode, inode.charge(), 1)
+		inode.trackers = append(inode.trackers, tracker)
+	}
+	if before > 0 && after == 0 {
+		tracker.charge(inode, -inode.charge(), -1)
+		inode.dropTracker(tracker)
+	}
+}
+
+func (tracker *usageTracker) charge(inode *Inode, bytes int64, inodes int64) {
+	tracker.total.Bytes += bytes
+	tracker.total.Inodes += inodes
+	usage, ok := tracker.owners[inode.Owner]
+	if !ok {
+		usage = &Usage{}
+		tracker.owners[inode.Owner] = usage
+	}
+	usage.Bytes += bytes
+	usage.Inodes += inodes
+}
+
+func (tracker *usageTracker) ownerUsage(owner string) Usage {
+	if usage, ok := tracker.owners[owner]; ok {
+		return *usage
+	}
+	return Usage{}
+}
+
+func (inode *Inode) dropTracker(tracker *usageTracker) {
+	for i, t := range inode.trackers {
+		if t == tracker {
+			inode.trackers = append(inode.trackers[:i], inode.trackers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (inode *Inode) dropEntry(entry *Node) {
+	for i, n := range inode.entries {
+		if n == entry {
+			inode.entries = append(inode.entries[:i], inode.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// Changes the size of the file and the space reserved for it, updating usage of the directories with the file
+func (inode *Inode) resize(size int64, reserved int64) {
+	before := inode.charge()
+	inode.Size = size
+	inode.Reserved = reserved
+	if bytes := inode.charge() - before; bytes != 0 {
+		for _, tracker := range inode.trackers {
+			tracker.charge(inode, bytes, 0)
+		}
+	}
+}
+
+// Returns the existing directories on the path, from the root down, that limit usage of their subtree
+func (server *NamingServer) QuotaDirectories(path string) []*Node {
+	var dirs []*Node
+	node := server.RootIndexNode
+	if node.Quota != nil {
+		dirs = append(dirs, node)
+	}
+	if path == "" {
+		return dirs
+	}
+
+	for _, s := range strings.Split(path, "/")[1:] {
+		node = node.GetChild(s)
+		if node == nil || node.Type != DIR {
+			break
+		}
+		if node.Quota != nil {
+			dirs = append(dirs, node)
+		}
+	}
+	return dirs
+}
+
+// Checks that the path can grow by the bytes and inodes without exceeding quotas of its directories or of the user
+func (server *NamingServer) CheckQuotas(path string, user string, bytes int64, inodes int64) syscall.Errno {
+	for _, dir := range server.QuotaDirectories(path) {
+		usage := dir.ContentsUsage()
+		usage.Bytes += bytes
+		usage.Inodes += inodes
+		if !dir.Quota.Allows(usage) {
+			return syscall.EDQUOT
+		}
+	}
+
+	return server.checkUserQuota(user, bytes, inodes)
+}
+
+func (server *NamingServer) checkUserQuota(user string, bytes int64, inodes int64) syscall.Errno {
+	quota, ok := server.UserQuotas[user]
+	if ok && user != "" {
+		usage := server.RootIndexNode.usage.ownerUsage(user)
+		usage.Bytes += bytes
+		usage.Inodes += inodes
+		if !quota.Allows(usage) {
+			return syscall.EDQUOT
+		}
+	}
+	return 0
+}
+
+// Counts the nodes that creating the path would add to the index
+func (server *NamingServer) MissingNodes(path string) int64 {
+	node := server.RootIndexNode
+	segments := strings.Split(path, "/")[1:]
+	for i, s := range segments {
+		node = node.GetChild(s)
+		if node == nil {
+			return int64(len(segments) - i)
+		}
+	}
+	return 0
+}
+
+// Checks that the node moved to the new path fits into the quotas of the directories it enters
+func (server *NamingServer) CheckMoveQuotas(node *Node, path string, newPath string) syscall.Errno {
+	moved := node.Usage("")
+	previous := server.QuotaDirectories(path)
+	for _, dir := range server.QuotaDirectories(newPath) {
+		if dir == node || containsNode(previous, dir) {
+			// already counted there
+			continue
+		}
+		usage := dir.ContentsUsage()
+		usage.Bytes += moved.Bytes
+		usage.Inodes += moved.Inodes
+		if !dir.Quota.Allows(usage) {
+			return syscall.EDQUOT
+		}
+	}
+	return 0
+}
+
+func containsNode(nodes []*Node, node *Node) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+// Reserves space for the file to grow to the size before its primary replica writes it
+func (server *NamingServer) ReserveSpace(fileId uint64, size int64) syscall.Errno {
+	inode, ok := server.Inodes[fileId]
+	if !ok {
+		return syscall.ENOENT
+	}
+
+	growth := size - inode.charge()
+	if growth > 0 {
+		// quotas of all directories with an entry of the file apply
+		for _, tracker := range inode.trackers {
+			quota := tracker.dir.Quota
+			if quota != nil && !quota.Allows(Usage{Bytes: tracker.total.Bytes + growth, Inodes: tracker.total.Inodes}) {
+				return syscall.EDQUOT
+			}
+		}
+		errno := server.checkUserQuota(inode.Owner, growth, 0)
+		if errno != 0 {
+			return errno
+		}
+	}
+	if size > inode.Size {
+		inode.resize(inode.Size, size)
+	}
+	return 0
+}
+
+// Drops the space reserved for a write the primary replica failed to apply
+func (server *NamingServer) ReleaseSpace(fileId uint64) syscall.Errno {
+	inode, ok := server.Inodes[fileId]
+	if !ok {
+		return syscall.ENOENT
+	}
+	inode.resize(inode.Size, 0)
+	return 0
+}
+
+type QuotaReport struct {
+	Path  string
+	User  string
+	Quota Quota
+	Usage Usage
+}
+
+// Usage of every directory and user that has a quota, ordered by path and then by user
+func (server *NamingServer) ReportUsage() []QuotaReport {
+	var report []QuotaReport
+
+	var walk func(path string, node *Node)
+	walk = func(path string, node *Node) {
+		if node.Quota != nil {
+			report = append(report, QuotaReport{Path: path, Quota: *node.Quota, Usage: node.ContentsUsage()})
+		}
+		for _, child := range node.Children {
+			if child.Type == DIR {
+				walk(path+"/"+child.Name, child)
+			}
+		}
+	}
+	walk("", server.RootIndexNode)
+
+	var users []string
+	for user := range server.UserQuotas {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	for _, user := range users {
+		report = append(report, QuotaReport{User: user, Quota: *server.UserQuotas[user], Usage: server.RootIndexNode.usage.ownerUsage(user)})
+	}
+	return report
+}
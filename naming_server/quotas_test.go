@@ -0,0 +1,138 @@
+package naming_server
+
+import (
+	"context"
+	"syscall"
+	"testing"
+)
+
+// Usage of the contents of the directory counted by walking them, of the owner if set
+func recount(dir *Node, owner string) Usage {
+	var usage Usage
+	seen := make(map[*Inode]bool)
+	for _, child := range dir.Children {
+		child.collectUsage(owner, &usage, seen)
+	}
+	return usage
+}
+
+// Checks that usage kept by the trackers matches a full recount of every directory and user
+func checkUsage(t *testing.T, server *NamingServer, step string) {
+	server.RootIndexNode.Walk(func(dir *Node) {
+		tracker := dir.usage
+		if tracker == nil {
+			return
+		}
+		if want := recount(dir, ""); tracker.total != want {
+			t.Errorf("after %s, usage of %q = %+v; recount gives %+v", step, dir.Name, tracker.total, want)
+		}
+		for _, owner := range []string{"alice", "bob"} {
+			if usage, want := tracker.ownerUsage(owner), recount(dir, owner); usage != want {
+				t.Errorf("after %s, usage of %s in %q = %+v; recount gives %+v", step, owner, dir.Name, usage, want)
+			}
+		}
+	})
+}
+
+func TestUsageTracking(t *testing.T) {
+	server := newTestServer(t)
+	createPaths(t, server, "alice", "/q/", "/q/a/", "/q/a/f", "/q/g")
+	createPaths(t, server, "bob", "/h")
+	q, _ := server.LookupNode("/q")
+	q.Quota = &Quota{MaxBytes: 1 << 30}
+	q.trackUsage(true)
+	checkUsage(t, server, "create")
+
+	f, _ := server.LookupNode("/q/a/f")
+	f.resize(1000, 0)
+	checkUsage(t, server, "truncate")
+	if errno := server.ReserveSpace(f.ID, 5000); errno != 0 {
+		t.Fatal(errno)
+	}
+	checkUsage(t, server, "reserve")
+	if errno := server.ReleaseSpace(f.ID); errno != 0 {
+		t.Fatal(errno)
+	}
+	checkUsage(t, server, "release")
+
+	if _, errno := server.Rename("/q/a/f", "/f", 0); errno != 0 {
+		t.Fatal(errno)
+	}
+	if _, errno := server.Rename("/h", "/q/a/h", 0); errno != 0 {
+		t.Fatal(errno)
+	}
+	checkUsage(t, server, "rename")
+
+	if errno := server.Link("/f", "/q/link"); errno != 0 {
+		t.Fatal(errno)
+	}
+	if errno := server.Link("/f", "/q/a/link"); errno != 0 {
+		t.Fatal(errno)
+	}
+	f.resize(3000, 0)
+	checkUsage(t, server, "link")
+	if node, errno := server.Unlink("/q/link", FILE, false); errno != 0 {
+		t.Fatal(errno)
+	} else {
+		server.ReleaseInodes(node)
+	}
+	checkUsage(t, server, "unlink")
+
+	node, errno := server.Unlink("/q/a", DIR, true)
+	if errno != 0 {
+		t.Fatal(errno)
+	}
+	if server.MoveToTrash("/q/a", node, "alice") == nil {
+		t.Fatal("trash did not take /q/a")
+	}
+	checkUsage(t, server, "trash")
+
+	createPaths(t, server, "alice", "/q/b/", "/q/b/x")
+	if _, _, errno := server.CreateSnapshot(context.Background(), "/q", "s"); errno != 0 {
+		t.Fatal(errno)
+	}
+	checkUsage(t, server, "snapshot")
+}
+
+func TestQuotaLimits(t *testing.T) {
+	server := newTestServer(t)
+	createPaths(t, server, "alice", "/q/", "/q/f", "/d/", "/d/x", "/d/y")
+	q, _ := server.LookupNode("/q")
+	q.Quota = &Quota{MaxInodes: 3, MaxBytes: 1000}
+	q.trackUsage(true)
+	server.UserQuotas["alice"] = &Quota{MaxInodes: 6}
+
+	tests := []struct {
+		name   string
+		path   string
+		user   string
+		bytes  int64
+		inodes int64
+		errno  syscall.Errno
+	}{
+		{"within quota of the directory", "/q/g", "bob", 0, 2, 0},
+		{"over inodes of the directory", "/q/g", "bob", 0, 3, syscall.EDQUOT},
+		{"over bytes of the directory", "/q/g", "bob", 1001, 1, syscall.EDQUOT},
+		{"outside the directory", "/g", "bob", 1001, 3, 0},
+		{"within quota of the user", "/g", "alice", 0, 1, 0},
+		{"over quota of the user", "/g", "alice", 0, 2, syscall.EDQUOT},
+	}
+	for _, test := range tests {
+		if errno := server.CheckQuotas(test.path, test.user, test.bytes, test.inodes); errno != test.errno {
+			t.Errorf("%s: CheckQuotas = %v; want %v", test.name, errno, test.errno)
+		}
+	}
+
+	d, _ := server.LookupNode("/d")
+	if errno := server.CheckMoveQuotas(d, "/d", "/q/d"); errno != syscall.EDQUOT {
+		t.Errorf("moving 3 inodes into the directory with room for 2 = %v; want EDQUOT", errno)
+	}
+	x, _ := server.LookupNode("/d/x")
+	if errno := server.CheckMoveQuotas(x, "/d/x", "/q/x"); errno != 0 {
+		t.Errorf("moving a file into the directory = %v", errno)
+	}
+	f, _ := server.LookupNode("/q/f")
+	if errno := server.CheckMoveQuotas(f, "/q/f", "/q/g"); errno != 0 {
+		t.Errorf("moving a file within the directory = %v", errno)
+	}
+}
@@ -0,0 +1,176 @@
+package naming_server
+
+import (
+	"context"
+	"fmt"
+	utils "project-dfs"
+	"project-dfs/pb"
+	"sort"
+	"time"
+)
+
+// fileReplicas is a snapshot of the index entry of a single file taken for reconciliation
+type fileReplicas struct {
+	id      uint64
+	version uint64
+	aliases []string
+}
+
+// Collects the files of the inode table, so that hard links are reconciled only once
+func (server *NamingServer) collectFiles() []fileReplicas {
+	var files []fileReplicas
+	for id, inode := range server.Inodes {
+		if len(inode.Storages) == 0 {
+			// directories and symbolic links live only in the index
+			continue
+		}
+
+		var aliases []string
+		for _, storage := range inode.Storages {
+			aliases = append(aliases, storage.Alias)
+		}
+		files = append(files, fileReplicas{
+			id:      id,
+			version: inode.Version,
+			aliases: aliases,
+		})
+	}
+	return files
+}
+
+func (server *NamingServer) ReconcileLoop() {
+	for {
+		time.Sleep(server.ReconcileInterval)
+		server.ReconcileReplicas()
+		if server.HasDrainingNodes() {
+			server.Rebalance(context.Background(), false, false)
+		}
+	}
+}
+
+// Compares versions of all replicas with the index and makes
+// replicas with lower versions fetch the file from an up-to-date one.
+func (server *NamingServer) ReconcileReplicas() {
+	server.indexMutex.Lock()
+	files := server.collectFiles()
+	server.indexMutex.Unlock()
+
+	for _, file := range files {
+		server.reconcileFile(file)
+	}
+}
+
+func (server *NamingServer) reconcileFile(file fileReplicas) {
+	var stale []*StorageServerInfo
+	var staleAliases []string
+	source := ""
+
+	for _, alias := range file.aliases {
+		info, ok := server.GetAddress(alias)
+		if !ok {
+			continue
+		}
+		ss := server.GetStorageServer(info.privateAddress)
+		if ss == nil {
+			continue
+		}
+
+		response, err := ss.GetFileInfo(context.Background(), &pb.GetFileInfoArgs{FileId: file.id})
+		if err != nil {
+			// The server is unreachable; nothing can be done about it now
+			println("Error checking replica", alias, "of file", file.id, ":", err.Error())
+			continue
+		}
+
+		if response.ErrorStatus.Code == 0 && response.Version >= file.version {
+			if source == "" {
+				source = info.privateAddress
+			}
+			continue
+		}
+		stale = append(stale, info)
+		staleAliases = append(staleAliases, alias)
+	}
+
+	if len(stale) == 0 {
+		return
+	}
+	if source == "" {
+		fmt.Println("No up-to-date replica of file", file.id, "to reconcile", staleAliases, "from")
+		return
+	}
+
+	for i, info := range stale {
+		fmt.Println("Replica", staleAliases[i], "of file", file.id, "is stale; fetching version", file.version)
+		response, err := server.GetStorageServer(info.privateAddress).FetchFile(context.Background(), &pb.FetchFileArgs{
+			FileId:        file.id,
+			SourceAddress: source,
+			Version:       file.version,
+		})
+		if err != nil {
+			println("Error reconciling replica", staleAliases[i], "of file", file.id, ":", err.Error())
+			continue
+		}
+		if response.ErrorStatus.Code != 0 {
+			println("Error reconciling replica", staleAliases[i], "of file", file.id, ":", response.ErrorStatus.Description)
+		}
+	}
+}
+
+// Compares the inventory a storage server sends at registration with the replicas assigned to it,
+// including the ones preserved for snapshots. Returns the replicas it lacks or holds in older versions,
+// along with the live servers it can fetch them from.
+func (server *NamingServer) ReconcileInventory(alias string, inventory []*pb.StoredFile) []*pb.AssignedReplica {
+	held := make(map[uint64]uint64, len(inventory))
+	for _, file := range inventory {
+		held[file.FileId] = file.Version
+	}
+
+	server.indexMutex.Lock()
+	defer server.indexMutex.Unlock()
+	view := server.replicaView()
+
+	fetch := make([]*pb.AssignedReplica, 0)
+	unassigned := 0
+	for fileId := range held {
+		if !view.holds(fileId, alias) {
+			unassigned++
+		}
+	}
+	for fileId, aliases := range view.holders {
+		if !utils.Contains(aliases, alias) {
+			continue
+		}
+		version := view.versions[fileId]
+		if heldVersion, ok := held[fileId]; ok && heldVersion >= version {
+			continue
+		}
+
+		var sources []*pb.DiscoveredStorage
+		for _, source := range aliases {
+			if source == alias || !server.IsAlive(source) {
+				continue
+			}
+			info, ok := server.GetAddress(source)
+			if !ok {
+				continue
+			}
+			sources = append(sources, &pb.DiscoveredStorage{
+				Alias:         source,
+				Address:       info.privateAddress,
+				PublicAddress: info.publicAddress,
+			})
+		}
+		if len(sources) == 0 {
+			fmt.Println("No replica of file", fileId, "for", alias, "to fetch from")
+			continue
+		}
+		fetch = append(fetch, &pb.AssignedReplica{FileId: fileId, Version: version, Sources: sources})
+	}
+	sort.Slice(fetch, func(i, j int) bool {
+		return fetch[i].FileId < fetch[j].FileId
+	})
+
+	fmt.Println("Storage server", alias, "holds", len(held), "replicas;", len(fetch), "to fetch,", unassigned, "not assigned to it")
+	return fetch
+}
@@ -0,0 +1,145 @@
+package naming_server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"project-dfs/pb"
+	"syscall"
+	"time"
+)
+
+// Session of a client. Handles opened in it stay open as long as the client keeps it alive.
+type Session struct {
+	ID      uint64
+	User    string
+	Expires time.Time
+	Handles map[uint64]*Handle // key:value = handleId:handle
+}
+
+// Handle of a file opened in a session
+type Handle struct {
+	ID    uint64
+	Inode *Inode
+	Flags uint32
+}
+
+func (handle *Handle) IsWritable() bool {
+	return handle.Flags&(uint32(pb.OpenFlag_O_WRONLY)|uint32(pb.OpenFlag_O_RDWR)) != 0
+}
+
+func (session *Session) IsValid() bool {
+	return time.Now().Before(session.Expires)
+}
+
+// Starts a new session of the user. Caller has to hold the index mutex.
+func (server *NamingServer) OpenSession(user string) *Session {
+	server.sessionCounter++
+	session := &Session{
+		ID:      server.sessionCounter,
+		User:    user,
+		Expires: time.Now().Add(server.SessionTimeout),
+		Handles: make(map[uint64]*Handle),
+	}
+	server.Sessions[session.ID] = session
+	return session
+}
+
+// Returns the session unless it has expired. Caller has to hold the index mutex.
+func (server *NamingServer) FindSession(id uint64) (*Session, syscall.Errno) {
+	session, ok := server.Sessions[id]
+	if !ok || !session.IsValid() {
+		return nil, syscall.ESTALE
+	}
+	return session, 0
+}
+
+// Returns the handle opened in the session. Caller has to hold the index mutex.
+func (server *NamingServer) FindHandle(sessionId uint64, handleId uint64) (*Session, *Handle, syscall.Errno) {
+	session, errno := server.FindSession(sessionId)
+	if errno != 0 {
+		return nil, nil, errno
+	}
+	handle, ok := session.Handles[handleId]
+	if !ok {
+		return nil, nil, syscall.EBADF
+	}
+	return session, handle, 0
+}
+
+// Opens the file at the path in the session. Caller has to hold the index mutex.
+func (server *NamingServer) OpenFile(session *Session, path string, flags uint32) (*Handle, syscall.Errno) {
+	node, ok := server.FindNode(path)
+	if !ok {
+		return nil, syscall.ENOENT
+	}
+	switch node.Type {
+	case DIR:
+		return nil, syscall.EISDIR
+	case SYMLINK:
+		// symbolic links are resolved by clients
+		return nil, syscall.ELOOP
+	}
+
+	server.handleCounter++
+	handle := &Handle{
+		ID:    server.handleCounter,
+		Inode: node.Inode,
+		Flags: flags,
+	}
+	if handle.IsWritable() && IsInSnapshot(path) {
+		return nil, syscall.EROFS
+	}
+	session.Handles[handle.ID] = handle
+	node.Opens++
+	return handle, 0
+}
+
+// Closes the handle, releasing locks of the session on the file like close(2) does.
+// Returns the removals of the file that storage servers have to apply
+// if the file has been deleted while open. Caller has to hold the index mutex.
+func (server *NamingServer) CloseHandle(session *Session, handle *Handle) []StorageOp {
+	delete(session.Handles, handle.ID)
+	server.Unlock(session, handle.Inode.ID, 0, math.MaxInt64)
+	handle.Inode.Opens--
+	if handle.Inode.Opens > 0 || handle.Inode.Links > 0 {
+		return nil
+	}
+	return server.releaseInode(handle.Inode)
+}
+
+// Ends the session and closes its handles. Caller has to hold the index mutex.
+func (server *NamingServer) CloseSession(session *Session) []StorageOp {
+	var ops []StorageOp
+	for _, handle := range session.Handles {
+		ops = append(ops, server.CloseHandle(session, handle)...)
+	}
+	delete(server.Sessions, session.ID)
+	// requests of the session waiting for locks give up
+	server.notifyLockWaiters()
+	return ops
+}
+
+func (server *NamingServer) SessionLoop() {
+	for {
+		time.Sleep(server.SessionTimeout)
+		server.ExpireSessions()
+	}
+}
+
+// Closes sessions of clients that have stopped keeping them alive
+func (server *NamingServer) ExpireSessions() {
+	var ops []StorageOp
+
+	server.indexMutex.Lock()
+	for _, session := range server.Sessions {
+		if session.IsValid() {
+			continue
+		}
+		fmt.Println("Session", session.ID, "of", session.User, "has expired; closing", len(session.Handles), "handles")
+		ops = append(ops, server.CloseSession(session)...)
+	}
+	server.indexMutex.Unlock()
+
+	server.ApplyStorageOps(context.Background(), ops)
+}
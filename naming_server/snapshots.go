@@ -0,0 +1,271 @@
+package naming_server
+
+import (
+	"context"
+	"project-dfs/pb"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Snapshots are read-only and reachable under /.snapshots/<name>/
+const SnapshotsDirectory = "/.snapshots"
+
+// Snapshot holds a clone of the index subtree taken at creation time.
+// File contents are preserved by storage servers under the IDs of the clones; a file is copied only once it is modified.
+type Snapshot struct {
+	Name      string
+	Path      string
+	Root      *Node
+	CreatedAt time.Time
+}
+
+func IsInSnapshot(path string) bool {
+	return path == SnapshotsDirectory || strings.HasPrefix(path, SnapshotsDirectory+"/")
+}
+
+// Clones the subtree for a snapshot. Cloned inodes get IDs of their own, but are not registered in the inode table,
+// so that they cannot be modified. Collects the files that every storage server has to preserve for the clones.
+func (server *NamingServer) cloneNode(n *Node, clones map[*Inode]*Inode, files map[string][]*pb.SnapshotFile) *Node {
+	clone := &Node{
+		Name:     n.Name,
+		Type:     n.Type,
+		Children: make([]*Node, 0, len(n.Children)),
+		Inode:    clones[n.Inode],
+	}
+	// hard links of the subtree keep sharing the cloned inode
+	if clone.Inode == nil {
+		clone.Inode = &Inode{
+			ID:       server.allocateID(),
+			Storages: make([]*StorageInfo, 0, len(n.Storages)),
+			Version:  n.Version,
+			Size:     n.Size,
+			Owner:    n.Owner,
+			Links:    n.Links,
+			Target:   n.Target,
+		}
+		clone.Xattrs = n.CopyXattrs()
+		for _, storage := range n.Storages {
+			clone.Storages = append(clone.Storages, &StorageInfo{Alias: storage.Alias})
+			files[storage.Alias] = append(files[storage.Alias], &pb.SnapshotFile{
+				FileId:         n.ID,
+				SnapshotFileId: clone.ID,
+			})
+		}
+		clones[n.Inode] = clone.Inode
+	}
+	for _, child := range n.Children {
+		clone.Children = append(clone.Children, server.cloneNode(child, clones, files))
+	}
+	return clone
+}
+
+// Forgets the storage server as a holder of the file
+func (inode *Inode) RemoveReplica(alias string) {
+	storages := make([]*StorageInfo, 0, len(inode.Storages))
+	for _, storage := range inode.Storages {
+		if storage.Alias != alias {
+			storages = append(storages, storage)
+		}
+	}
+	inode.Storages = storages
+}
+
+// Forgets the storage server as a holder of any file of the subtree
+func (n *Node) RemoveStorage(alias string) {
+	n.RemoveReplica(alias)
+	for _, child := range n.Children {
+		child.RemoveStorage(alias)
+	}
+}
+
+// Resolves a path under /.snapshots in the snapshot trees
+func (server *NamingServer) FindSnapshotNode(path string) (*Node, bool) {
+	segments := strings.Split(strings.TrimPrefix(path, SnapshotsDirectory), "/")[1:]
+	if len(segments) == 0 {
+		// list of all snapshots
+		node := NewNode(SnapshotsDirectory[1:], DIR)
+		for _, snapshot := range server.Snapshots {
+			// snapshot trees keep no parents
+			node.Children = append(node.Children, snapshot.Root)
+		}
+		sort.Slice(node.Children, func(i, j int) bool {
+			return node.Children[i].Name < node.Children[j].Name
+		})
+		return node, true
+	}
+
+	snapshot, ok := server.Snapshots[segments[0]]
+	if !ok {
+		return nil, false
+	}
+	node := snapshot.Root
+	for _, s := range segments[1:] {
+		node = node.GetChild(s)
+		if node == nil {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// Clones the subtree into a new snapshot and makes storage servers preserve its files.
+// The index is not locked while files are preserved, so the clones take the versions storage servers
+// actually preserved. Replicas preserved at an older version than another one are dropped from the snapshot.
+func (server *NamingServer) CreateSnapshot(ctx context.Context, path string, name string) ([]StorageOp, []*pb.ErrorStatus, syscall.Errno) {
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return nil, nil, syscall.EINVAL
+	}
+	if IsInSnapshot(path) || IsInTrash(path) {
+		return nil, nil, syscall.EINVAL
+	}
+
+	server.indexMutex.Lock()
+	if _, ok := server.Snapshots[name]; ok {
+		server.indexMutex.Unlock()
+		return nil, nil, syscall.EEXIST
+	}
+	node, errno := server.LookupNode(path)
+	if errno == 0 && node.Type != DIR {
+		errno = syscall.ENOTDIR
+	}
+	if errno != 0 {
+		server.indexMutex.Unlock()
+		return nil, nil, errno
+	}
+
+	files := make(map[string][]*pb.SnapshotFile)
+	root := server.cloneNode(node, make(map[*Inode]*Inode), files)
+	root.Name = name
+	snapshot := &Snapshot{
+		Name:      name,
+		Path:      path,
+		Root:      root,
+		CreatedAt: time.Now(),
+	}
+	server.indexMutex.Unlock()
+
+	var failed []StorageOp
+	var statuses []*pb.ErrorStatus
+	preserved := make(map[uint64]map[string]*pb.StoredFile) // key:value = fileId:(alias:preserved file)
+	for alias, aliasFiles := range files {
+		aliasPreserved, status := server.snapshotStorage(ctx, alias, &pb.SnapshotArgs{Files: aliasFiles})
+		if status != nil && status.Code != 0 {
+			println("Error creating snapshot", name, "on", alias, ":", status.Description)
+			failed = append(failed, StorageOp{Alias: alias})
+			statuses = append(statuses, status)
+			continue
+		}
+		for _, file := range aliasPreserved {
+			if preserved[file.FileId] == nil {
+				preserved[file.FileId] = make(map[string]*pb.StoredFile)
+			}
+			preserved[file.FileId][alias] = file
+		}
+	}
+
+	server.indexMutex.Lock()
+	var ops []StorageOp // removals of preserved files left out of the snapshot
+	if _, ok := server.Snapshots[name]; ok {
+		// another snapshot of the same name was created meanwhile
+		for fileId, holders := range preserved {
+			for alias := range holders {
+				ops = append(ops, StorageOp{Alias: alias, Kind: RemoveOp, FileID: fileId})
+			}
+		}
+		errno = syscall.EEXIST
+	} else {
+		for _, op := range failed {
+			root.RemoveStorage(op.Alias)
+		}
+		fixed := make(map[uint64]bool)
+		root.Walk(func(n *Node) {
+			holders, ok := preserved[n.ID]
+			if !ok || fixed[n.ID] {
+				return
+			}
+			fixed[n.ID] = true
+
+			var latest *pb.StoredFile
+			for _, file := range holders {
+				if latest == nil || file.Version > latest.Version {
+					latest = file
+				}
+			}
+			n.Version = latest.Version
+			n.Size = latest.Size
+			for alias, file := range holders {
+				if file.Version < latest.Version {
+					n.RemoveReplica(alias)
+					ops = append(ops, StorageOp{Alias: alias, Kind: RemoveOp, FileID: n.ID})
+				}
+			}
+		})
+		server.Snapshots[name] = snapshot
+		server.Notify(pb.EventType_EVENT_CREATE, pb.NodeMode_DIRECTORY, SnapshotsDirectory+"/"+name, "")
+	}
+	server.indexMutex.Unlock()
+
+	// preserved files left behind are found by fsck as orphans
+	server.ApplyStorageOps(ctx, ops)
+	if errno != 0 {
+		return nil, nil, errno
+	}
+	return failed, statuses, 0
+}
+
+// Forgets the snapshot and makes storage servers drop the preserved files
+func (server *NamingServer) DeleteSnapshot(ctx context.Context, name string) syscall.Errno {
+	server.indexMutex.Lock()
+	snapshot, ok := server.Snapshots[name]
+	if !ok {
+		server.indexMutex.Unlock()
+		return syscall.ENOENT
+	}
+	delete(server.Snapshots, name)
+	server.Notify(pb.EventType_EVENT_DELETE, pb.NodeMode_DIRECTORY, SnapshotsDirectory+"/"+name, "")
+
+	var ops []StorageOp
+	removed := make(map[uint64]bool)
+	snapshot.Root.Walk(func(node *Node) {
+		if removed[node.ID] {
+			return
+		}
+		removed[node.ID] = true
+		for _, storage := range node.Storages {
+			ops = append(ops, StorageOp{Alias: storage.Alias, Kind: RemoveOp, FileID: node.ID})
+		}
+	})
+	server.indexMutex.Unlock()
+
+	failed, statuses := server.ApplyStorageOps(ctx, ops)
+	for i, op := range failed {
+		println("Error deleting snapshot", name, "on", op.Alias, ":", statuses[i].Description)
+	}
+	return 0
+}
+
+// Makes the storage server preserve the files and returns their preserved versions
+func (server *NamingServer) snapshotStorage(ctx context.Context, alias string, args *pb.SnapshotArgs) ([]*pb.StoredFile, *pb.ErrorStatus) {
+	info, ok := server.GetAddress(alias)
+	if !ok {
+		return nil, &pb.ErrorStatus{
+			Code:        uint32(syscall.ENXIO),
+			Description: "Storage server " + alias + " is not registered",
+		}
+	}
+	ss := server.GetStorageServer(info.privateAddress)
+	if ss == nil {
+		return nil, &pb.ErrorStatus{
+			Code:        uint32(syscall.EIO),
+			Description: "No connection to storage server " + alias,
+		}
+	}
+
+	response, err := ss.CreateSnapshot(ctx, args)
+	if err != nil {
+		return nil, &pb.ErrorStatus{Code: uint32(syscall.EIO), Description: err.Error()}
+	}
+	return response.Files, response.ErrorStatus
+}
@@ -0,0 +1,157 @@
+package naming_server
+
+import (
+	"context"
+	"fmt"
+	utils "project-dfs"
+	"project-dfs/pb"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Deleted nodes are kept in the index under /.trash/<user>/<entry id>/ until purged
+const TrashDirectory = "/.trash"
+
+type TrashEntry struct {
+	ID           uint64
+	User         string
+	OriginalPath string
+	DeletedAt    time.Time
+}
+
+// Path of the directory holding the deleted node
+func (entry *TrashEntry) Path() string {
+	return TrashDirectory + "/" + entry.User + "/" + strconv.FormatUint(entry.ID, 10)
+}
+
+// Path of the deleted node itself
+func (entry *TrashEntry) NodePath() string {
+	return entry.Path() + "/" + utils.NamePart(entry.OriginalPath)
+}
+
+func IsInTrash(path string) bool {
+	return path == TrashDirectory || strings.HasPrefix(path, TrashDirectory+"/")
+}
+
+// Users can only delete or move entries in their own trash; its directories are managed by the naming server
+func CheckTrashAccess(path string, user string) syscall.Errno {
+	if !IsInTrash(path) {
+		return 0
+	}
+	// "", ".trash", user, entry id, ...
+	segments := strings.Split(path, "/")
+	if len(segments) < 4 || segments[2] != user {
+		return syscall.EPERM
+	}
+	return 0
+}
+
+// Requests that do not name the user act on behalf of the default one
+func UserName(user string) string {
+	if user == "" {
+		return "default"
+	}
+	return user
+}
+
+// Puts the node unlinked from the path into the trash of the user.
+// Storage servers keep the files by ID, so they are not involved.
+// Returns nil if the trash cannot take the node, and the node has to be released at once.
+func (server *NamingServer) MoveToTrash(path string, node *Node, user string) *TrashEntry {
+	entry := &TrashEntry{
+		ID:           server.trashCounter + 1,
+		User:         user,
+		OriginalPath: path,
+		DeletedAt:    time.Now(),
+	}
+	dir, errno := server.CreateNodeIfNotExists(entry.Path(), false, "")
+	if errno != 0 {
+		println("Error moving", path, "to the trash:", errno.Error())
+		return nil
+	}
+	server.trashCounter++
+	server.Trash[entry.ID] = entry
+
+	dir.AddChild(node)
+	// entries in the trash still refer to the inodes
+	node.AdjustLinks(1)
+	return entry
+}
+
+// Moves the node of the trash entry back to the original path or to the new one, if set.
+// Returns the restored path.
+func (server *NamingServer) Restore(id uint64, user string, newPath string) (string, syscall.Errno) {
+	entry, ok := server.Trash[id]
+	if !ok {
+		return "", syscall.ENOENT
+	}
+	if entry.User != user {
+		return "", syscall.EPERM
+	}
+
+	if newPath == "" {
+		newPath = entry.OriginalPath
+	}
+	if IsInTrash(newPath) {
+		return "", syscall.EINVAL
+	}
+
+	_, errno := server.Rename(entry.NodePath(), newPath, uint32(pb.RenameFlag_RENAME_NOREPLACE))
+	if errno != 0 {
+		return "", errno
+	}
+
+	if entryNode, errno := server.Unlink(entry.Path(), DIR, false); errno == 0 {
+		server.ReleaseInodes(entryNode)
+	}
+	delete(server.Trash, id)
+	server.removeEmptyTrash(user)
+	return newPath, 0
+}
+
+// Removes the trash directory of the user once it holds no entries
+func (server *NamingServer) removeEmptyTrash(user string) {
+	if node, errno := server.Unlink(TrashDirectory+"/"+user, DIR, false); errno == 0 {
+		server.ReleaseInodes(node)
+	}
+}
+
+// Periodically reclaims space held by the trash, by expired file versions and by the changelog
+func (server *NamingServer) PurgeLoop() {
+	for {
+		time.Sleep(time.Minute)
+		server.PurgeTrash()
+		server.ExpireAllVersions()
+		server.CompactChangelog()
+	}
+}
+
+// Removes trash entries older than the retention period and makes storage servers reclaim their space
+func (server *NamingServer) PurgeTrash() {
+	var ops []StorageOp
+
+	purged := make(map[string]bool) // users whose trash may be left empty
+	server.indexMutex.Lock()
+	for id, entry := range server.Trash {
+		if time.Since(entry.DeletedAt) < server.TrashRetention {
+			continue
+		}
+
+		fmt.Println("Purging", entry.OriginalPath, "from the trash of", entry.User)
+		node, errno := server.Unlink(entry.Path(), DIR, true)
+		delete(server.Trash, id)
+		purged[entry.User] = true
+		if errno != 0 {
+			continue
+		}
+		ops = append(ops, server.ReleaseInodes(node)...)
+	}
+	for user := range purged {
+		server.removeEmptyTrash(user)
+	}
+	server.indexMutex.Unlock()
+
+	server.ApplyStorageOps(context.Background(), ops)
+}
@@ -0,0 +1,79 @@
+package naming_server
+
+import (
+	"context"
+	"project-dfs/pb"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// Deletes the file on behalf of the user, returning the trash entry it is moved to
+func trashFile(t *testing.T, ctlr *NamingServerController, path string, user string) *TrashEntry {
+	response, _ := ctlr.DeleteFile(context.Background(), &pb.DeleteRequest{Path: path, User: user})
+	if response.ErrorStatus.Code != 0 {
+		t.Fatalf("deleting %s: %s", path, response.ErrorStatus.Description)
+	}
+	return ctlr.Server.Trash[response.TrashId]
+}
+
+func TestTrashOwnerCheck(t *testing.T) {
+	server := newTestServer(t)
+	ctlr := &NamingServerController{Server: server}
+	createPaths(t, server, "alice", "/a", "/b", "/c")
+	entry := trashFile(t, ctlr, "/a", "alice")
+	other := trashFile(t, ctlr, "/b", "alice")
+
+	tests := []struct {
+		name    string
+		path    string
+		newPath string // deletes the path if empty
+		user    string
+		errno   syscall.Errno
+	}{
+		{"delete entry of another user", entry.NodePath(), "", "bob", syscall.EPERM},
+		{"delete trash of another user", "/.trash/alice", "", "bob", syscall.EPERM},
+		{"delete own trash directory", "/.trash/alice", "", "alice", syscall.EPERM},
+		{"move entry of another user", entry.NodePath(), "/stolen", "bob", syscall.EPERM},
+		{"move into the trash", "/c", entry.Path() + "/c", "alice", syscall.EPERM},
+		{"move into trash of another user", "/c", "/.trash/bob", "alice", syscall.EPERM},
+		{"move own entry", entry.NodePath(), "/restored", "alice", 0},
+		{"delete own entry", other.NodePath(), "", "alice", 0},
+	}
+	for _, test := range tests {
+		var status *pb.ErrorStatus
+		if test.newPath == "" {
+			response, _ := ctlr.DeleteFile(context.Background(), &pb.DeleteRequest{Path: test.path, User: test.user})
+			status = response.ErrorStatus
+		} else {
+			response, _ := ctlr.Move(context.Background(), &pb.MoveRequest{Path: test.path, NewPath: test.newPath, User: test.user})
+			status = response.ErrorStatus
+		}
+		if status.Code != uint32(test.errno) {
+			t.Errorf("%s: %s; want %v", test.name, status.Description, test.errno)
+		}
+	}
+	checkPaths(t, server, []string{"/restored", "/c", "/.trash/alice/"}, []string{"/stolen", other.NodePath()})
+}
+
+func TestPurgeRemovesEmptyTrash(t *testing.T) {
+	server := newTestServer(t)
+	ctlr := &NamingServerController{Server: server}
+	createPaths(t, server, "alice", "/a", "/b")
+	createPaths(t, server, "bob", "/c")
+	expired := trashFile(t, ctlr, "/a", "alice")
+	kept := trashFile(t, ctlr, "/b", "alice")
+	trashFile(t, ctlr, "/c", "bob").DeletedAt = time.Now().Add(-server.TrashRetention)
+	expired.DeletedAt = time.Now().Add(-server.TrashRetention)
+
+	server.PurgeTrash()
+	checkPaths(t, server, []string{"/.trash/alice/", kept.NodePath()}, []string{expired.Path(), "/.trash/bob"})
+
+	if _, errno := server.Restore(kept.ID, "alice", ""); errno != 0 {
+		t.Fatal(errno)
+	}
+	checkPaths(t, server, []string{"/b"}, []string{"/.trash/alice"})
+	if len(server.Trash) != 0 {
+		t.Fatalf("trash entries %v are left", server.Trash)
+	}
+}
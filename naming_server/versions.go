@@ -0,0 +1,218 @@
+package naming_server
+
+import (
+	"context"
+	"fmt"
+	"project-dfs/pb"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Versioning policy of a directory; applies to all files under it unless overridden
+type VersioningPolicy struct {
+	Enabled     bool
+	MaxVersions int           // unlimited if zero
+	MaxAge      time.Duration // unlimited if zero
+}
+
+// Saved immutable version of a file
+type FileVersion struct {
+	Version uint64
+	Size    int64
+	SavedAt time.Time
+}
+
+// Returns the policy of the closest directory on the path that has one
+func (server *NamingServer) VersioningPolicyOf(path string) *VersioningPolicy {
+	var policy *VersioningPolicy
+	node := server.RootIndexNode
+	if node.Versioning != nil {
+		policy = node.Versioning
+	}
+
+	for _, s := range strings.Split(path, "/")[1:] {
+		node = node.GetChild(s)
+		if node == nil {
+			break
+		}
+		if node.Versioning != nil {
+			policy = node.Versioning
+		}
+	}
+
+	if policy == nil || !policy.Enabled {
+		return nil
+	}
+	return policy
+}
+
+// Removes versions of the file that exceed the retention of the policy. Returns the removed versions.
+func (n *Node) ExpireVersions(policy *VersioningPolicy) []uint64 {
+	var expired []uint64
+	var kept []FileVersion
+
+	for i, version := range n.History {
+		tooMany := policy.MaxVersions > 0 && len(n.History)-i > policy.MaxVersions
+		tooOld := policy.MaxAge > 0 && time.Since(version.SavedAt) > policy.MaxAge
+		if tooMany || tooOld {
+			expired = append(expired, version.Version)
+		} else {
+			kept = append(kept, version)
+		}
+	}
+
+	n.History = kept
+	return expired
+}
+
+// Saves the current contents of the file as a new version if it has been modified since the last one
+func (server *NamingServer) CommitFile(ctx context.Context, path string) (uint64, *pb.ErrorStatus) {
+	server.indexMutex.Lock()
+	node, errno := server.LookupNode(path)
+	if errno == 0 && node.Type == DIR {
+		errno = syscall.EISDIR
+	}
+	if errno != 0 {
+		server.indexMutex.Unlock()
+		return 0, &pb.ErrorStatus{
+			Code:        uint32(errno),
+			Description: errno.Error(),
+		}
+	}
+
+	policy := server.VersioningPolicyOf(path)
+	modified := len(node.History) == 0 || node.History[len(node.History)-1].Version < node.Version
+	if policy == nil || !modified || node.Version == 0 {
+		server.indexMutex.Unlock()
+		return 0, nil
+	}
+	version := FileVersion{
+		Version: node.Version,
+		Size:    node.Size,
+		SavedAt: time.Now(),
+	}
+	fileId := node.ID
+	aliases := node.SubtreeStorages()
+	server.indexMutex.Unlock()
+
+	saved := false
+	stale := false
+	for _, alias := range aliases {
+		status := server.versionStorage(ctx, alias, &pb.VersionArgs{FileId: fileId, Version: version.Version}, false)
+		if status != nil && status.Code != 0 {
+			println("Error saving version", version.Version, "of", path, "on", alias, ":", status.Description)
+			stale = stale || status.Code == uint32(syscall.ESTALE)
+			continue
+		}
+		saved = true
+	}
+	if !saved && stale {
+		// another writer has committed since; the version is saved when it commits the file
+		server.indexMutex.Lock()
+		newer := node.Version > version.Version
+		server.indexMutex.Unlock()
+		if newer {
+			return 0, nil
+		}
+	}
+	if !saved {
+		return 0, &pb.ErrorStatus{
+			Code:        uint32(syscall.EIO),
+			Description: "No replica could save the version",
+		}
+	}
+
+	server.indexMutex.Lock()
+	if len(node.History) == 0 || node.History[len(node.History)-1].Version < version.Version {
+		node.History = append(node.History, version)
+	}
+	expired := node.ExpireVersions(policy)
+	server.indexMutex.Unlock()
+
+	server.deleteVersions(ctx, path, fileId, aliases, expired)
+	return version.Version, nil
+}
+
+// Applies retention of versioning policies to all saved versions
+func (server *NamingServer) ExpireAllVersions() {
+	type expiredVersions struct {
+		path     string
+		fileId   uint64
+		aliases  []string
+		versions []uint64
+	}
+	var all []expiredVersions
+
+	server.indexMutex.Lock()
+	var walk func(path string, node *Node)
+	walk = func(path string, node *Node) {
+		for _, child := range node.Children {
+			childPath := path + "/" + child.Name
+			if child.Type == DIR {
+				walk(childPath, child)
+				continue
+			}
+			if len(child.History) == 0 {
+				continue
+			}
+
+			policy := server.VersioningPolicyOf(childPath)
+			if policy == nil {
+				// versioning has been disabled; saved versions are kept until it is enabled again
+				continue
+			}
+			expired := child.ExpireVersions(policy)
+			if len(expired) > 0 {
+				all = append(all, expiredVersions{childPath, child.ID, child.SubtreeStorages(), expired})
+			}
+		}
+	}
+	walk("", server.RootIndexNode)
+	server.indexMutex.Unlock()
+
+	for _, e := range all {
+		server.deleteVersions(context.Background(), e.path, e.fileId, e.aliases, e.versions)
+	}
+}
+
+func (server *NamingServer) deleteVersions(ctx context.Context, path string, fileId uint64, aliases []string, versions []uint64) {
+	for _, v := range versions {
+		fmt.Println("Removing version", v, "of", path)
+		for _, alias := range aliases {
+			status := server.versionStorage(ctx, alias, &pb.VersionArgs{FileId: fileId, Version: v}, true)
+			if status != nil && status.Code != 0 {
+				println("Error removing version", v, "of", path, "on", alias, ":", status.Description)
+			}
+		}
+	}
+}
+
+func (server *NamingServer) versionStorage(ctx context.Context, alias string, args *pb.VersionArgs, remove bool) *pb.ErrorStatus {
+	info, ok := server.GetAddress(alias)
+	if !ok {
+		return &pb.ErrorStatus{
+			Code:        uint32(syscall.ENXIO),
+			Description: "Storage server " + alias + " is not registered",
+		}
+	}
+	ss := server.GetStorageServer(info.privateAddress)
+	if ss == nil {
+		return &pb.ErrorStatus{
+			Code:        uint32(syscall.EIO),
+			Description: "No connection to storage server " + alias,
+		}
+	}
+
+	var response *pb.VersionResult
+	var err error
+	if remove {
+		response, err = ss.DeleteVersion(ctx, args)
+	} else {
+		response, err = ss.SaveVersion(ctx, args)
+	}
+	if err != nil {
+		return &pb.ErrorStatus{Code: uint32(syscall.EIO), Description: err.Error()}
+	}
+	return response.ErrorStatus
+}
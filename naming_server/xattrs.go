@@ -0,0 +1,93 @@
+package naming_server
+
+import (
+	"project-dfs/pb"
+	"sort"
+	"syscall"
+)
+
+// Limits on extended attributes, same as the ones of Linux
+const (
+	MaxXattrNameLength = 255
+	MaxXattrValueSize  = 64 * 1024
+	MaxXattrsSize      = 64 * 1024 // names and values of all attributes of a node
+)
+
+func (inode *Inode) xattrsSize() int {
+	size := 0
+	for name, value := range inode.Xattrs {
+		size += len(name) + len(value)
+	}
+	return size
+}
+
+func (inode *Inode) GetXattr(name string) ([]byte, syscall.Errno) {
+	value, ok := inode.Xattrs[name]
+	if !ok {
+		return nil, syscall.ENODATA
+	}
+	return value, 0
+}
+
+func (inode *Inode) SetXattr(name string, value []byte, flags uint32) syscall.Errno {
+	if name == "" || len(name) > MaxXattrNameLength {
+		return syscall.ERANGE
+	}
+	if len(value) > MaxXattrValueSize {
+		return syscall.E2BIG
+	}
+
+	old, exists := inode.Xattrs[name]
+	if exists && flags&uint32(pb.XattrFlag_XATTR_CREATE) != 0 {
+		return syscall.EEXIST
+	}
+	if !exists && flags&uint32(pb.XattrFlag_XATTR_REPLACE) != 0 {
+		return syscall.ENODATA
+	}
+
+	size := inode.xattrsSize() + len(value)
+	if exists {
+		size -= len(old)
+	} else {
+		size += len(name)
+	}
+	if size > MaxXattrsSize {
+		return syscall.ENOSPC
+	}
+
+	if inode.Xattrs == nil {
+		inode.Xattrs = make(map[string][]byte)
+	}
+	inode.Xattrs[name] = append([]byte(nil), value...)
+	return 0
+}
+
+// Returns a copy of the attributes that can be used once the index is unlocked.
+// Values are replaced rather than modified, so they are shared.
+func (inode *Inode) CopyXattrs() map[string][]byte {
+	if inode.Xattrs == nil {
+		return nil
+	}
+	xattrs := make(map[string][]byte, len(inode.Xattrs))
+	for name, value := range inode.Xattrs {
+		xattrs[name] = value
+	}
+	return xattrs
+}
+
+func (inode *Inode) ListXattrs() []string {
+	names := make([]string, 0, len(inode.Xattrs))
+	for name := range inode.Xattrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (inode *Inode) RemoveXattr(name string) syscall.Errno {
+	if _, ok := inode.Xattrs[name]; !ok {
+		return syscall.ENODATA
+	}
+	delete(inode.Xattrs, name)
+	return 0
+}
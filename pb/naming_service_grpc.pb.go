@@ -21,21 +21,96 @@ type NamingClient interface {
 	Register(ctx context.Context, in *RegRequest, opts ...grpc.CallOption) (*RegResponse, error)
 	// Creates a new file on 2 randomly selected storage servers.
 	CreateFile(ctx context.Context, in *CreateFileRequest, opts ...grpc.CallOption) (*CreateFileResponse, error)
-	// Copies a file OR a directory (recursively) to 2 randomly selected storage servers.
-	// storage server is decided for each file separately.
+	// Copies a file OR a directory (recursively) on the storage servers holding the source.
+	// Symbolic links are copied as links; hard links become independent files.
 	Copy(ctx context.Context, in *CopyRequest, opts ...grpc.CallOption) (*CopyResponse, error)
 	// Fetches the file index to obtain list of storage servers that hold the requested path.
 	Discover(ctx context.Context, in *DiscoverRequest, opts ...grpc.CallOption) (*DiscoverResponse, error)
 	// Removes the file with specified name from the index and notifies storage servers about file removal.
+	// Unless the trash is disabled, the file is moved to the trash of the user and removed after the retention period.
 	DeleteFile(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
 	// Removes the directory with specified name from the index and notifies storage servers about directory removal.
+	// Fails with ENOTEMPTY if the directory has contents, unless the removal is recursive.
 	DeleteDirectory(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
-	// Moves the file with the specified name in the index and notifies storage servers about file move.
+	// Moves the file with the specified name in the index. Storage servers keep files by ID, so they are not involved.
+	// Follows rename(2) semantics: an existing destination is replaced unless RENAME_NOREPLACE or RENAME_EXCHANGE is set.
 	Move(ctx context.Context, in *MoveRequest, opts ...grpc.CallOption) (*MoveResponse, error)
 	// Creates a directory in the index and notifies storage servers about newly created directory.
 	MakeDirectory(ctx context.Context, in *MakeDirectoryRequest, opts ...grpc.CallOption) (*MakeDirectoryResponse, error)
 	// Retrieves list of the directory contents from the index.
 	ListDirectory(ctx context.Context, in *ListDirectoryRequest, opts ...grpc.CallOption) (*ListDirectoryResponse, error)
+	// Bumps the version of the file after a write has been applied by a storage server.
+	CommitWrite(ctx context.Context, in *CommitWriteRequest, opts ...grpc.CallOption) (*CommitWriteResponse, error)
+	// Grants a time-bounded primary lease on the file. Only the lease holder orders mutations of the file.
+	GrantLease(ctx context.Context, in *LeaseRequest, opts ...grpc.CallOption) (*LeaseResponse, error)
+	// Reports that the storage server is alive and renews the leases it holds.
+	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
+	// Shrinks or extends the file to the specified size on all of its replicas.
+	Truncate(ctx context.Context, in *TruncateRequest, opts ...grpc.CallOption) (*TruncateResponse, error)
+	// Preallocates space for the file on all of its replicas.
+	Allocate(ctx context.Context, in *AllocateRequest, opts ...grpc.CallOption) (*AllocateResponse, error)
+	// Moves the deleted node from the trash back to its original path or to a new one.
+	Restore(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*RestoreResponse, error)
+	// Lists trash entries of the user.
+	ListTrash(ctx context.Context, in *ListTrashRequest, opts ...grpc.CallOption) (*ListTrashResponse, error)
+	// Captures a read-only snapshot of the directory subtree, readable under /.snapshots/<name>/.
+	CreateSnapshot(ctx context.Context, in *CreateSnapshotRequest, opts ...grpc.CallOption) (*CreateSnapshotResponse, error)
+	// Lists existing snapshots.
+	ListSnapshots(ctx context.Context, in *ListSnapshotsRequest, opts ...grpc.CallOption) (*ListSnapshotsResponse, error)
+	// Deletes the snapshot and lets storage servers reclaim the space held by it.
+	DeleteSnapshot(ctx context.Context, in *DeleteSnapshotRequest, opts ...grpc.CallOption) (*DeleteSnapshotResponse, error)
+	// Enables or disables keeping versions of the files under the directory.
+	SetVersioning(ctx context.Context, in *SetVersioningRequest, opts ...grpc.CallOption) (*SetVersioningResponse, error)
+	// Saves the contents of the modified file as a new immutable version, if its directory keeps versions.
+	CommitFile(ctx context.Context, in *CommitFileRequest, opts ...grpc.CallOption) (*CommitFileResponse, error)
+	// Lists saved versions of the file.
+	ListVersions(ctx context.Context, in *ListVersionsRequest, opts ...grpc.CallOption) (*ListVersionsResponse, error)
+	// Replaces contents of the file with the saved version.
+	RestoreVersion(ctx context.Context, in *RestoreVersionRequest, opts ...grpc.CallOption) (*RestoreVersionResponse, error)
+	// Sets byte and inode limits on the directory subtree, or on everything owned by the user if the user is set.
+	SetQuota(ctx context.Context, in *SetQuotaRequest, opts ...grpc.CallOption) (*SetQuotaResponse, error)
+	// Reports usage against quotas of the directory or the user, or against all quotas if neither is set.
+	GetUsage(ctx context.Context, in *UsageRequest, opts ...grpc.CallOption) (*UsageResponse, error)
+	// Reserves space for the file to grow to the specified size. Fails with EDQUOT if a quota would be exceeded.
+	ReserveSpace(ctx context.Context, in *ReserveSpaceRequest, opts ...grpc.CallOption) (*ReserveSpaceResponse, error)
+	// Creates a symbolic link pointing to the target. The target is stored as is and may not exist.
+	Symlink(ctx context.Context, in *SymlinkRequest, opts ...grpc.CallOption) (*SymlinkResponse, error)
+	// Returns the target of the symbolic link.
+	Readlink(ctx context.Context, in *ReadlinkRequest, opts ...grpc.CallOption) (*ReadlinkResponse, error)
+	// Creates a hard link: a new directory entry for the same file.
+	Link(ctx context.Context, in *LinkRequest, opts ...grpc.CallOption) (*LinkResponse, error)
+	// Returns the value of the extended attribute of the file or directory.
+	Getxattr(ctx context.Context, in *GetxattrRequest, opts ...grpc.CallOption) (*GetxattrResponse, error)
+	// Sets the extended attribute. Names are limited to 255 bytes, values and all attributes of a node to 64 KiB.
+	Setxattr(ctx context.Context, in *SetxattrRequest, opts ...grpc.CallOption) (*SetxattrResponse, error)
+	// Lists names of the extended attributes.
+	Listxattr(ctx context.Context, in *ListxattrRequest, opts ...grpc.CallOption) (*ListxattrResponse, error)
+	// Removes the extended attribute.
+	Removexattr(ctx context.Context, in *RemovexattrRequest, opts ...grpc.CallOption) (*RemovexattrResponse, error)
+	// Starts a client session. Handles of the session stay open as long as the client keeps the session alive.
+	OpenSession(ctx context.Context, in *OpenSessionRequest, opts ...grpc.CallOption) (*OpenSessionResponse, error)
+	// Extends the session. Sessions not kept alive within the timeout expire and their handles are closed.
+	KeepAlive(ctx context.Context, in *KeepAliveRequest, opts ...grpc.CallOption) (*KeepAliveResponse, error)
+	// Ends the session, closing all of its handles.
+	CloseSession(ctx context.Context, in *CloseSessionRequest, opts ...grpc.CallOption) (*CloseSessionResponse, error)
+	// Opens the file and returns a handle along with its current version and replicas.
+	// A file deleted while open keeps its contents until the last handle is closed.
+	Open(ctx context.Context, in *OpenRequest, opts ...grpc.CallOption) (*OpenResponse, error)
+	// Closes the handle. Closing a handle opened for writing saves a version of the file if versioning is enabled.
+	Close(ctx context.Context, in *CloseRequest, opts ...grpc.CallOption) (*CloseResponse, error)
+	// Places an advisory shared or exclusive lock on a byte range of the open file, like fcntl(2) F_SETLK.
+	// Locks belong to the session and are released when the file is closed or the session ends.
+	// A waiting request is told that it waits for the conflicting lock and then that the lock is granted.
+	Lock(ctx context.Context, in *LockRequest, opts ...grpc.CallOption) (Naming_LockClient, error)
+	// Releases locks of the session on the byte range of the file.
+	Unlock(ctx context.Context, in *UnlockRequest, opts ...grpc.CallOption) (*UnlockResponse, error)
+	// Returns a lock that would prevent the lock from being placed, like fcntl(2) F_GETLK.
+	TestLock(ctx context.Context, in *LockRequest, opts ...grpc.CallOption) (*TestLockResponse, error)
+	// Streams changes of the path or of the subtree under it. Every event carries a sequence number,
+	// so that a watcher can resume after a disconnect from the last event it has received.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Naming_WatchClient, error)
+	// Reads the durable changelog of the namespace: the events of Watch in order, from any retained sequence number.
+	ReadChanges(ctx context.Context, in *ReadChangesRequest, opts ...grpc.CallOption) (*ReadChangesResponse, error)
 }
 
 type namingClient struct {
@@ -127,6 +202,358 @@ func (c *namingClient) ListDirectory(ctx context.Context, in *ListDirectoryReque
 	return out, nil
 }
 
+func (c *namingClient) CommitWrite(ctx context.Context, in *CommitWriteRequest, opts ...grpc.CallOption) (*CommitWriteResponse, error) {
+	out := new(CommitWriteResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/CommitWrite", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) GrantLease(ctx context.Context, in *LeaseRequest, opts ...grpc.CallOption) (*LeaseResponse, error) {
+	out := new(LeaseResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/GrantLease", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error) {
+	out := new(HeartbeatResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/Heartbeat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) Truncate(ctx context.Context, in *TruncateRequest, opts ...grpc.CallOption) (*TruncateResponse, error) {
+	out := new(TruncateResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/Truncate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) Allocate(ctx context.Context, in *AllocateRequest, opts ...grpc.CallOption) (*AllocateResponse, error) {
+	out := new(AllocateResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/Allocate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) Restore(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*RestoreResponse, error) {
+	out := new(RestoreResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/Restore", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) ListTrash(ctx context.Context, in *ListTrashRequest, opts ...grpc.CallOption) (*ListTrashResponse, error) {
+	out := new(ListTrashResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/ListTrash", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) CreateSnapshot(ctx context.Context, in *CreateSnapshotRequest, opts ...grpc.CallOption) (*CreateSnapshotResponse, error) {
+	out := new(CreateSnapshotResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/CreateSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) ListSnapshots(ctx context.Context, in *ListSnapshotsRequest, opts ...grpc.CallOption) (*ListSnapshotsResponse, error) {
+	out := new(ListSnapshotsResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/ListSnapshots", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) DeleteSnapshot(ctx context.Context, in *DeleteSnapshotRequest, opts ...grpc.CallOption) (*DeleteSnapshotResponse, error) {
+	out := new(DeleteSnapshotResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/DeleteSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) SetVersioning(ctx context.Context, in *SetVersioningRequest, opts ...grpc.CallOption) (*SetVersioningResponse, error) {
+	out := new(SetVersioningResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/SetVersioning", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) CommitFile(ctx context.Context, in *CommitFileRequest, opts ...grpc.CallOption) (*CommitFileResponse, error) {
+	out := new(CommitFileResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/CommitFile", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) ListVersions(ctx context.Context, in *ListVersionsRequest, opts ...grpc.CallOption) (*ListVersionsResponse, error) {
+	out := new(ListVersionsResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/ListVersions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) RestoreVersion(ctx context.Context, in *RestoreVersionRequest, opts ...grpc.CallOption) (*RestoreVersionResponse, error) {
+	out := new(RestoreVersionResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/RestoreVersion", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) SetQuota(ctx context.Context, in *SetQuotaRequest, opts ...grpc.CallOption) (*SetQuotaResponse, error) {
+	out := new(SetQuotaResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/SetQuota", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) GetUsage(ctx context.Context, in *UsageRequest, opts ...grpc.CallOption) (*UsageResponse, error) {
+	out := new(UsageResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/GetUsage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) ReserveSpace(ctx context.Context, in *ReserveSpaceRequest, opts ...grpc.CallOption) (*ReserveSpaceResponse, error) {
+	out := new(ReserveSpaceResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/ReserveSpace", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) Symlink(ctx context.Context, in *SymlinkRequest, opts ...grpc.CallOption) (*SymlinkResponse, error) {
+	out := new(SymlinkResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/Symlink", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) Readlink(ctx context.Context, in *ReadlinkRequest, opts ...grpc.CallOption) (*ReadlinkResponse, error) {
+	out := new(ReadlinkResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/Readlink", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) Link(ctx context.Context, in *LinkRequest, opts ...grpc.CallOption) (*LinkResponse, error) {
+	out := new(LinkResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/Link", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) Getxattr(ctx context.Context, in *GetxattrRequest, opts ...grpc.CallOption) (*GetxattrResponse, error) {
+	out := new(GetxattrResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/Getxattr", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) Setxattr(ctx context.Context, in *SetxattrRequest, opts ...grpc.CallOption) (*SetxattrResponse, error) {
+	out := new(SetxattrResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/Setxattr", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) Listxattr(ctx context.Context, in *ListxattrRequest, opts ...grpc.CallOption) (*ListxattrResponse, error) {
+	out := new(ListxattrResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/Listxattr", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) Removexattr(ctx context.Context, in *RemovexattrRequest, opts ...grpc.CallOption) (*RemovexattrResponse, error) {
+	out := new(RemovexattrResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/Removexattr", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) OpenSession(ctx context.Context, in *OpenSessionRequest, opts ...grpc.CallOption) (*OpenSessionResponse, error) {
+	out := new(OpenSessionResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/OpenSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) KeepAlive(ctx context.Context, in *KeepAliveRequest, opts ...grpc.CallOption) (*KeepAliveResponse, error) {
+	out := new(KeepAliveResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/KeepAlive", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) CloseSession(ctx context.Context, in *CloseSessionRequest, opts ...grpc.CallOption) (*CloseSessionResponse, error) {
+	out := new(CloseSessionResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/CloseSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) Open(ctx context.Context, in *OpenRequest, opts ...grpc.CallOption) (*OpenResponse, error) {
+	out := new(OpenResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/Open", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) Close(ctx context.Context, in *CloseRequest, opts ...grpc.CallOption) (*CloseResponse, error) {
+	out := new(CloseResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/Close", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) Lock(ctx context.Context, in *LockRequest, opts ...grpc.CallOption) (Naming_LockClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Naming_serviceDesc.Streams[0], "/pb.Naming/Lock", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &namingLockClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Naming_LockClient interface {
+	Recv() (*LockResponse, error)
+	grpc.ClientStream
+}
+
+type namingLockClient struct {
+	grpc.ClientStream
+}
+
+func (x *namingLockClient) Recv() (*LockResponse, error) {
+	m := new(LockResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *namingClient) Unlock(ctx context.Context, in *UnlockRequest, opts ...grpc.CallOption) (*UnlockResponse, error) {
+	out := new(UnlockResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/Unlock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) TestLock(ctx context.Context, in *LockRequest, opts ...grpc.CallOption) (*TestLockResponse, error) {
+	out := new(TestLockResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/TestLock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namingClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Naming_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Naming_serviceDesc.Streams[1], "/pb.Naming/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &namingWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Naming_WatchClient interface {
+	Recv() (*WatchResponse, error)
+	grpc.ClientStream
+}
+
+type namingWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *namingWatchClient) Recv() (*WatchResponse, error) {
+	m := new(WatchResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *namingClient) ReadChanges(ctx context.Context, in *ReadChangesRequest, opts ...grpc.CallOption) (*ReadChangesResponse, error) {
+	out := new(ReadChangesResponse)
+	err := c.cc.Invoke(ctx, "/pb.Naming/ReadChanges", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // NamingServer is the server API for Naming service.
 // All implementations must embed UnimplementedNamingServer
 // for forward compatibility
@@ -135,226 +562,1021 @@ type NamingServer interface {
 	Register(context.Context, *RegRequest) (*RegResponse, error)
 	// Creates a new file on 2 randomly selected storage servers.
 	CreateFile(context.Context, *CreateFileRequest) (*CreateFileResponse, error)
-	// Copies a file OR a directory (recursively) to 2 randomly selected storage servers.
-	// storage server is decided for each file separately.
+	// Copies a file OR a directory (recursively) on the storage servers holding the source.
+	// Symbolic links are copied as links; hard links become independent files.
 	Copy(context.Context, *CopyRequest) (*CopyResponse, error)
 	// Fetches the file index to obtain list of storage servers that hold the requested path.
 	Discover(context.Context, *DiscoverRequest) (*DiscoverResponse, error)
 	// Removes the file with specified name from the index and notifies storage servers about file removal.
+	// Unless the trash is disabled, the file is moved to the trash of the user and removed after the retention period.
 	DeleteFile(context.Context, *DeleteRequest) (*DeleteResponse, error)
 	// Removes the directory with specified name from the index and notifies storage servers about directory removal.
+	// Fails with ENOTEMPTY if the directory has contents, unless the removal is recursive.
 	DeleteDirectory(context.Context, *DeleteRequest) (*DeleteResponse, error)
-	// Moves the file with the specified name in the index and notifies storage servers about file move.
+	// Moves the file with the specified name in the index. Storage servers keep files by ID, so they are not involved.
+	// Follows rename(2) semantics: an existing destination is replaced unless RENAME_NOREPLACE or RENAME_EXCHANGE is set.
 	Move(context.Context, *MoveRequest) (*MoveResponse, error)
 	// Creates a directory in the index and notifies storage servers about newly created directory.
 	MakeDirectory(context.Context, *MakeDirectoryRequest) (*MakeDirectoryResponse, error)
 	// Retrieves list of the directory contents from the index.
 	ListDirectory(context.Context, *ListDirectoryRequest) (*ListDirectoryResponse, error)
+	// Bumps the version of the file after a write has been applied by a storage server.
+	CommitWrite(context.Context, *CommitWriteRequest) (*CommitWriteResponse, error)
+	// Grants a time-bounded primary lease on the file. Only the lease holder orders mutations of the file.
+	GrantLease(context.Context, *LeaseRequest) (*LeaseResponse, error)
+	// Reports that the storage server is alive and renews the leases it holds.
+	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
+	// Shrinks or extends the file to the specified size on all of its replicas.
+	Truncate(context.Context, *TruncateRequest) (*TruncateResponse, error)
+	// Preallocates space for the file on all of its replicas.
+	Allocate(context.Context, *AllocateRequest) (*AllocateResponse, error)
+	// Moves the deleted node from the trash back to its original path or to a new one.
+	Restore(context.Context, *RestoreRequest) (*RestoreResponse, error)
+	// Lists trash entries of the user.
+	ListTrash(context.Context, *ListTrashRequest) (*ListTrashResponse, error)
+	// Captures a read-only snapshot of the directory subtree, readable under /.snapshots/<name>/.
+	CreateSnapshot(context.Context, *CreateSnapshotRequest) (*CreateSnapshotResponse, error)
+	// Lists existing snapshots.
+	ListSnapshots(context.Context, *ListSnapshotsRequest) (*ListSnapshotsResponse, error)
+	// Deletes the snapshot and lets storage servers reclaim the space held by it.
+	DeleteSnapshot(context.Context, *DeleteSnapshotRequest) (*DeleteSnapshotResponse, error)
+	// Enables or disables keeping versions of the files under the directory.
+	SetVersioning(context.Context, *SetVersioningRequest) (*SetVersioningResponse, error)
+	// Saves the contents of the modified file as a new immutable version, if its directory keeps versions.
+	CommitFile(context.Context, *CommitFileRequest) (*CommitFileResponse, error)
+	// Lists saved versions of the file.
+	ListVersions(context.Context, *ListVersionsRequest) (*ListVersionsResponse, error)
+	// Replaces contents of the file with the saved version.
+	RestoreVersion(context.Context, *RestoreVersionRequest) (*RestoreVersionResponse, error)
+	// Sets byte and inode limits on the directory subtree, or on everything owned by the user if the user is set.
+	SetQuota(context.Context, *SetQuotaRequest) (*SetQuotaResponse, error)
+	// Reports usage against quotas of the directory or the user, or against all quotas if neither is set.
+	GetUsage(context.Context, *UsageRequest) (*UsageResponse, error)
+	// Reserves space for the file to grow to the specified size. Fails with EDQUOT if a quota would be exceeded.
+	ReserveSpace(context.Context, *ReserveSpaceRequest) (*ReserveSpaceResponse, error)
+	// Creates a symbolic link pointing to the target. The target is stored as is and may not exist.
+	Symlink(context.Context, *SymlinkRequest) (*SymlinkResponse, error)
+	// Returns the target of the symbolic link.
+	Readlink(context.Context, *ReadlinkRequest) (*ReadlinkResponse, error)
+	// Creates a hard link: a new directory entry for the same file.
+	Link(context.Context, *LinkRequest) (*LinkResponse, error)
+	// Returns the value of the extended attribute of the file or directory.
+	Getxattr(context.Context, *GetxattrRequest) (*GetxattrResponse, error)
+	// Sets the extended attribute. Names are limited to 255 bytes, values and all attributes of a node to 64 KiB.
+	Setxattr(context.Context, *SetxattrRequest) (*SetxattrResponse, error)
+	// Lists names of the extended attributes.
+	Listxattr(context.Context, *ListxattrRequest) (*ListxattrResponse, error)
+	// Removes the extended attribute.
+	Removexattr(context.Context, *RemovexattrRequest) (*RemovexattrResponse, error)
+	// Starts a client session. Handles of the session stay open as long as the client keeps the session alive.
+	OpenSession(context.Context, *OpenSessionRequest) (*OpenSessionResponse, error)
+	// Extends the session. Sessions not kept alive within the timeout expire and their handles are closed.
+	KeepAlive(context.Context, *KeepAliveRequest) (*KeepAliveResponse, error)
+	// Ends the session, closing all of its handles.
+	CloseSession(context.Context, *CloseSessionRequest) (*CloseSessionResponse, error)
+	// Opens the file and returns a handle along with its current version and replicas.
+	// A file deleted while open keeps its contents until the last handle is closed.
+	Open(context.Context, *OpenRequest) (*OpenResponse, error)
+	// Closes the handle. Closing a handle opened for writing saves a version of the file if versioning is enabled.
+	Close(context.Context, *CloseRequest) (*CloseResponse, error)
+	// Places an advisory shared or exclusive lock on a byte range of the open file, like fcntl(2) F_SETLK.
+	// Locks belong to the session and are released when the file is closed or the session ends.
+	// A waiting request is told that it waits for the conflicting lock and then that the lock is granted.
+	Lock(*LockRequest, Naming_LockServer) error
+	// Releases locks of the session on the byte range of the file.
+	Unlock(context.Context, *UnlockRequest) (*UnlockResponse, error)
+	// Returns a lock that would prevent the lock from being placed, like fcntl(2) F_GETLK.
+	TestLock(context.Context, *LockRequest) (*TestLockResponse, error)
+	// Streams changes of the path or of the subtree under it. Every event carries a sequence number,
+	// so that a watcher can resume after a disconnect from the last event it has received.
+	Watch(*WatchRequest, Naming_WatchServer) error
+	// Reads the durable changelog of the namespace: the events of Watch in order, from any retained sequence number.
+	ReadChanges(context.Context, *ReadChangesRequest) (*ReadChangesResponse, error)
 	mustEmbedUnimplementedNamingServer()
 }
 
-// UnimplementedNamingServer must be embedded to have forward compatible implementations.
-type UnimplementedNamingServer struct {
+// UnimplementedNamingServer must be embedded to have forward compatible implementations.
+type UnimplementedNamingServer struct {
+}
+
+func (UnimplementedNamingServer) Register(context.Context, *RegRequest) (*RegResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedNamingServer) CreateFile(context.Context, *CreateFileRequest) (*CreateFileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateFile not implemented")
+}
+func (UnimplementedNamingServer) Copy(context.Context, *CopyRequest) (*CopyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Copy not implemented")
+}
+func (UnimplementedNamingServer) Discover(context.Context, *DiscoverRequest) (*DiscoverResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Discover not implemented")
+}
+func (UnimplementedNamingServer) DeleteFile(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteFile not implemented")
+}
+func (UnimplementedNamingServer) DeleteDirectory(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteDirectory not implemented")
+}
+func (UnimplementedNamingServer) Move(context.Context, *MoveRequest) (*MoveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Move not implemented")
+}
+func (UnimplementedNamingServer) MakeDirectory(context.Context, *MakeDirectoryRequest) (*MakeDirectoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MakeDirectory not implemented")
+}
+func (UnimplementedNamingServer) ListDirectory(context.Context, *ListDirectoryRequest) (*ListDirectoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDirectory not implemented")
+}
+func (UnimplementedNamingServer) CommitWrite(context.Context, *CommitWriteRequest) (*CommitWriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CommitWrite not implemented")
+}
+func (UnimplementedNamingServer) GrantLease(context.Context, *LeaseRequest) (*LeaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GrantLease not implemented")
+}
+func (UnimplementedNamingServer) Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
+}
+func (UnimplementedNamingServer) Truncate(context.Context, *TruncateRequest) (*TruncateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Truncate not implemented")
+}
+func (UnimplementedNamingServer) Allocate(context.Context, *AllocateRequest) (*AllocateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Allocate not implemented")
+}
+func (UnimplementedNamingServer) Restore(context.Context, *RestoreRequest) (*RestoreResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
+func (UnimplementedNamingServer) ListTrash(context.Context, *ListTrashRequest) (*ListTrashResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTrash not implemented")
+}
+func (UnimplementedNamingServer) CreateSnapshot(context.Context, *CreateSnapshotRequest) (*CreateSnapshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSnapshot not implemented")
+}
+func (UnimplementedNamingServer) ListSnapshots(context.Context, *ListSnapshotsRequest) (*ListSnapshotsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSnapshots not implemented")
+}
+func (UnimplementedNamingServer) DeleteSnapshot(context.Context, *DeleteSnapshotRequest) (*DeleteSnapshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSnapshot not implemented")
+}
+func (UnimplementedNamingServer) SetVersioning(context.Context, *SetVersioningRequest) (*SetVersioningResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetVersioning not implemented")
+}
+func (UnimplementedNamingServer) CommitFile(context.Context, *CommitFileRequest) (*CommitFileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CommitFile not implemented")
+}
+func (UnimplementedNamingServer) ListVersions(context.Context, *ListVersionsRequest) (*ListVersionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVersions not implemented")
+}
+func (UnimplementedNamingServer) RestoreVersion(context.Context, *RestoreVersionRequest) (*RestoreVersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreVersion not implemented")
+}
+func (UnimplementedNamingServer) SetQuota(context.Context, *SetQuotaRequest) (*SetQuotaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetQuota not implemented")
+}
+func (UnimplementedNamingServer) GetUsage(context.Context, *UsageRequest) (*UsageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsage not implemented")
+}
+func (UnimplementedNamingServer) ReserveSpace(context.Context, *ReserveSpaceRequest) (*ReserveSpaceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReserveSpace not implemented")
+}
+func (UnimplementedNamingServer) Symlink(context.Context, *SymlinkRequest) (*SymlinkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Symlink not implemented")
+}
+func (UnimplementedNamingServer) Readlink(context.Context, *ReadlinkRequest) (*ReadlinkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Readlink not implemented")
+}
+func (UnimplementedNamingServer) Link(context.Context, *LinkRequest) (*LinkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Link not implemented")
+}
+func (UnimplementedNamingServer) Getxattr(context.Context, *GetxattrRequest) (*GetxattrResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Getxattr not implemented")
+}
+func (UnimplementedNamingServer) Setxattr(context.Context, *SetxattrRequest) (*SetxattrResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Setxattr not implemented")
+}
+func (UnimplementedNamingServer) Listxattr(context.Context, *ListxattrRequest) (*ListxattrResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Listxattr not implemented")
+}
+func (UnimplementedNamingServer) Removexattr(context.Context, *RemovexattrRequest) (*RemovexattrResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Removexattr not implemented")
+}
+func (UnimplementedNamingServer) OpenSession(context.Context, *OpenSessionRequest) (*OpenSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OpenSession not implemented")
+}
+func (UnimplementedNamingServer) KeepAlive(context.Context, *KeepAliveRequest) (*KeepAliveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KeepAlive not implemented")
+}
+func (UnimplementedNamingServer) CloseSession(context.Context, *CloseSessionRequest) (*CloseSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseSession not implemented")
+}
+func (UnimplementedNamingServer) Open(context.Context, *OpenRequest) (*OpenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Open not implemented")
+}
+func (UnimplementedNamingServer) Close(context.Context, *CloseRequest) (*CloseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Close not implemented")
+}
+func (UnimplementedNamingServer) Lock(*LockRequest, Naming_LockServer) error {
+	return status.Errorf(codes.Unimplemented, "method Lock not implemented")
+}
+func (UnimplementedNamingServer) Unlock(context.Context, *UnlockRequest) (*UnlockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unlock not implemented")
+}
+func (UnimplementedNamingServer) TestLock(context.Context, *LockRequest) (*TestLockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TestLock not implemented")
+}
+func (UnimplementedNamingServer) Watch(*WatchRequest, Naming_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedNamingServer) ReadChanges(context.Context, *ReadChangesRequest) (*ReadChangesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadChanges not implemented")
+}
+func (UnimplementedNamingServer) mustEmbedUnimplementedNamingServer() {}
+
+// UnsafeNamingServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NamingServer will
+// result in compilation errors.
+type UnsafeNamingServer interface {
+	mustEmbedUnimplementedNamingServer()
+}
+
+func RegisterNamingServer(s *grpc.Server, srv NamingServer) {
+	s.RegisterService(&_Naming_serviceDesc, srv)
+}
+
+func _Naming_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/Register",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).Register(ctx, req.(*RegRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_CreateFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).CreateFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/CreateFile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).CreateFile(ctx, req.(*CreateFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_Copy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CopyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).Copy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/Copy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).Copy(ctx, req.(*CopyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_Discover_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiscoverRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).Discover(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/Discover",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).Discover(ctx, req.(*DiscoverRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_DeleteFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).DeleteFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/DeleteFile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).DeleteFile(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_DeleteDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).DeleteDirectory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/DeleteDirectory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).DeleteDirectory(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_Move_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).Move(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/Move",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).Move(ctx, req.(*MoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_MakeDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MakeDirectoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).MakeDirectory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/MakeDirectory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).MakeDirectory(ctx, req.(*MakeDirectoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_ListDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDirectoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).ListDirectory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/ListDirectory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).ListDirectory(ctx, req.(*ListDirectoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_CommitWrite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommitWriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).CommitWrite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/CommitWrite",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).CommitWrite(ctx, req.(*CommitWriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_GrantLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).GrantLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/GrantLease",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).GrantLease(ctx, req.(*LeaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeartbeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/Heartbeat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).Heartbeat(ctx, req.(*HeartbeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_Truncate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TruncateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).Truncate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/Truncate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).Truncate(ctx, req.(*TruncateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_Allocate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AllocateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).Allocate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/Allocate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).Allocate(ctx, req.(*AllocateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_Restore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).Restore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/Restore",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).Restore(ctx, req.(*RestoreRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_ListTrash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTrashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).ListTrash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/ListTrash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).ListTrash(ctx, req.(*ListTrashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_CreateSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).CreateSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/CreateSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).CreateSnapshot(ctx, req.(*CreateSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_ListSnapshots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSnapshotsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).ListSnapshots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/ListSnapshots",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).ListSnapshots(ctx, req.(*ListSnapshotsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_DeleteSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).DeleteSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/DeleteSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).DeleteSnapshot(ctx, req.(*DeleteSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_SetVersioning_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetVersioningRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).SetVersioning(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/SetVersioning",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).SetVersioning(ctx, req.(*SetVersioningRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_CommitFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommitFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).CommitFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/CommitFile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).CommitFile(ctx, req.(*CommitFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_ListVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVersionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).ListVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/ListVersions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).ListVersions(ctx, req.(*ListVersionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_RestoreVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).RestoreVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/RestoreVersion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).RestoreVersion(ctx, req.(*RestoreVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_SetQuota_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetQuotaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).SetQuota(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/SetQuota",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).SetQuota(ctx, req.(*SetQuotaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Naming_GetUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).GetUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/GetUsage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).GetUsage(ctx, req.(*UsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (UnimplementedNamingServer) Register(context.Context, *RegRequest) (*RegResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
-}
-func (UnimplementedNamingServer) CreateFile(context.Context, *CreateFileRequest) (*CreateFileResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method CreateFile not implemented")
-}
-func (UnimplementedNamingServer) Copy(context.Context, *CopyRequest) (*CopyResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Copy not implemented")
-}
-func (UnimplementedNamingServer) Discover(context.Context, *DiscoverRequest) (*DiscoverResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Discover not implemented")
-}
-func (UnimplementedNamingServer) DeleteFile(context.Context, *DeleteRequest) (*DeleteResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method DeleteFile not implemented")
+func _Naming_ReserveSpace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReserveSpaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).ReserveSpace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/ReserveSpace",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).ReserveSpace(ctx, req.(*ReserveSpaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedNamingServer) DeleteDirectory(context.Context, *DeleteRequest) (*DeleteResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method DeleteDirectory not implemented")
+
+func _Naming_Symlink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SymlinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).Symlink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/Symlink",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).Symlink(ctx, req.(*SymlinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedNamingServer) Move(context.Context, *MoveRequest) (*MoveResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Move not implemented")
+
+func _Naming_Readlink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadlinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).Readlink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/Readlink",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).Readlink(ctx, req.(*ReadlinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedNamingServer) MakeDirectory(context.Context, *MakeDirectoryRequest) (*MakeDirectoryResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method MakeDirectory not implemented")
+
+func _Naming_Link_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).Link(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/Link",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).Link(ctx, req.(*LinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedNamingServer) ListDirectory(context.Context, *ListDirectoryRequest) (*ListDirectoryResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method ListDirectory not implemented")
+
+func _Naming_Getxattr_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetxattrRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).Getxattr(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/Getxattr",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).Getxattr(ctx, req.(*GetxattrRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedNamingServer) mustEmbedUnimplementedNamingServer() {}
 
-// UnsafeNamingServer may be embedded to opt out of forward compatibility for this service.
-// Use of this interface is not recommended, as added methods to NamingServer will
-// result in compilation errors.
-type UnsafeNamingServer interface {
-	mustEmbedUnimplementedNamingServer()
+func _Naming_Setxattr_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetxattrRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).Setxattr(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/Setxattr",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).Setxattr(ctx, req.(*SetxattrRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func RegisterNamingServer(s *grpc.Server, srv NamingServer) {
-	s.RegisterService(&_Naming_serviceDesc, srv)
+func _Naming_Listxattr_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListxattrRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamingServer).Listxattr(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Naming/Listxattr",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamingServer).Listxattr(ctx, req.(*ListxattrRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _Naming_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(RegRequest)
+func _Naming_Removexattr_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemovexattrRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(NamingServer).Register(ctx, in)
+		return srv.(NamingServer).Removexattr(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/pb.Naming/Register",
+		FullMethod: "/pb.Naming/Removexattr",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(NamingServer).Register(ctx, req.(*RegRequest))
+		return srv.(NamingServer).Removexattr(ctx, req.(*RemovexattrRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Naming_CreateFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CreateFileRequest)
+func _Naming_OpenSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OpenSessionRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(NamingServer).CreateFile(ctx, in)
+		return srv.(NamingServer).OpenSession(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/pb.Naming/CreateFile",
+		FullMethod: "/pb.Naming/OpenSession",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(NamingServer).CreateFile(ctx, req.(*CreateFileRequest))
+		return srv.(NamingServer).OpenSession(ctx, req.(*OpenSessionRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Naming_Copy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CopyRequest)
+func _Naming_KeepAlive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeepAliveRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(NamingServer).Copy(ctx, in)
+		return srv.(NamingServer).KeepAlive(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/pb.Naming/Copy",
+		FullMethod: "/pb.Naming/KeepAlive",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(NamingServer).Copy(ctx, req.(*CopyRequest))
+		return srv.(NamingServer).KeepAlive(ctx, req.(*KeepAliveRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Naming_Discover_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DiscoverRequest)
+func _Naming_CloseSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloseSessionRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(NamingServer).Discover(ctx, in)
+		return srv.(NamingServer).CloseSession(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/pb.Naming/Discover",
+		FullMethod: "/pb.Naming/CloseSession",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(NamingServer).Discover(ctx, req.(*DiscoverRequest))
+		return srv.(NamingServer).CloseSession(ctx, req.(*CloseSessionRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Naming_DeleteFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DeleteRequest)
+func _Naming_Open_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OpenRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(NamingServer).DeleteFile(ctx, in)
+		return srv.(NamingServer).Open(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/pb.Naming/DeleteFile",
+		FullMethod: "/pb.Naming/Open",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(NamingServer).DeleteFile(ctx, req.(*DeleteRequest))
+		return srv.(NamingServer).Open(ctx, req.(*OpenRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Naming_DeleteDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DeleteRequest)
+func _Naming_Close_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloseRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(NamingServer).DeleteDirectory(ctx, in)
+		return srv.(NamingServer).Close(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/pb.Naming/DeleteDirectory",
+		FullMethod: "/pb.Naming/Close",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(NamingServer).DeleteDirectory(ctx, req.(*DeleteRequest))
+		return srv.(NamingServer).Close(ctx, req.(*CloseRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Naming_Move_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(MoveRequest)
+func _Naming_Lock_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LockRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NamingServer).Lock(m, &namingLockServer{stream})
+}
+
+type Naming_LockServer interface {
+	Send(*LockResponse) error
+	grpc.ServerStream
+}
+
+type namingLockServer struct {
+	grpc.ServerStream
+}
+
+func (x *namingLockServer) Send(m *LockResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Naming_Unlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnlockRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(NamingServer).Move(ctx, in)
+		return srv.(NamingServer).Unlock(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/pb.Naming/Move",
+		FullMethod: "/pb.Naming/Unlock",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(NamingServer).Move(ctx, req.(*MoveRequest))
+		return srv.(NamingServer).Unlock(ctx, req.(*UnlockRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Naming_MakeDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(MakeDirectoryRequest)
+func _Naming_TestLock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(NamingServer).MakeDirectory(ctx, in)
+		return srv.(NamingServer).TestLock(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/pb.Naming/MakeDirectory",
+		FullMethod: "/pb.Naming/TestLock",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(NamingServer).MakeDirectory(ctx, req.(*MakeDirectoryRequest))
+		return srv.(NamingServer).TestLock(ctx, req.(*LockRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Naming_ListDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ListDirectoryRequest)
+func _Naming_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NamingServer).Watch(m, &namingWatchServer{stream})
+}
+
+type Naming_WatchServer interface {
+	Send(*WatchResponse) error
+	grpc.ServerStream
+}
+
+type namingWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *namingWatchServer) Send(m *WatchResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Naming_ReadChanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadChangesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(NamingServer).ListDirectory(ctx, in)
+		return srv.(NamingServer).ReadChanges(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/pb.Naming/ListDirectory",
+		FullMethod: "/pb.Naming/ReadChanges",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(NamingServer).ListDirectory(ctx, req.(*ListDirectoryRequest))
+		return srv.(NamingServer).ReadChanges(ctx, req.(*ReadChangesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -399,7 +1621,146 @@ var _Naming_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ListDirectory",
 			Handler:    _Naming_ListDirectory_Handler,
 		},
+		{
+			MethodName: "CommitWrite",
+			Handler:    _Naming_CommitWrite_Handler,
+		},
+		{
+			MethodName: "GrantLease",
+			Handler:    _Naming_GrantLease_Handler,
+		},
+		{
+			MethodName: "Heartbeat",
+			Handler:    _Naming_Heartbeat_Handler,
+		},
+		{
+			MethodName: "Truncate",
+			Handler:    _Naming_Truncate_Handler,
+		},
+		{
+			MethodName: "Allocate",
+			Handler:    _Naming_Allocate_Handler,
+		},
+		{
+			MethodName: "Restore",
+			Handler:    _Naming_Restore_Handler,
+		},
+		{
+			MethodName: "ListTrash",
+			Handler:    _Naming_ListTrash_Handler,
+		},
+		{
+			MethodName: "CreateSnapshot",
+			Handler:    _Naming_CreateSnapshot_Handler,
+		},
+		{
+			MethodName: "ListSnapshots",
+			Handler:    _Naming_ListSnapshots_Handler,
+		},
+		{
+			MethodName: "DeleteSnapshot",
+			Handler:    _Naming_DeleteSnapshot_Handler,
+		},
+		{
+			MethodName: "SetVersioning",
+			Handler:    _Naming_SetVersioning_Handler,
+		},
+		{
+			MethodName: "CommitFile",
+			Handler:    _Naming_CommitFile_Handler,
+		},
+		{
+			MethodName: "ListVersions",
+			Handler:    _Naming_ListVersions_Handler,
+		},
+		{
+			MethodName: "RestoreVersion",
+			Handler:    _Naming_RestoreVersion_Handler,
+		},
+		{
+			MethodName: "SetQuota",
+			Handler:    _Naming_SetQuota_Handler,
+		},
+		{
+			MethodName: "GetUsage",
+			Handler:    _Naming_GetUsage_Handler,
+		},
+		{
+			MethodName: "ReserveSpace",
+			Handler:    _Naming_ReserveSpace_Handler,
+		},
+		{
+			MethodName: "Symlink",
+			Handler:    _Naming_Symlink_Handler,
+		},
+		{
+			MethodName: "Readlink",
+			Handler:    _Naming_Readlink_Handler,
+		},
+		{
+			MethodName: "Link",
+			Handler:    _Naming_Link_Handler,
+		},
+		{
+			MethodName: "Getxattr",
+			Handler:    _Naming_Getxattr_Handler,
+		},
+		{
+			MethodName: "Setxattr",
+			Handler:    _Naming_Setxattr_Handler,
+		},
+		{
+			MethodName: "Listxattr",
+			Handler:    _Naming_Listxattr_Handler,
+		},
+		{
+			MethodName: "Removexattr",
+			Handler:    _Naming_Removexattr_Handler,
+		},
+		{
+			MethodName: "OpenSession",
+			Handler:    _Naming_OpenSession_Handler,
+		},
+		{
+			MethodName: "KeepAlive",
+			Handler:    _Naming_KeepAlive_Handler,
+		},
+		{
+			MethodName: "CloseSession",
+			Handler:    _Naming_CloseSession_Handler,
+		},
+		{
+			MethodName: "Open",
+			Handler:    _Naming_Open_Handler,
+		},
+		{
+			MethodName: "Close",
+			Handler:    _Naming_Close_Handler,
+		},
+		{
+			MethodName: "Unlock",
+			Handler:    _Naming_Unlock_Handler,
+		},
+		{
+			MethodName: "TestLock",
+			Handler:    _Naming_TestLock_Handler,
+		},
+		{
+			MethodName: "ReadChanges",
+			Handler:    _Naming_ReadChanges_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Lock",
+			Handler:       _Naming_Lock_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _Naming_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "naming_service.proto",
 }
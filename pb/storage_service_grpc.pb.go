@@ -24,7 +24,16 @@ type StorageClient interface {
 	Remove(ctx context.Context, in *RemoveArgs, opts ...grpc.CallOption) (*RemoveResult, error)
 	GetFileInfo(ctx context.Context, in *GetFileInfoArgs, opts ...grpc.CallOption) (*GetFileInfoResult, error)
 	Copy(ctx context.Context, in *CopyArgs, opts ...grpc.CallOption) (*CopyResult, error)
-	Move(ctx context.Context, in *MoveArgs, opts ...grpc.CallOption) (*MoveResult, error)
+	FetchFile(ctx context.Context, in *FetchFileArgs, opts ...grpc.CallOption) (*FetchFileResult, error)
+	Append(ctx context.Context, in *AppendArgs, opts ...grpc.CallOption) (*AppendResult, error)
+	Truncate(ctx context.Context, in *TruncateArgs, opts ...grpc.CallOption) (*TruncateResult, error)
+	Allocate(ctx context.Context, in *AllocateArgs, opts ...grpc.CallOption) (*AllocateResult, error)
+	CreateSnapshot(ctx context.Context, in *SnapshotArgs, opts ...grpc.CallOption) (*SnapshotResult, error)
+	SaveVersion(ctx context.Context, in *VersionArgs, opts ...grpc.CallOption) (*VersionResult, error)
+	DeleteVersion(ctx context.Context, in *VersionArgs, opts ...grpc.CallOption) (*VersionResult, error)
+	RestoreVersion(ctx context.Context, in *RestoreVersionArgs, opts ...grpc.CallOption) (*VersionResult, error)
+	GetInventory(ctx context.Context, in *InventoryArgs, opts ...grpc.CallOption) (*InventoryResult, error)
+	ReadDelta(ctx context.Context, in *ReadDeltaArgs, opts ...grpc.CallOption) (Storage_ReadDeltaClient, error)
 }
 
 type storageClient struct {
@@ -98,15 +107,119 @@ func (c *storageClient) Copy(ctx context.Context, in *CopyArgs, opts ...grpc.Cal
 	return out, nil
 }
 
-func (c *storageClient) Move(ctx context.Context, in *MoveArgs, opts ...grpc.CallOption) (*MoveResult, error) {
-	out := new(MoveResult)
-	err := c.cc.Invoke(ctx, "/pb.Storage/Move", in, out, opts...)
+func (c *storageClient) FetchFile(ctx context.Context, in *FetchFileArgs, opts ...grpc.CallOption) (*FetchFileResult, error) {
+	out := new(FetchFileResult)
+	err := c.cc.Invoke(ctx, "/pb.Storage/FetchFile", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
+func (c *storageClient) Append(ctx context.Context, in *AppendArgs, opts ...grpc.CallOption) (*AppendResult, error) {
+	out := new(AppendResult)
+	err := c.cc.Invoke(ctx, "/pb.Storage/Append", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Truncate(ctx context.Context, in *TruncateArgs, opts ...grpc.CallOption) (*TruncateResult, error) {
+	out := new(TruncateResult)
+	err := c.cc.Invoke(ctx, "/pb.Storage/Truncate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Allocate(ctx context.Context, in *AllocateArgs, opts ...grpc.CallOption) (*AllocateResult, error) {
+	out := new(AllocateResult)
+	err := c.cc.Invoke(ctx, "/pb.Storage/Allocate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) CreateSnapshot(ctx context.Context, in *SnapshotArgs, opts ...grpc.CallOption) (*SnapshotResult, error) {
+	out := new(SnapshotResult)
+	err := c.cc.Invoke(ctx, "/pb.Storage/CreateSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) SaveVersion(ctx context.Context, in *VersionArgs, opts ...grpc.CallOption) (*VersionResult, error) {
+	out := new(VersionResult)
+	err := c.cc.Invoke(ctx, "/pb.Storage/SaveVersion", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) DeleteVersion(ctx context.Context, in *VersionArgs, opts ...grpc.CallOption) (*VersionResult, error) {
+	out := new(VersionResult)
+	err := c.cc.Invoke(ctx, "/pb.Storage/DeleteVersion", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) RestoreVersion(ctx context.Context, in *RestoreVersionArgs, opts ...grpc.CallOption) (*VersionResult, error) {
+	out := new(VersionResult)
+	err := c.cc.Invoke(ctx, "/pb.Storage/RestoreVersion", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) GetInventory(ctx context.Context, in *InventoryArgs, opts ...grpc.CallOption) (*InventoryResult, error) {
+	out := new(InventoryResult)
+	err := c.cc.Invoke(ctx, "/pb.Storage/GetInventory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) ReadDelta(ctx context.Context, in *ReadDeltaArgs, opts ...grpc.CallOption) (Storage_ReadDeltaClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Storage_serviceDesc.Streams[0], "/pb.Storage/ReadDelta", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &storageReadDeltaClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Storage_ReadDeltaClient interface {
+	Recv() (*DeltaChunk, error)
+	grpc.ClientStream
+}
+
+type storageReadDeltaClient struct {
+	grpc.ClientStream
+}
+
+func (x *storageReadDeltaClient) Recv() (*DeltaChunk, error) {
+	m := new(DeltaChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // StorageServer is the server API for Storage service.
 // All implementations must embed UnimplementedStorageServer
 // for forward compatibility
@@ -118,7 +231,16 @@ type StorageServer interface {
 	Remove(context.Context, *RemoveArgs) (*RemoveResult, error)
 	GetFileInfo(context.Context, *GetFileInfoArgs) (*GetFileInfoResult, error)
 	Copy(context.Context, *CopyArgs) (*CopyResult, error)
-	Move(context.Context, *MoveArgs) (*MoveResult, error)
+	FetchFile(context.Context, *FetchFileArgs) (*FetchFileResult, error)
+	Append(context.Context, *AppendArgs) (*AppendResult, error)
+	Truncate(context.Context, *TruncateArgs) (*TruncateResult, error)
+	Allocate(context.Context, *AllocateArgs) (*AllocateResult, error)
+	CreateSnapshot(context.Context, *SnapshotArgs) (*SnapshotResult, error)
+	SaveVersion(context.Context, *VersionArgs) (*VersionResult, error)
+	DeleteVersion(context.Context, *VersionArgs) (*VersionResult, error)
+	RestoreVersion(context.Context, *RestoreVersionArgs) (*VersionResult, error)
+	GetInventory(context.Context, *InventoryArgs) (*InventoryResult, error)
+	ReadDelta(*ReadDeltaArgs, Storage_ReadDeltaServer) error
 	mustEmbedUnimplementedStorageServer()
 }
 
@@ -147,8 +269,35 @@ func (UnimplementedStorageServer) GetFileInfo(context.Context, *GetFileInfoArgs)
 func (UnimplementedStorageServer) Copy(context.Context, *CopyArgs) (*CopyResult, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Copy not implemented")
 }
-func (UnimplementedStorageServer) Move(context.Context, *MoveArgs) (*MoveResult, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Move not implemented")
+func (UnimplementedStorageServer) FetchFile(context.Context, *FetchFileArgs) (*FetchFileResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchFile not implemented")
+}
+func (UnimplementedStorageServer) Append(context.Context, *AppendArgs) (*AppendResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Append not implemented")
+}
+func (UnimplementedStorageServer) Truncate(context.Context, *TruncateArgs) (*TruncateResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Truncate not implemented")
+}
+func (UnimplementedStorageServer) Allocate(context.Context, *AllocateArgs) (*AllocateResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Allocate not implemented")
+}
+func (UnimplementedStorageServer) CreateSnapshot(context.Context, *SnapshotArgs) (*SnapshotResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSnapshot not implemented")
+}
+func (UnimplementedStorageServer) SaveVersion(context.Context, *VersionArgs) (*VersionResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SaveVersion not implemented")
+}
+func (UnimplementedStorageServer) DeleteVersion(context.Context, *VersionArgs) (*VersionResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteVersion not implemented")
+}
+func (UnimplementedStorageServer) RestoreVersion(context.Context, *RestoreVersionArgs) (*VersionResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreVersion not implemented")
+}
+func (UnimplementedStorageServer) GetInventory(context.Context, *InventoryArgs) (*InventoryResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInventory not implemented")
+}
+func (UnimplementedStorageServer) ReadDelta(*ReadDeltaArgs, Storage_ReadDeltaServer) error {
+	return status.Errorf(codes.Unimplemented, "method ReadDelta not implemented")
 }
 func (UnimplementedStorageServer) mustEmbedUnimplementedStorageServer() {}
 
@@ -289,24 +438,189 @@ func _Storage_Copy_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Storage_Move_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(MoveArgs)
+func _Storage_FetchFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchFileArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).FetchFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Storage/FetchFile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).FetchFile(ctx, req.(*FetchFileArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_Append_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppendArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).Append(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Storage/Append",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).Append(ctx, req.(*AppendArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_Truncate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TruncateArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).Truncate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Storage/Truncate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).Truncate(ctx, req.(*TruncateArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_Allocate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AllocateArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).Allocate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Storage/Allocate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).Allocate(ctx, req.(*AllocateArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_CreateSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).CreateSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Storage/CreateSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).CreateSnapshot(ctx, req.(*SnapshotArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_SaveVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VersionArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).SaveVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Storage/SaveVersion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).SaveVersion(ctx, req.(*VersionArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_DeleteVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VersionArgs)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageServer).Move(ctx, in)
+		return srv.(StorageServer).DeleteVersion(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/pb.Storage/Move",
+		FullMethod: "/pb.Storage/DeleteVersion",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageServer).Move(ctx, req.(*MoveArgs))
+		return srv.(StorageServer).DeleteVersion(ctx, req.(*VersionArgs))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Storage_RestoreVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreVersionArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).RestoreVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Storage/RestoreVersion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).RestoreVersion(ctx, req.(*RestoreVersionArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_GetInventory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InventoryArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).GetInventory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Storage/GetInventory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).GetInventory(ctx, req.(*InventoryArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_ReadDelta_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReadDeltaArgs)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StorageServer).ReadDelta(m, &storageReadDeltaServer{stream})
+}
+
+type Storage_ReadDeltaServer interface {
+	Send(*DeltaChunk) error
+	grpc.ServerStream
+}
+
+type storageReadDeltaServer struct {
+	grpc.ServerStream
+}
+
+func (x *storageReadDeltaServer) Send(m *DeltaChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Storage_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "pb.Storage",
 	HandlerType: (*StorageServer)(nil),
@@ -340,10 +654,48 @@ var _Storage_serviceDesc = grpc.ServiceDesc{
 			Handler:    _Storage_Copy_Handler,
 		},
 		{
-			MethodName: "Move",
-			Handler:    _Storage_Move_Handler,
+			MethodName: "FetchFile",
+			Handler:    _Storage_FetchFile_Handler,
+		},
+		{
+			MethodName: "Append",
+			Handler:    _Storage_Append_Handler,
+		},
+		{
+			MethodName: "Truncate",
+			Handler:    _Storage_Truncate_Handler,
+		},
+		{
+			MethodName: "Allocate",
+			Handler:    _Storage_Allocate_Handler,
+		},
+		{
+			MethodName: "CreateSnapshot",
+			Handler:    _Storage_CreateSnapshot_Handler,
+		},
+		{
+			MethodName: "SaveVersion",
+			Handler:    _Storage_SaveVersion_Handler,
+		},
+		{
+			MethodName: "DeleteVersion",
+			Handler:    _Storage_DeleteVersion_Handler,
+		},
+		{
+			MethodName: "RestoreVersion",
+			Handler:    _Storage_RestoreVersion_Handler,
+		},
+		{
+			MethodName: "GetInventory",
+			Handler:    _Storage_GetInventory_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ReadDelta",
+			Handler:       _Storage_ReadDelta_Handler,
+			ServerStreams: true,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "storage_service.proto",
 }
@@ -0,0 +1,304 @@
+package storage_server
+
+import (
+	"bytes"
+	"crypto/md5"
+	"hash"
+	"io"
+	"math"
+	"os"
+	"project-dfs/pb"
+)
+
+// Replicas that differ only partially are transferred the way rsync does it: the fetching server
+// sends checksums of the blocks of the replica it holds, and the source sends only the data
+// that cannot be copied from those blocks.
+const (
+	minBlockSize = 2048
+	maxBlocks    = 1 << 16 // keeps the checksums of a replica within a single message
+	deltaChunk   = 1 << 20 // data sent in a single message
+)
+
+// Picks the block size for a replica of the size, roughly its square root like rsync does
+func blockSizeFor(size int64) int64 {
+	blockSize := int64(math.Sqrt(float64(size)))
+	if blockSize < (size+maxBlocks-1)/maxBlocks {
+		blockSize = (size + maxBlocks - 1) / maxBlocks
+	}
+	if blockSize < minBlockSize {
+		blockSize = minBlockSize
+	}
+	return blockSize
+}
+
+// Weak checksum of rsync, which can be moved along the data one byte at a time
+type rollingChecksum struct {
+	a, b uint32
+	size uint32
+}
+
+func newRollingChecksum(block []byte) rollingChecksum {
+	sum := rollingChecksum{size: uint32(len(block))}
+	for i, c := range block {
+		sum.a += uint32(c)
+		sum.b += uint32(len(block)-i) * uint32(c)
+	}
+	return sum
+}
+
+// Moves the checksum one byte forward, dropping the byte out and taking the byte in
+func (sum *rollingChecksum) roll(out byte, in byte) {
+	sum.a += uint32(in) - uint32(out)
+	sum.b += sum.a - sum.size*uint32(out)
+}
+
+func (sum *rollingChecksum) value() uint32 {
+	return sum.a&0xffff | sum.b<<16
+}
+
+// Computes checksums of the full blocks of the file
+func blockChecksums(path string) (int64, []*pb.BlockChecksum, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer fd.Close()
+
+	fileInfo, err := fd.Stat()
+	if err != nil {
+		return 0, nil, err
+	}
+	blockSize := blockSizeFor(fileInfo.Size())
+
+	var blocks []*pb.BlockChecksum
+	buf := make([]byte, blockSize)
+	for {
+		_, err := io.ReadFull(fd, buf)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return blockSize, blocks, nil
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		sum := newRollingChecksum(buf)
+		strong := md5.Sum(buf)
+		blocks = append(blocks, &pb.BlockChecksum{Weak: sum.value(), Strong: strong[:]})
+	}
+}
+
+// Builds chunks of the delta, putting consecutive blocks into a single chunk
+type deltaSender struct {
+	send  func(*pb.DeltaChunk) error
+	chunk *pb.DeltaChunk
+	hash  hash.Hash // of the contents sent
+}
+
+func (sender *deltaSender) flush() error {
+	if sender.chunk == nil {
+		return nil
+	}
+	err := sender.send(sender.chunk)
+	sender.chunk = nil
+	return err
+}
+
+func (sender *deltaSender) data(data []byte) error {
+	// the data left at the end of the file may exceed a chunk by up to a block
+	for len(data) > deltaChunk {
+		if err := sender.data(data[:deltaChunk]); err != nil {
+			return err
+		}
+		data = data[deltaChunk:]
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	sender.hash.Write(data)
+	if sender.chunk != nil && (sender.chunk.BlockCount > 0 || len(sender.chunk.Data)+len(data) > deltaChunk) {
+		if err := sender.flush(); err != nil {
+			return err
+		}
+	}
+	if sender.chunk == nil {
+		sender.chunk = &pb.DeltaChunk{}
+	}
+	// the data is copied, as the buffer it comes from is reused
+	sender.chunk.Data = append(sender.chunk.Data, data...)
+	return nil
+}
+
+func (sender *deltaSender) block(index int64, contents []byte) error {
+	sender.hash.Write(contents)
+	if sender.chunk != nil && sender.chunk.BlockCount > 0 {
+		if sender.chunk.FirstBlock+sender.chunk.BlockCount == index {
+			sender.chunk.BlockCount++
+			return nil
+		}
+		if err := sender.flush(); err != nil {
+			return err
+		}
+	}
+	if sender.chunk == nil {
+		sender.chunk = &pb.DeltaChunk{}
+	}
+	sender.chunk.FirstBlock = index
+	sender.chunk.BlockCount = 1
+	return nil
+}
+
+// Sends contents of the file following the offset as data and blocks of the replica
+// the receiver holds. Returns the MD5 of the contents sent.
+func SendDelta(fd *os.File, offset int64, blockSize int64, blocks []*pb.BlockChecksum, send func(*pb.DeltaChunk) error) ([]byte, error) {
+	sender := &deltaSender{send: send, hash: md5.New()}
+	reader := io.NewSectionReader(fd, offset, math.MaxInt64-offset)
+
+	index := make(map[uint32][]int64) // key:value = weak checksum:blocks
+	for i, block := range blocks {
+		index[block.Weak] = append(index[block.Weak], int64(i))
+	}
+	window := int(blockSize)
+	if len(index) == 0 {
+		// there is nothing to match, so the buffer is filled with data only
+		window = deltaChunk
+	}
+
+	// data is sent from start on, and the window of the checksum starts at i
+	buf := make([]byte, 0, 2*deltaChunk+window)
+	start, i := 0, 0
+	eof := false
+	next := int64(0) // block likely to match next
+	var sum rollingChecksum
+	rolling := false
+	var out byte
+	for {
+		if len(buf)-i < window && !eof {
+			// data waiting to be sent never exceeds a chunk, so there is room for at least another one
+			n := copy(buf[:cap(buf)], buf[start:])
+			buf = buf[:n]
+			i -= start
+			start = 0
+
+			n, err := io.ReadFull(reader, buf[len(buf):cap(buf)])
+			buf = buf[:len(buf)+n]
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				eof = true
+			} else if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if len(buf)-i < window {
+			break
+		}
+		if len(index) == 0 {
+			i += window
+			if err := sender.data(buf[start:i]); err != nil {
+				return nil, err
+			}
+			start = i
+			continue
+		}
+
+		if rolling {
+			sum.roll(out, buf[i+window-1])
+		} else {
+			sum = newRollingChecksum(buf[i : i+window])
+			rolling = true
+		}
+		if match := matchBlock(blocks, index[sum.value()], next, buf[i:i+window]); match >= 0 {
+			if err := sender.data(buf[start:i]); err != nil {
+				return nil, err
+			}
+			if err := sender.block(match, buf[i:i+window]); err != nil {
+				return nil, err
+			}
+			i += window
+			start = i
+			next = match + 1
+			rolling = false
+			continue
+		}
+
+		if i-start >= deltaChunk {
+			if err := sender.data(buf[start:i]); err != nil {
+				return nil, err
+			}
+			start = i
+		}
+		out = buf[i]
+		i++
+	}
+
+	if err := sender.data(buf[start:]); err != nil {
+		return nil, err
+	}
+	if err := sender.flush(); err != nil {
+		return nil, err
+	}
+	return sender.hash.Sum(nil), nil
+}
+
+// Returns the block among the candidates with the contents, preferring the expected one, or -1
+func matchBlock(blocks []*pb.BlockChecksum, candidates []int64, expected int64, contents []byte) int64 {
+	if len(candidates) == 0 {
+		return -1
+	}
+	strong := md5.Sum(contents)
+	match := int64(-1)
+	for _, candidate := range candidates {
+		if bytes.Equal(blocks[candidate].Strong, strong[:]) {
+			if candidate == expected {
+				return candidate
+			}
+			if match < 0 {
+				match = candidate
+			}
+		}
+	}
+	return match
+}
+
+// Writes the file from chunks of the delta and blocks of the held replica
+type deltaWriter struct {
+	dest      io.Writer
+	basis     *os.File
+	blockSize int64
+	written   int64
+	hash      hash.Hash // of the contents written
+}
+
+func newDeltaWriter(dest io.Writer, basis *os.File, blockSize int64) *deltaWriter {
+	hash := md5.New()
+	return &deltaWriter{
+		dest:      io.MultiWriter(dest, hash),
+		basis:     basis,
+		blockSize: blockSize,
+		hash:      hash,
+	}
+}
+
+func (writer *deltaWriter) apply(chunk *pb.DeltaChunk) error {
+	n, err := writer.dest.Write(chunk.Data)
+	writer.written += int64(n)
+	if err != nil {
+		return err
+	}
+	if chunk.BlockCount == 0 {
+		return nil
+	}
+
+	if writer.basis == nil {
+		return io.ErrUnexpectedEOF
+	}
+	length := chunk.BlockCount * writer.blockSize
+	copied, err := io.Copy(writer.dest, io.NewSectionReader(writer.basis, chunk.FirstBlock*writer.blockSize, length))
+	writer.written += copied
+	if err == nil && copied < length {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (writer *deltaWriter) checksum() []byte {
+	return writer.hash.Sum(nil)
+}
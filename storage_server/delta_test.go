@@ -0,0 +1,155 @@
+package storage_server
+
+import (
+	"bytes"
+	"crypto/md5"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"project-dfs/pb"
+	"testing"
+)
+
+func randomBytes(seed int64, n int) []byte {
+	data := make([]byte, n)
+	rand.New(rand.NewSource(seed)).Read(data)
+	return data
+}
+
+func writeTemp(t *testing.T, dir string, name string, data []byte) string {
+	path := filepath.Join(dir, name)
+	err := ioutil.WriteFile(path, data, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Sends the target as a delta against the basis and rebuilds it from the delta.
+// Returns the number of bytes sent as data rather than as blocks of the basis.
+func roundTrip(t *testing.T, basis []byte, target []byte, offset int64) int {
+	dir, err := ioutil.TempDir("", "dfs-delta-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	basisPath := writeTemp(t, dir, "basis", basis)
+	blockSize, blocks, err := blockChecksums(basisPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) > maxBlocks {
+		t.Fatalf("%d checksums of %d bytes exceed the limit", len(blocks), len(basis))
+	}
+	basisFd, err := os.Open(basisPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer basisFd.Close()
+	targetFd, err := os.Open(writeTemp(t, dir, "target", target))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer targetFd.Close()
+
+	var rebuilt bytes.Buffer
+	writer := newDeltaWriter(&rebuilt, basisFd, blockSize)
+	sent := 0
+	checksum, err := SendDelta(targetFd, offset, blockSize, blocks, func(chunk *pb.DeltaChunk) error {
+		if len(chunk.Data) > deltaChunk {
+			t.Fatalf("chunk of %d bytes exceeds the limit", len(chunk.Data))
+		}
+		sent += len(chunk.Data)
+		return writer.apply(chunk)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := target[offset:]
+	if !bytes.Equal(rebuilt.Bytes(), want) {
+		t.Fatalf("rebuilt %d bytes differ from the %d bytes sent", rebuilt.Len(), len(want))
+	}
+	sum := md5.Sum(want)
+	if !bytes.Equal(checksum, sum[:]) || !bytes.Equal(writer.checksum(), sum[:]) {
+		t.Fatal("checksums of the delta do not match the contents")
+	}
+	if writer.written != int64(len(want)) {
+		t.Fatalf("writer counted %d bytes; want %d", writer.written, len(want))
+	}
+	return sent
+}
+
+func TestDeltaRoundTrip(t *testing.T) {
+	const size = 3<<20 + 1234
+	basis := randomBytes(1, size)
+	blockSize := int(blockSizeFor(size))
+
+	tests := []struct {
+		name    string
+		basis   []byte
+		target  []byte
+		offset  int64
+		maxSent int // bytes sent as data at most
+	}{
+		{"identical", basis, basis, 0, blockSize},
+		{"shifted", basis, append(randomBytes(2, 100), basis...), 0, 100 + 2*blockSize},
+		{"truncated", basis, basis[:size-5000], 0, 2 * blockSize},
+		{"appended", basis, append(append([]byte{}, basis...), randomBytes(3, 10000)...), 0, 10000 + blockSize},
+		{"modified in the middle", basis, append(append(append([]byte{}, basis[:size/2]...), 'x'), basis[size/2+1:]...), 0, 3 * blockSize},
+		{"unrelated", basis, randomBytes(4, size), 0, size},
+		{"no basis", nil, basis, 0, size},
+		{"empty target", basis, nil, 0, 0},
+		{"resumed at offset", nil, basis, size / 3, size - size/3},
+		// the offset is not aligned to the blocks, so the data up to the next block is sent too
+		{"resumed with basis", basis, basis, size / 3, 2 * blockSize},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sent := roundTrip(t, test.basis, test.target, test.offset)
+			if sent > test.maxSent {
+				t.Fatalf("%d bytes sent as data; want at most %d", sent, test.maxSent)
+			}
+		})
+	}
+}
+
+func TestBlockSizeFor(t *testing.T) {
+	tests := []struct {
+		size      int64
+		blockSize int64
+	}{
+		{0, minBlockSize},
+		{1, minBlockSize},
+		{minBlockSize * minBlockSize, minBlockSize},
+		{minBlockSize*minBlockSize + 1, minBlockSize},
+		{1 << 30, 1 << 15},
+		{maxBlocks * maxBlocks, maxBlocks},
+		// past 4 GiB the square root would need more checksums than a message takes
+		{maxBlocks*maxBlocks + 1, maxBlocks + 1},
+		{1 << 40, 1 << 24},
+	}
+	for _, test := range tests {
+		blockSize := blockSizeFor(test.size)
+		if blockSize != test.blockSize {
+			t.Errorf("blockSizeFor(%d) = %d; want %d", test.size, blockSize, test.blockSize)
+		}
+		if test.size/blockSize > maxBlocks {
+			t.Errorf("blockSizeFor(%d) = %d makes %d blocks", test.size, blockSize, test.size/blockSize)
+		}
+	}
+}
+
+func TestRollingChecksum(t *testing.T) {
+	data := randomBytes(5, 4096)
+	const window = 512
+	sum := newRollingChecksum(data[:window])
+	for i := 1; i+window <= len(data); i++ {
+		sum.roll(data[i-1], data[i+window-1])
+		if want := newRollingChecksum(data[i : i+window]); sum.value() != want.value() {
+			t.Fatalf("rolled checksum at %d = %x; want %x", i, sum.value(), want.value())
+		}
+	}
+}
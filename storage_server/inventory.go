@@ -0,0 +1,34 @@
+package storage_server
+
+import (
+	"io/ioutil"
+	"os"
+	"project-dfs/pb"
+	"strconv"
+)
+
+// Lists the replicas kept in the storage directory along with their versions.
+// Files being fetched from other replicas are left out.
+func Inventory() ([]*pb.StoredFile, error) {
+	entries, err := ioutil.ReadDir(StoragePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*pb.StoredFile
+	for _, entry := range entries {
+		fileId, err := strconv.ParseUint(entry.Name(), 10, 64)
+		if err != nil || !entry.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, &pb.StoredFile{
+			FileId:  fileId,
+			Version: GetFileVersion(BlobPath(fileId)),
+			Size:    entry.Size(),
+		})
+	}
+	return files, nil
+}
@@ -0,0 +1,196 @@
+package storage_server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"project-dfs/pb"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Primary lease on a file granted to this server by the naming server
+type heldLease struct {
+	expires  time.Time
+	lastUsed time.Time
+}
+
+// Returns the address of the primary replica of the file, or "" if this server is the primary itself.
+func (server *StorageServer) GetPrimary(ctx context.Context, fileId uint64) (string, *pb.ErrorStatus) {
+	server.leasesMutex.Lock()
+	lease, ok := server.leases[fileId]
+	if ok && time.Now().Before(lease.expires) {
+		lease.lastUsed = time.Now()
+		server.leasesMutex.Unlock()
+		return "", nil
+	}
+	server.leasesMutex.Unlock()
+
+	// Lease validity is counted from the moment of request, so that it never outlives the naming server's view
+	requested := time.Now()
+	response, err := server.GetNamingClient().GrantLease(ctx, &pb.LeaseRequest{
+		FileId:      fileId,
+		StorageName: server.Alias,
+	})
+	if err != nil {
+		return "", &pb.ErrorStatus{
+			Code:        uint32(syscall.EIO),
+			Description: err.Error(),
+		}
+	}
+	if response.ErrorStatus.Code != 0 {
+		return "", response.ErrorStatus
+	}
+
+	if response.Primary.Alias != server.Alias {
+		return response.Primary.Address, nil
+	}
+
+	server.leasesMutex.Lock()
+	server.leases[fileId] = &heldLease{
+		expires:  requested.Add(time.Duration(response.LeaseMillis) * time.Millisecond),
+		lastUsed: requested,
+	}
+	server.leasesMutex.Unlock()
+	return "", nil
+}
+
+// Returns the client of the primary replica of the file, or nil if this server is the primary itself
+func (server *StorageServer) PrimaryClient(ctx context.Context, fileId uint64) (pb.StorageClient, *pb.ErrorStatus) {
+	primary, status := server.GetPrimary(ctx, fileId)
+	if status != nil || primary == "" {
+		return nil, status
+	}
+
+	client := server.GetStorageClient(primary)
+	if client == nil {
+		return nil, &pb.ErrorStatus{
+			Code:        uint32(syscall.EIO),
+			Description: "No connection to primary replica",
+		}
+	}
+	return client, nil
+}
+
+// Returns the mutex that serializes mutations of the file on the primary
+func (server *StorageServer) FileMutex(fileId uint64) *sync.Mutex {
+	server.fileMutexesMutex.Lock()
+	defer server.fileMutexesMutex.Unlock()
+
+	mutex, ok := server.fileMutexes[fileId]
+	if !ok {
+		mutex = &sync.Mutex{}
+		server.fileMutexes[fileId] = mutex
+	}
+	return mutex
+}
+
+// Bumps the version of the file mutated by the primary and stores it locally
+func (server *StorageServer) CommitMutation(ctx context.Context, fileId uint64) (uint64, *pb.ErrorStatus) {
+	fileInfo, err := os.Stat(BlobPath(fileId))
+	if err != nil {
+		return 0, &pb.ErrorStatus{
+			Code:        1,
+			Description: err.Error(),
+		}
+	}
+
+	commit, err := server.GetNamingClient().CommitWrite(ctx, &pb.CommitWriteRequest{
+		FileId:      fileId,
+		StorageName: server.Alias,
+		Size:        fileInfo.Size(),
+	})
+	if err != nil {
+		return 0, &pb.ErrorStatus{
+			Code:        uint32(syscall.EIO),
+			Description: err.Error(),
+		}
+	}
+	if commit.ErrorStatus.Code != 0 {
+		return 0, commit.ErrorStatus
+	}
+
+	_ = SetFileVersion(BlobPath(fileId), commit.Version)
+	return commit.Version, nil
+}
+
+// Applies the mutation to the other replicas of the file, one after another.
+// Replicas that fail to apply it stay behind and are reconciled by the naming server.
+func (server *StorageServer) ForEachSecondary(ctx context.Context, fileId uint64, mutate func(client pb.StorageClient) error) {
+	response, err := server.GetNamingClient().Discover(ctx, &pb.DiscoverRequest{
+		FileId:             fileId,
+		ExcludeStorageName: server.Alias,
+	})
+	if err != nil {
+		println("Error while replicating call:", err.Error())
+		return
+	}
+
+	for _, s := range response.StorageInfo {
+		client := server.GetStorageClient(s.Address)
+		if client == nil {
+			println("aborting replication")
+			break
+		}
+		err := mutate(client)
+		if err != nil {
+			println("Error replicating to", s.Alias, ":", err.Error())
+		}
+	}
+}
+
+func (server *StorageServer) HeartbeatLoop() {
+	for {
+		time.Sleep(server.HeartbeatInterval)
+		server.SendHeartbeat()
+	}
+}
+
+// Reports liveness and free space to the naming server and renews leases on recently mutated files
+func (server *StorageServer) SendHeartbeat() {
+	server.leasesMutex.Lock()
+	var fileIds []uint64
+	for fileId, lease := range server.leases {
+		if !time.Now().Before(lease.expires) {
+			delete(server.leases, fileId)
+			continue
+		}
+		if time.Since(lease.lastUsed) < server.HeartbeatInterval {
+			fileIds = append(fileIds, fileId)
+		}
+	}
+	server.leasesMutex.Unlock()
+
+	requested := time.Now()
+	response, err := server.GetNamingClient().Heartbeat(context.Background(), &pb.HeartbeatRequest{
+		ServerAlias:    server.Alias,
+		Leases:         fileIds,
+		CapacityBytes:  getCapacity(),
+		AvailableBytes: getFreeSpace(),
+	})
+	if err != nil {
+		println("Error sending heartbeat:", err.Error())
+		return
+	}
+
+	if response.Status != pb.Status_ACCEPT {
+		fmt.Println("Naming server does not know about", server.Alias, "; registering again")
+		replicas, err := server.Register()
+		if err != nil {
+			println("Error registering:", err.Error())
+			return
+		}
+		go server.FetchReplicas(replicas)
+		return
+	}
+
+	server.leasesMutex.Lock()
+	defer server.leasesMutex.Unlock()
+	for _, fileId := range response.RenewedLeases {
+		lease, ok := server.leases[fileId]
+		if ok {
+			lease.expires = requested.Add(time.Duration(response.LeaseMillis) * time.Millisecond)
+		}
+	}
+}
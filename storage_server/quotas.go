@@ -0,0 +1,49 @@
+package storage_server
+
+import (
+	"context"
+	"errors"
+	"os"
+	"project-dfs/pb"
+	"syscall"
+)
+
+// Makes the naming server reserve space before the primary grows the file to the size.
+// Fails with EDQUOT if the write would exceed a quota.
+func (server *StorageServer) ReserveSpace(ctx context.Context, fileId uint64, size int64) *pb.ErrorStatus {
+	fileInfo, err := os.Stat(BlobPath(fileId))
+	if err == nil && size <= fileInfo.Size() {
+		// the file does not grow
+		return nil
+	}
+
+	response, err := server.GetNamingClient().ReserveSpace(ctx, &pb.ReserveSpaceRequest{
+		FileId: fileId,
+		Size:   size,
+	})
+	if err != nil {
+		return &pb.ErrorStatus{
+			Code:        uint32(syscall.EIO),
+			Description: err.Error(),
+		}
+	}
+	if response.ErrorStatus.Code != 0 {
+		return response.ErrorStatus
+	}
+	return nil
+}
+
+// Gives back the space reserved for a write the primary failed to commit
+func (server *StorageServer) ReleaseSpace(fileId uint64) {
+	// the space is released even if the write was cancelled
+	response, err := server.GetNamingClient().ReserveSpace(context.Background(), &pb.ReserveSpaceRequest{
+		FileId:  fileId,
+		Release: true,
+	})
+	if err == nil && response.ErrorStatus.Code != 0 {
+		err = errors.New(response.ErrorStatus.Description)
+	}
+	if err != nil {
+		println("Error releasing space reserved for file", fileId, ":", err.Error())
+	}
+}
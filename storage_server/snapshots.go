@@ -0,0 +1,74 @@
+package storage_server
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+// Files preserved for a snapshot or as a saved version are hard links to the live replicas.
+// The shared inode is marked, so that the live replica gets its own copy before it is modified in place.
+const sharedAttribute = "user.dfs.shared"
+
+func isShared(path string) bool {
+	_, err := syscall.Getxattr(path, sharedAttribute, make([]byte, 1))
+	return err == nil
+}
+
+// Preserves the current contents of the replica at the preserved path.
+// The inode is shared until the replica is modified.
+func PreserveFile(livePath string, preservedPath string) error {
+	err := syscall.Setxattr(livePath, sharedAttribute, []byte{1}, 0)
+	if err != nil {
+		return err
+	}
+	return os.Link(livePath, preservedPath)
+}
+
+// Copies contents and version of the replica
+func copyFile(path string, copyPath string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dest, err := os.Create(copyPath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dest, src)
+	dest.Close()
+	if err == nil {
+		err = SetFileVersion(copyPath, GetFileVersion(path))
+	}
+	if err != nil {
+		_ = os.Remove(copyPath)
+	}
+	return err
+}
+
+// Makes the replica safe to modify in place.
+// If its contents are preserved for a snapshot, the replica is replaced with a private copy first.
+func BreakSharing(path string) error {
+	if !isShared(path) {
+		return nil
+	}
+
+	var stat syscall.Stat_t
+	err := syscall.Stat(path, &stat)
+	if err != nil {
+		return err
+	}
+	if stat.Nlink == 1 {
+		// all snapshots referencing the contents are gone
+		return syscall.Removexattr(path, sharedAttribute)
+	}
+
+	copyPath := path + ".cow"
+	err = copyFile(path, copyPath)
+	if err != nil {
+		return err
+	}
+	return os.Rename(copyPath, path)
+}
@@ -2,15 +2,19 @@ package storage_server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"google.golang.org/grpc"
 	"log"
 	"net"
 	"os"
+	utils "project-dfs"
 	"project-dfs/pb"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 )
 
 type StorageServer struct {
@@ -21,7 +25,19 @@ type StorageServer struct {
 	storageAddressesMutex sync.Mutex
 	storageAddresses      map[string]string // key:value = serverAlias:serverAddress
 	namingClient          pb.NamingClient
+	storageClientsMutex   sync.Mutex
 	storageClients        map[string]pb.StorageClient
+	HeartbeatInterval     time.Duration
+	leasesMutex           sync.Mutex
+	leases                map[uint64]*heldLease // key:value = fileId:lease
+	fileMutexesMutex      sync.Mutex
+	fileMutexes           map[uint64]*sync.Mutex
+	SyncWorkers           int
+	syncThrottle          *throttle
+	checkpointMutex       sync.Mutex
+	checkpoint            map[uint64]*fetchProgress // key:value = fileId:progress of its fetch
+	fetchMutexesMutex     sync.Mutex
+	fetchMutexes          map[uint64]*sync.Mutex
 }
 
 func (server *StorageServer) SetMap(newKey string, newValue string) {
@@ -32,7 +48,7 @@ func (server *StorageServer) SetMap(newKey string, newValue string) {
 
 func (server *StorageServer) GetNamingClient() pb.NamingClient {
 	if server.namingClient == nil {
-		conn, err := grpc.Dial(server.NamingServerAddress, grpc.WithInsecure())
+		conn, err := grpc.Dial(server.NamingServerAddress, utils.DialOptions()...)
 		if err != nil {
 			println("Error while getting naming client:", err)
 			return nil
@@ -43,9 +59,12 @@ func (server *StorageServer) GetNamingClient() pb.NamingClient {
 }
 
 func (server *StorageServer) GetStorageClient(address string) pb.StorageClient {
+	server.storageClientsMutex.Lock()
+	defer server.storageClientsMutex.Unlock()
+
 	client, ok := server.storageClients[address]
 	if !ok {
-		conn, err := grpc.Dial(address, grpc.WithInsecure())
+		conn, err := grpc.Dial(address, utils.DialOptions()...)
 		if err != nil {
 			println("Error while getting storage client:", err)
 			return nil
@@ -85,6 +104,27 @@ func initStorageServer() *StorageServer {
 		fmt.Println("ALIAS variable not specified; falling back to", alias)
 	}
 
+	// Obtain heartbeat interval (in seconds) from environment
+	heartbeatInterval, err := strconv.Atoi(os.Getenv("HEARTBEAT_INTERVAL"))
+	if err != nil || heartbeatInterval <= 0 {
+		heartbeatInterval = 10
+		fmt.Println("HEARTBEAT_INTERVAL variable not specified; falling back to", heartbeatInterval)
+	}
+
+	// Obtain number of replicas fetched at once from environment
+	syncWorkers, err := strconv.Atoi(os.Getenv("SYNC_WORKERS"))
+	if err != nil || syncWorkers <= 0 {
+		syncWorkers = 4
+		fmt.Println("SYNC_WORKERS variable not specified; falling back to", syncWorkers)
+	}
+
+	// Obtain bandwidth available to fetches (in bytes per second) from environment
+	syncBandwidth, err := strconv.ParseInt(os.Getenv("SYNC_BANDWIDTH"), 10, 64)
+	if err != nil || syncBandwidth < 0 {
+		syncBandwidth = 0
+		fmt.Println("SYNC_BANDWIDTH variable not specified; fetches are not throttled")
+	}
+
 	return &StorageServer{
 		LocalAddress:          localAddress,
 		Alias:                 alias,
@@ -93,6 +133,13 @@ func initStorageServer() *StorageServer {
 		storageAddressesMutex: sync.Mutex{},
 		storageAddresses:      make(map[string]string),
 		storageClients:        map[string]pb.StorageClient{},
+		HeartbeatInterval:     time.Duration(heartbeatInterval) * time.Second,
+		leases:                map[uint64]*heldLease{},
+		fileMutexes:           map[uint64]*sync.Mutex{},
+		SyncWorkers:           syncWorkers,
+		syncThrottle:          &throttle{rate: syncBandwidth},
+		checkpoint:            loadCheckpoint(),
+		fetchMutexes:          map[uint64]*sync.Mutex{},
 	}
 }
 
@@ -103,106 +150,88 @@ func CheckError(err error) {
 	}
 }
 
+// Registers this storage server in the naming server along with the replicas it holds.
+// Returns the replicas assigned to the server that it lacks or holds in older versions.
+func (server *StorageServer) Register() ([]*pb.AssignedReplica, error) {
+	port, _ := strconv.Atoi(server.LocalAddress[strings.LastIndex(server.LocalAddress, ":")+1:])
+
+	inventory, err := Inventory()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := server.GetNamingClient().Register(context.Background(), &pb.RegRequest{
+		ServerAlias:    server.Alias,
+		Port:           uint32(port),
+		PublicHostname: server.PublicHostname,
+		Inventory:      inventory,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Response from naming server: %s", response.GetStatus())
+
+	if response.GetStatus() != pb.Status_ACCEPT {
+		return nil, errors.New("registration declined by naming server")
+	}
+	return response.Fetch, nil
+}
+
 func Run() {
 	server := initStorageServer()
 
 	fmt.Printf("Initialized storage metadata: %+v\n", server)
 
 	fmt.Println("Connecting to naming server at", server.NamingServerAddress)
-	conn, err := grpc.Dial(server.NamingServerAddress, grpc.WithInsecure())
+	replicas, err := server.Register()
+	for err != nil {
+		// the naming server may be restarting, or takes a decommissioned server back once it is activated
+		println("Error registering:", err.Error())
+		time.Sleep(server.HeartbeatInterval)
+		replicas, err = server.Register()
+	}
+
+	// listen to connections
+	listener, err := net.Listen("tcp", server.LocalAddress)
 	CheckError(err)
 
-	port, _ := strconv.Atoi(server.LocalAddress[strings.LastIndex(server.LocalAddress, ":")+1:])
+	fmt.Println("Starting sync of", len(replicas), "replicas of "+server.Alias+"...")
+	server.DiscardFetches(replicas)
+	server.FetchReplicas(replicas)
+	fmt.Println("Sync completed.")
 
-	newServer := pb.NewNamingClient(conn)
-	response, err := newServer.Register(context.Background(), &pb.RegRequest{
-		ServerAlias:    server.Alias,
-		Port:           uint32(port),
-		PublicHostname: server.PublicHostname,
-	})
-	CheckError(err)
-	log.Printf("Response from naming server: %s", response.GetStatus())
+	go server.HeartbeatLoop()
 
-	if response.GetStatus().String() == "ACCEPT" {
-		// listen to connections
-		listener, err := net.Listen("tcp", server.LocalAddress)
-		CheckError(err)
+	println("Listening on " + server.LocalAddress)
+	storageController := NewStorageServiceController(server)
+	grpcServer := grpc.NewServer(utils.ServerOptions()...)
+	pb.RegisterStorageServer(grpcServer, storageController)
+	err = grpcServer.Serve(listener)
+	CheckError(err)
+}
 
-		fmt.Println("Starting sync of " + server.Alias + "...")
-		server.Sync("")
-		fmt.Println("Sync completed.")
+// Versions of the replicas are kept in an extended attribute of the file itself,
+// so that they follow the file when it is put in place of the replica.
+const versionAttribute = "user.dfs.version"
 
-		println("Listening on " + server.LocalAddress)
-		storageController := NewStorageServiceController(server)
-		grpcServer := grpc.NewServer()
-		pb.RegisterStorageServer(grpcServer, storageController)
-		err = grpcServer.Serve(listener)
-		CheckError(err)
+func GetFileVersion(path string) uint64 {
+	buf := make([]byte, 20)
+	n, err := syscall.Getxattr(path, versionAttribute, buf)
+	if err != nil {
+		return 0
 	}
+	version, _ := strconv.ParseUint(string(buf[:n]), 10, 64)
+	return version
 }
 
-func (server *StorageServer) Sync(path string) {
-	fmt.Println("Syncing directory", path)
+func SetFileVersion(path string, version uint64) error {
+	return syscall.Setxattr(path, versionAttribute, []byte(strconv.FormatUint(version, 10)), 0)
+}
 
-	response, err := server.GetNamingClient().ListDirectory(context.Background(), &pb.ListDirectoryRequest{Path: path})
-	if err != nil {
-		println("Error syncing path", path, ":", err.Error())
-		return
-	}
-
-	fmt.Println(response.Contents)
-
-	for _, content := range response.Contents {
-		if content.Mode == pb.NodeMode_DIRECTORY {
-			// Recursively sync directory
-			server.Sync(path + "/" + content.Name)
-		} else {
-			filePath := path + "/" + content.Name
-			os.MkdirAll(StoragePath+path, 0777)
-
-			discovered, err := server.GetNamingClient().Discover(context.Background(), &pb.DiscoverRequest{
-				Path:               filePath,
-				ExcludeStorageName: server.Alias,
-			})
-			if err != nil {
-				println("Error discovery during sync:", err.Error())
-				continue
-			}
-
-			// Skip syncing if this is the only storage server holding this file
-			if len(discovered.StorageInfo) < 1 {
-				fmt.Println("Not enough storage servers for", filePath)
-				continue
-			}
-
-			fmt.Println("Syncing file", filePath)
-
-			addr := discovered.StorageInfo[0].PublicAddress
-			storageClient := server.GetStorageClient(addr)
-
-			offset := int64(0)
-			for true {
-				read, err := storageClient.ReadFile(context.Background(), &pb.ReadFileArgs{
-					Path:   filePath,
-					Offset: offset,
-					Count:  4096,
-				})
-				if err != nil {
-					println("Error read during sync:", err.Error())
-					break
-				}
-
-				fmt.Println("Writing", read.Buffer)
-
-				fd, _ := os.OpenFile(StoragePath+filePath, os.O_WRONLY, os.ModePerm)
-				fd.WriteAt(read.Buffer, offset)
-				offset += int64(read.Count)
-				fd.Close()
-
-				if read.Count == 0 {
-					break
-				}
-			}
-		}
+// Advances the version of a secondary replica only if no earlier mutation was missed by it.
+// Otherwise the replica stays behind and the naming server reconciles it.
+func AdvanceFileVersion(path string, version uint64) {
+	if GetFileVersion(path)+1 == version {
+		_ = SetFileVersion(path, version)
 	}
 }
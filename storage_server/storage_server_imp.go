@@ -4,8 +4,8 @@ import (
 	"context"
 	"io"
 	"os"
-	utils "project-dfs"
 	"project-dfs/pb"
+	"strconv"
 	"syscall"
 )
 
@@ -13,6 +13,11 @@ const (
 	StoragePath = "storage"
 )
 
+// Replicas are kept under the IDs of their files, so renames and links in the index never involve storage servers
+func BlobPath(fileId uint64) string {
+	return StoragePath + "/" + strconv.FormatUint(fileId, 10)
+}
+
 type StorageServiceController struct {
 	pb.UnimplementedStorageServer
 	Server *StorageServer
@@ -33,6 +38,14 @@ func getFreeSpace() int64 {
 	return int64(stat.Bavail * uint64(stat.Bsize))
 }
 
+// Returns the size of the file system the files are stored in
+func getCapacity() int64 {
+	var stat syscall.Statfs_t
+	wd, _ := os.Getwd()
+	_ = syscall.Statfs(wd, &stat)
+	return int64(stat.Blocks * uint64(stat.Bsize))
+}
+
 func (ctlr *StorageServiceController) Initialize(ctx context.Context, args *pb.InitializeArgs) (*pb.InitializeResult, error) {
 	/* Initialize the client storage on a new system,
 	remove any existing file in the dfs root directory and return available size.*/
@@ -49,20 +62,29 @@ func (ctlr *StorageServiceController) Initialize(ctx context.Context, args *pb.I
 
 func (ctlr *StorageServiceController) CreateFile(ctx context.Context, args *pb.CreateFileArgs) (*pb.CreateFileResult, error) {
 	// create a new empty file
-	path := StoragePath + args.Path
-	exists, directoryPath := utils.DoesDirectoryExist(path)
+	path := BlobPath(args.FileId)
+	errDir := os.MkdirAll(StoragePath, 0777)
+	if errDir != nil {
+		return &pb.CreateFileResult{ErrorStatus: &pb.ErrorStatus{
+			Code:        1,
+			Description: errDir.Error(),
+		}}, nil
+	}
 
-	if !exists {
-		errDir := os.MkdirAll(directoryPath, 0777)
-		if errDir != nil {
-			return &pb.CreateFileResult{ErrorStatus: &pb.ErrorStatus{
-				Code:        1,
-				Description: errDir.Error(),
-			}}, nil
-		}
+	// A blob left under the ID is replaced instead of truncated in place, so that the file starts at version 0
+	// without the saved versions of the old one, and a blob preserved for a snapshot keeps its contents
+	err := os.Remove(path)
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	if err == nil {
+		err = os.RemoveAll(VersionsDirectory(args.FileId))
 	}
 
-	_, err := os.Create(path)
+	var fd *os.File
+	if err == nil {
+		fd, err = os.Create(path)
+	}
 	if err != nil {
 		return &pb.CreateFileResult{ErrorStatus: &pb.ErrorStatus{
 			Code:        1,
@@ -70,6 +92,8 @@ func (ctlr *StorageServiceController) CreateFile(ctx context.Context, args *pb.C
 		}}, nil
 	}
 
+	fd.Close()
+
 	return &pb.CreateFileResult{ErrorStatus: &pb.ErrorStatus{
 		Code:        0,
 		Description: "OK",
@@ -79,7 +103,10 @@ func (ctlr *StorageServiceController) CreateFile(ctx context.Context, args *pb.C
 func (ctlr *StorageServiceController) ReadFile(ctx context.Context, args *pb.ReadFileArgs) (response *pb.ReadFileResult, err error) {
 	// download a file from the DFS to the Client side
 
-	path := StoragePath + args.Path
+	path := BlobPath(args.FileId)
+	if args.AtVersion != 0 {
+		path = VersionPath(args.FileId, args.AtVersion)
+	}
 	fd, err := os.OpenFile(path, os.O_RDONLY, os.ModePerm)
 	if err != nil {
 		return &pb.ReadFileResult{ErrorStatus: &pb.ErrorStatus{
@@ -90,6 +117,7 @@ func (ctlr *StorageServiceController) ReadFile(ctx context.Context, args *pb.Rea
 			Count:  0}, nil
 	}
 
+	version := GetFileVersion(path)
 	buf := make([]byte, args.Count)
 	n, err := fd.ReadAt(buf, args.Offset)
 	if n <= 0 {
@@ -98,8 +126,9 @@ func (ctlr *StorageServiceController) ReadFile(ctx context.Context, args *pb.Rea
 				Code:        0,
 				Description: err.Error(),
 			},
-			Buffer: make([]byte, 0),
-			Count:  0,
+			Buffer:  make([]byte, 0),
+			Count:   0,
+			Version: version,
 		}, nil
 	}
 
@@ -109,15 +138,52 @@ func (ctlr *StorageServiceController) ReadFile(ctx context.Context, args *pb.Rea
 			Code:        0,
 			Description: "OK",
 		},
-		Buffer: buf[0:n],
-		Count:  int32(n),
+		Buffer:  buf[0:n],
+		Count:   int32(n),
+		Version: version,
 	}
 	return response, nil
 }
 
 func (ctlr *StorageServiceController) WriteFile(ctx context.Context, args *pb.WriteFileArgs) (*pb.WriteFileResult, error) {
 
-	path := StoragePath + args.Path
+	path := BlobPath(args.FileId)
+
+	committed := false
+	if !args.IsChainCall {
+		// mutations of the file are ordered by the primary replica
+		primary, status := ctlr.Server.PrimaryClient(ctx, args.FileId)
+		if status != nil {
+			return &pb.WriteFileResult{ErrorStatus: status}, nil
+		}
+		if primary != nil {
+			return primary.WriteFile(ctx, args)
+		}
+
+		mutex := ctlr.Server.FileMutex(args.FileId)
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		status = ctlr.Server.ReserveSpace(ctx, args.FileId, args.Offset+int64(len(args.Buffer)))
+		if status != nil {
+			return &pb.WriteFileResult{ErrorStatus: status}, nil
+		}
+		// a write that fails before it is committed gives back the space reserved for it
+		defer func() {
+			if !committed {
+				ctlr.Server.ReleaseSpace(args.FileId)
+			}
+		}()
+	}
+
+	err := BreakSharing(path)
+	if err != nil {
+		return &pb.WriteFileResult{ErrorStatus: &pb.ErrorStatus{
+			Code:        1,
+			Description: err.Error(),
+		}}, nil
+	}
+
 	fd, err := os.OpenFile(path, os.O_WRONLY, os.ModePerm)
 	if err != nil {
 		return &pb.WriteFileResult{ErrorStatus: &pb.ErrorStatus{
@@ -137,45 +203,289 @@ func (ctlr *StorageServiceController) WriteFile(ctx context.Context, args *pb.Wr
 
 	fd.Close()
 
+	if args.IsChainCall {
+		AdvanceFileVersion(path, args.Version)
+	} else {
+		version, status := ctlr.Server.CommitMutation(ctx, args.FileId)
+		if status != nil {
+			return &pb.WriteFileResult{ErrorStatus: status}, nil
+		}
+		committed = true
+
+		ctlr.Server.ForEachSecondary(ctx, args.FileId, func(client pb.StorageClient) error {
+			_, err := client.WriteFile(ctx, &pb.WriteFileArgs{
+				FileId:      args.FileId,
+				Offset:      args.Offset,
+				Buffer:      args.Buffer,
+				IsChainCall: true,
+				Version:     version,
+			})
+			return err
+		})
+	}
+
+	return &pb.WriteFileResult{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "OK",
+	}}, nil
+}
+
+func (ctlr *StorageServiceController) Append(ctx context.Context, args *pb.AppendArgs) (*pb.AppendResult, error) {
+	// append a record to the end of the file
+	// the primary replica picks the offset, so concurrent appends never overlap
+
+	primary, status := ctlr.Server.PrimaryClient(ctx, args.FileId)
+	if status != nil {
+		return &pb.AppendResult{ErrorStatus: status}, nil
+	}
+	if primary != nil {
+		return primary.Append(ctx, args)
+	}
+
+	mutex := ctlr.Server.FileMutex(args.FileId)
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	path := BlobPath(args.FileId)
+	err := BreakSharing(path)
+	if err != nil {
+		return &pb.AppendResult{ErrorStatus: &pb.ErrorStatus{
+			Code:        1,
+			Description: err.Error(),
+		}}, nil
+	}
+
+	fd, err := os.OpenFile(path, os.O_WRONLY, os.ModePerm)
+	if err != nil {
+		return &pb.AppendResult{ErrorStatus: &pb.ErrorStatus{
+			Code:        1,
+			Description: err.Error(),
+		}}, nil
+	}
+	defer fd.Close()
+
+	fileInfo, err := fd.Stat()
+	if err != nil {
+		return &pb.AppendResult{ErrorStatus: &pb.ErrorStatus{
+			Code:        1,
+			Description: err.Error(),
+		}}, nil
+	}
+	offset := fileInfo.Size()
+
+	committed := false
+	status = ctlr.Server.ReserveSpace(ctx, args.FileId, offset+int64(len(args.Buffer)))
+	if status != nil {
+		return &pb.AppendResult{ErrorStatus: status}, nil
+	}
+	// a write that fails before it is committed gives back the space reserved for it
+	defer func() {
+		if !committed {
+			ctlr.Server.ReleaseSpace(args.FileId)
+		}
+	}()
+
+	_, err = fd.WriteAt(args.Buffer, offset)
+	if err != nil {
+		return &pb.AppendResult{ErrorStatus: &pb.ErrorStatus{
+			Code:        1,
+			Description: err.Error(),
+		}}, nil
+	}
+
+	version, status := ctlr.Server.CommitMutation(ctx, args.FileId)
+	if status != nil {
+		return &pb.AppendResult{ErrorStatus: status}, nil
+	}
+	committed = true
+
+	// secondaries apply the record at the very same offset
+	ctlr.Server.ForEachSecondary(ctx, args.FileId, func(client pb.StorageClient) error {
+		_, err := client.WriteFile(ctx, &pb.WriteFileArgs{
+			FileId:      args.FileId,
+			Offset:      offset,
+			Buffer:      args.Buffer,
+			IsChainCall: true,
+			Version:     version,
+		})
+		return err
+	})
+
+	return &pb.AppendResult{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "OK",
+		},
+		Offset: offset,
+	}, nil
+}
+
+func (ctlr *StorageServiceController) Truncate(ctx context.Context, args *pb.TruncateArgs) (*pb.TruncateResult, error) {
+	// shrink or extend the file to the requested size
+
+	committed := false
 	if !args.IsChainCall {
-		response, err := ctlr.Server.GetNamingClient().Discover(ctx, &pb.DiscoverRequest{
-			Path: args.Path,
+		primary, status := ctlr.Server.PrimaryClient(ctx, args.FileId)
+		if status != nil {
+			return &pb.TruncateResult{ErrorStatus: status}, nil
+		}
+		if primary != nil {
+			return primary.Truncate(ctx, args)
+		}
+
+		mutex := ctlr.Server.FileMutex(args.FileId)
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		status = ctlr.Server.ReserveSpace(ctx, args.FileId, args.Size)
+		if status != nil {
+			return &pb.TruncateResult{ErrorStatus: status}, nil
+		}
+		// a write that fails before it is committed gives back the space reserved for it
+		defer func() {
+			if !committed {
+				ctlr.Server.ReleaseSpace(args.FileId)
+			}
+		}()
+	}
+
+	path := BlobPath(args.FileId)
+	err := BreakSharing(path)
+	if err == nil {
+		err = os.Truncate(path, args.Size)
+	}
+	if err != nil {
+		return &pb.TruncateResult{ErrorStatus: &pb.ErrorStatus{
+			Code:        1,
+			Description: err.Error(),
+		}}, nil
+	}
+
+	if args.IsChainCall {
+		AdvanceFileVersion(path, args.Version)
+	} else {
+		version, status := ctlr.Server.CommitMutation(ctx, args.FileId)
+		if status != nil {
+			return &pb.TruncateResult{ErrorStatus: status}, nil
+		}
+		committed = true
+
+		ctlr.Server.ForEachSecondary(ctx, args.FileId, func(client pb.StorageClient) error {
+			_, err := client.Truncate(ctx, &pb.TruncateArgs{
+				FileId:      args.FileId,
+				Size:        args.Size,
+				IsChainCall: true,
+				Version:     version,
+			})
+			return err
 		})
-		if err != nil {
-			println("Error while replicating write call:", err.Error())
-		} else {
-			for _, s := range response.StorageInfo {
-				if s.Alias == ctlr.Server.Alias {
-					continue
-				}
-				client := ctlr.Server.GetStorageClient(s.Address)
-				if client == nil {
-					println("aborting write replication")
-					break
-				}
-				client.WriteFile(ctx, &pb.WriteFileArgs{
-					Path:        args.Path,
-					Offset:      args.Offset,
-					Buffer:      args.Buffer,
-					IsChainCall: true,
-				})
+	}
+
+	return &pb.TruncateResult{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "OK",
+	}}, nil
+}
+
+// Linux fallocate(2) mode that allocates space without changing the file size
+const fallocKeepSize = 0x1
+
+func (ctlr *StorageServiceController) Allocate(ctx context.Context, args *pb.AllocateArgs) (*pb.AllocateResult, error) {
+	// preallocate space for the file
+
+	committed := false
+	if !args.IsChainCall {
+		primary, status := ctlr.Server.PrimaryClient(ctx, args.FileId)
+		if status != nil {
+			return &pb.AllocateResult{ErrorStatus: status}, nil
+		}
+		if primary != nil {
+			return primary.Allocate(ctx, args)
+		}
+
+		mutex := ctlr.Server.FileMutex(args.FileId)
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if !args.KeepSize {
+			status = ctlr.Server.ReserveSpace(ctx, args.FileId, args.Offset+args.Length)
+			if status != nil {
+				return &pb.AllocateResult{ErrorStatus: status}, nil
 			}
+			// a write that fails before it is committed gives back the space reserved for it
+			defer func() {
+				if !committed {
+					ctlr.Server.ReleaseSpace(args.FileId)
+				}
+			}()
 		}
 	}
 
-	return &pb.WriteFileResult{ErrorStatus: &pb.ErrorStatus{
+	path := BlobPath(args.FileId)
+	err := BreakSharing(path)
+	if err != nil {
+		return &pb.AllocateResult{ErrorStatus: &pb.ErrorStatus{
+			Code:        1,
+			Description: err.Error(),
+		}}, nil
+	}
+
+	fd, err := os.OpenFile(path, os.O_WRONLY, os.ModePerm)
+	if err != nil {
+		return &pb.AllocateResult{ErrorStatus: &pb.ErrorStatus{
+			Code:        1,
+			Description: err.Error(),
+		}}, nil
+	}
+
+	mode := uint32(0)
+	if args.KeepSize {
+		mode = fallocKeepSize
+	}
+	err = syscall.Fallocate(int(fd.Fd()), mode, args.Offset, args.Length)
+	fd.Close()
+	if err != nil {
+		return &pb.AllocateResult{ErrorStatus: &pb.ErrorStatus{
+			Code:        1,
+			Description: err.Error(),
+		}}, nil
+	}
+
+	if args.IsChainCall {
+		AdvanceFileVersion(path, args.Version)
+	} else {
+		version, status := ctlr.Server.CommitMutation(ctx, args.FileId)
+		if status != nil {
+			return &pb.AllocateResult{ErrorStatus: status}, nil
+		}
+		committed = true
+
+		ctlr.Server.ForEachSecondary(ctx, args.FileId, func(client pb.StorageClient) error {
+			_, err := client.Allocate(ctx, &pb.AllocateArgs{
+				FileId:      args.FileId,
+				Offset:      args.Offset,
+				Length:      args.Length,
+				KeepSize:    args.KeepSize,
+				IsChainCall: true,
+				Version:     version,
+			})
+			return err
+		})
+	}
+
+	return &pb.AllocateResult{ErrorStatus: &pb.ErrorStatus{
 		Code:        0,
 		Description: "OK",
 	}}, nil
 }
 
 func (ctlr *StorageServiceController) Remove(ctx context.Context, args *pb.RemoveArgs) (*pb.RemoveResult, error) {
-	// allow to delete any file from DFS
-	// allow to delete directory.
-	// If the directory contains files the system asks for confirmation
+	// delete the replica of the file along with its saved versions
 
-	path := StoragePath + args.Path
-	err := os.RemoveAll(path)
+	err := os.RemoveAll(BlobPath(args.FileId))
+	if err == nil {
+		err = os.RemoveAll(VersionsDirectory(args.FileId))
+	}
 	if err != nil {
 		return &pb.RemoveResult{ErrorStatus: &pb.ErrorStatus{
 			Code:        1,
@@ -192,7 +502,7 @@ func (ctlr *StorageServiceController) Remove(ctx context.Context, args *pb.Remov
 func (ctlr *StorageServiceController) GetFileInfo(ctx context.Context, args *pb.GetFileInfoArgs) (*pb.GetFileInfoResult, error) {
 	// provide information about the file (any useful information - size, node id, etc.)
 
-	path := StoragePath + args.Path
+	path := BlobPath(args.FileId)
 	fileInfo, err := os.Lstat(path)
 	if err != nil {
 		return &pb.GetFileInfoResult{ErrorStatus: &pb.ErrorStatus{
@@ -206,35 +516,21 @@ func (ctlr *StorageServiceController) GetFileInfo(ctx context.Context, args *pb.
 		Code:        0,
 		Description: "OK",
 	},
-		FileSize: uint64(fileInfo.Size())}, nil
+		FileSize: uint64(fileInfo.Size()),
+		Version:  GetFileVersion(path)}, nil
 }
 
 func (ctlr *StorageServiceController) Copy(ctx context.Context, args *pb.CopyArgs) (*pb.CopyResult, error) {
 
-	path := StoragePath + args.Path
-	newPath := StoragePath + args.NewPath
-	exists, directoryPath := utils.DoesDirectoryExist(path)
-	existsNew, directoryNewPath := utils.DoesDirectoryExist(newPath)
+	path := BlobPath(args.FileId)
+	newPath := BlobPath(args.NewFileId)
 
-	if !exists {
-		errDir := os.MkdirAll(directoryPath, 0777)
-		if errDir != nil {
-			return &pb.CopyResult{ErrorStatus: &pb.ErrorStatus{
-				Code:        1,
-				Description: errDir.Error(),
-			}}, nil
-		}
-	}
-
-	if !existsNew {
-		errDir := os.MkdirAll(directoryNewPath, 0777)
-		if errDir != nil {
-			return &pb.CopyResult{ErrorStatus: &pb.ErrorStatus{
-				Code:        1,
-				Description: errDir.Error(),
-			}}, nil
-		}
-	}
+	// writes of the primary wait for the copy; the version is taken first,
+	// so a copy including a write of a secondary at most looks stale and gets reconciled
+	mutex := ctlr.Server.FileMutex(args.FileId)
+	mutex.Lock()
+	defer mutex.Unlock()
+	version := GetFileVersion(path)
 
 	src, err := os.Open(path)
 	if err != nil {
@@ -243,6 +539,7 @@ func (ctlr *StorageServiceController) Copy(ctx context.Context, args *pb.CopyArg
 			Description: err.Error(),
 		}}, nil
 	}
+	defer src.Close()
 
 	dest, err := os.Create(newPath)
 	if err != nil {
@@ -252,7 +549,12 @@ func (ctlr *StorageServiceController) Copy(ctx context.Context, args *pb.CopyArg
 		}}, nil
 	}
 
-	_, err = io.Copy(dest, src)
+	size, err := io.Copy(dest, src)
+	dest.Close()
+	if err == nil {
+		// the copy starts at the version of the source, so that stale replicas of it get reconciled
+		err = SetFileVersion(newPath, version)
+	}
 	if err != nil {
 		return &pb.CopyResult{ErrorStatus: &pb.ErrorStatus{
 			Code:        1,
@@ -260,56 +562,261 @@ func (ctlr *StorageServiceController) Copy(ctx context.Context, args *pb.CopyArg
 		}}, nil
 	}
 
-	return &pb.CopyResult{ErrorStatus: &pb.ErrorStatus{
-		Code:        0,
-		Description: "OK",
-	}}, nil
+	return &pb.CopyResult{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "OK",
+		},
+		File: &pb.StoredFile{FileId: args.NewFileId, Version: version, Size: size},
+	}, nil
 }
 
-func (ctlr *StorageServiceController) Move(ctx context.Context, args *pb.MoveArgs) (*pb.MoveResult, error) {
+func (ctlr *StorageServiceController) FetchFile(ctx context.Context, args *pb.FetchFileArgs) (*pb.FetchFileResult, error) {
+	// replace the local replica with the copy held by another storage server
+	// saved versions of the file are fetched along with it
 
-	// update IndexTree: send request to naming server
-	// add a new service into naming_server_imp for handling such a request
+	err := ctlr.Server.FetchFromReplica(ctx, args.SourceAddress, args.FileId)
+	if err != nil {
+		return &pb.FetchFileResult{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(syscall.EIO),
+			Description: err.Error(),
+		}}, nil
+	}
+	if GetFileVersion(BlobPath(args.FileId)) < args.Version {
+		return &pb.FetchFileResult{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(syscall.EAGAIN),
+			Description: "Source replica is behind the requested version",
+		}}, nil
+	}
+	for _, version := range args.SavedVersions {
+		err = ctlr.Server.FetchVersion(ctx, args.SourceAddress, args.FileId, version)
+		if err != nil {
+			return &pb.FetchFileResult{ErrorStatus: &pb.ErrorStatus{
+				Code:        uint32(syscall.EIO),
+				Description: err.Error(),
+			}}, nil
+		}
+	}
 
-	path := StoragePath + args.Path
-	newPath := StoragePath + args.NewPath
-	exists, directoryPath := utils.DoesDirectoryExist(path)
-	existsNew, directoryNewPath := utils.DoesDirectoryExist(newPath)
+	return &pb.FetchFileResult{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "OK",
+	}}, nil
+}
 
-	if !exists {
-		errDir := os.MkdirAll(directoryPath, 0777)
-		if errDir != nil {
-			return &pb.MoveResult{ErrorStatus: &pb.ErrorStatus{
+func (ctlr *StorageServiceController) CreateSnapshot(ctx context.Context, args *pb.SnapshotArgs) (*pb.SnapshotResult, error) {
+	// preserve the replicas for the snapshot
+	// a mutation in progress on the primary is either preserved as a whole or not at all
+
+	var files []*pb.StoredFile
+	for _, file := range args.Files {
+		mutex := ctlr.Server.FileMutex(file.FileId)
+		mutex.Lock()
+		path := BlobPath(file.FileId)
+		err := PreserveFile(path, BlobPath(file.SnapshotFileId))
+		var fileInfo os.FileInfo
+		if err == nil {
+			fileInfo, err = os.Lstat(path)
+		}
+		if err == nil {
+			files = append(files, &pb.StoredFile{
+				FileId:  file.SnapshotFileId,
+				Version: GetFileVersion(path),
+				Size:    fileInfo.Size(),
+			})
+		}
+		mutex.Unlock()
+		if err != nil {
+			return &pb.SnapshotResult{ErrorStatus: &pb.ErrorStatus{
 				Code:        1,
-				Description: errDir.Error(),
+				Description: err.Error(),
 			}}, nil
 		}
 	}
 
-	if !existsNew {
-		errDir := os.MkdirAll(directoryNewPath, 0777)
-		if errDir != nil {
-			return &pb.MoveResult{ErrorStatus: &pb.ErrorStatus{
-				Code:        1,
-				Description: errDir.Error(),
-			}}, nil
+	return &pb.SnapshotResult{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "OK",
+		},
+		Files: files,
+	}, nil
+}
+
+func (ctlr *StorageServiceController) SaveVersion(ctx context.Context, args *pb.VersionArgs) (*pb.VersionResult, error) {
+	// preserve the current contents of the replica as a saved version
+
+	err := SaveVersion(args.FileId, args.Version)
+	if err == syscall.ESTALE {
+		return &pb.VersionResult{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(syscall.ESTALE),
+			Description: "Replica is at another version",
+		}}, nil
+	}
+	if err != nil {
+		return &pb.VersionResult{ErrorStatus: &pb.ErrorStatus{
+			Code:        1,
+			Description: err.Error(),
+		}}, nil
+	}
+
+	return &pb.VersionResult{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "OK",
+	}}, nil
+}
+
+func (ctlr *StorageServiceController) DeleteVersion(ctx context.Context, args *pb.VersionArgs) (*pb.VersionResult, error) {
+	// drop the saved version of the replica
+
+	err := DeleteVersion(args.FileId, args.Version)
+	if err != nil {
+		return &pb.VersionResult{ErrorStatus: &pb.ErrorStatus{
+			Code:        1,
+			Description: err.Error(),
+		}}, nil
+	}
+
+	return &pb.VersionResult{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "OK",
+	}}, nil
+}
+
+func (ctlr *StorageServiceController) RestoreVersion(ctx context.Context, args *pb.RestoreVersionArgs) (*pb.VersionResult, error) {
+	// replace contents of the file with the saved version; the restore is committed as a new version
+
+	if !args.IsChainCall {
+		primary, status := ctlr.Server.PrimaryClient(ctx, args.FileId)
+		if status != nil {
+			return &pb.VersionResult{ErrorStatus: status}, nil
 		}
+		if primary != nil {
+			return primary.RestoreVersion(ctx, args)
+		}
+
+		mutex := ctlr.Server.FileMutex(args.FileId)
+		mutex.Lock()
+		defer mutex.Unlock()
 	}
 
-	err := os.Rename(path, newPath)
+	err := RestoreVersion(args.FileId, args.Version)
 	if err != nil {
-		return &pb.MoveResult{ErrorStatus: &pb.ErrorStatus{
+		return &pb.VersionResult{ErrorStatus: &pb.ErrorStatus{
 			Code:        1,
 			Description: err.Error(),
 		}}, nil
 	}
 
-	return &pb.MoveResult{ErrorStatus: &pb.ErrorStatus{
+	if args.IsChainCall {
+		AdvanceFileVersion(BlobPath(args.FileId), args.NewVersion)
+	} else {
+		version, status := ctlr.Server.CommitMutation(ctx, args.FileId)
+		if status != nil {
+			return &pb.VersionResult{ErrorStatus: status}, nil
+		}
+
+		ctlr.Server.ForEachSecondary(ctx, args.FileId, func(client pb.StorageClient) error {
+			_, err := client.RestoreVersion(ctx, &pb.RestoreVersionArgs{
+				FileId:      args.FileId,
+				Version:     args.Version,
+				IsChainCall: true,
+				NewVersion:  version,
+			})
+			return err
+		})
+	}
+
+	return &pb.VersionResult{ErrorStatus: &pb.ErrorStatus{
 		Code:        0,
 		Description: "OK",
 	}}, nil
 }
 
+func (ctlr *StorageServiceController) GetInventory(ctx context.Context, args *pb.InventoryArgs) (*pb.InventoryResult, error) {
+	// list the replicas held by the server, so that the naming server can compare them with the index
+
+	files, err := Inventory()
+	if err != nil {
+		return &pb.InventoryResult{ErrorStatus: &pb.ErrorStatus{
+			Code:        1,
+			Description: err.Error(),
+		}}, nil
+	}
+
+	return &pb.InventoryResult{ErrorStatus: &pb.ErrorStatus{
+		Code:        0,
+		Description: "OK",
+	},
+		Files: files}, nil
+}
+
+func (ctlr *StorageServiceController) ReadDelta(args *pb.ReadDeltaArgs, stream pb.Storage_ReadDeltaServer) error {
+	// send the contents of the file as data and blocks of the replica held by the receiver
+	// the receiver resumes an interrupted transfer only if the file has not changed since
+
+	path := BlobPath(args.FileId)
+	if args.SavedVersion != 0 {
+		path = VersionPath(args.FileId, args.SavedVersion)
+	}
+	fd, err := os.Open(path)
+	if err != nil {
+		return stream.Send(&pb.DeltaChunk{ErrorStatus: &pb.ErrorStatus{
+			Code:        1,
+			Description: err.Error(),
+		}})
+	}
+	defer fd.Close()
+
+	version := GetFileVersion(path)
+	fileInfo, err := fd.Stat()
+	if err != nil {
+		return stream.Send(&pb.DeltaChunk{ErrorStatus: &pb.ErrorStatus{
+			Code:        1,
+			Description: err.Error(),
+		}})
+	}
+	if args.Offset > 0 && (args.Version != version || args.Offset > fileInfo.Size()) {
+		return stream.Send(&pb.DeltaChunk{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(syscall.ESTALE),
+			Description: "File changed since the transfer was interrupted",
+		}})
+	}
+
+	err = stream.Send(&pb.DeltaChunk{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "OK",
+		},
+		Version: version,
+	})
+	if err != nil {
+		return err
+	}
+
+	checksum, err := SendDelta(fd, args.Offset, args.BlockSize, args.Blocks, stream.Send)
+	if err != nil {
+		return stream.Send(&pb.DeltaChunk{ErrorStatus: &pb.ErrorStatus{
+			Code:        1,
+			Description: err.Error(),
+		}})
+	}
+	if GetFileVersion(path) != version {
+		return stream.Send(&pb.DeltaChunk{ErrorStatus: &pb.ErrorStatus{
+			Code:        uint32(syscall.EAGAIN),
+			Description: "File changed during the transfer",
+		}})
+	}
+
+	return stream.Send(&pb.DeltaChunk{
+		ErrorStatus: &pb.ErrorStatus{
+			Code:        0,
+			Description: "OK",
+		},
+		Checksum: checksum,
+	})
+}
+
 //func (ctlr *StorageServiceController) ReadDirectory(ctx context.Context, args *pb.ReadDirectoryArgs) (*pb.ReadDirectoryResult, error) {
 //	// return list of files, which are stored in the directory
 //
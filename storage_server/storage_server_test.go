@@ -0,0 +1,70 @@
+package storage_server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"project-dfs/pb"
+	"testing"
+)
+
+// Runs the test in an empty working directory, where replicas are kept
+func inTempDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dfs-storage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+		_ = os.RemoveAll(dir)
+	})
+	err = os.MkdirAll(StoragePath, 0777)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Writes the replica at the version
+func writeReplica(t *testing.T, path string, data []byte, version uint64) {
+	err := ioutil.WriteFile(path, data, 0666)
+	if err == nil {
+		err = SetFileVersion(path, version)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateFileDropsLeftoverBlob(t *testing.T) {
+	inTempDir(t)
+	writeReplica(t, BlobPath(7), []byte("old contents"), 12)
+	err := SaveVersion(7, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctlr := &StorageServiceController{}
+	result, _ := ctlr.CreateFile(context.Background(), &pb.CreateFileArgs{FileId: 7})
+	if result.ErrorStatus.Code != 0 {
+		t.Fatal(result.ErrorStatus.Description)
+	}
+
+	fileInfo, err := os.Stat(BlobPath(7))
+	if err != nil || fileInfo.Size() != 0 {
+		t.Fatalf("created file = %v, %v; want empty file", fileInfo, err)
+	}
+	if version := GetFileVersion(BlobPath(7)); version != 0 {
+		t.Fatalf("created file is at version %d; want 0", version)
+	}
+	if _, err = os.Stat(VersionsDirectory(7)); !os.IsNotExist(err) {
+		t.Fatalf("saved versions of the old file are kept: %v", err)
+	}
+}
@@ -0,0 +1,414 @@
+package storage_server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"project-dfs/pb"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Replicas are fetched into <fileId>.fetch next to the replica and then put in its place.
+// Progress of the fetches is saved in the checkpoint, so that an interrupted fetch resumes
+// where it stopped instead of starting over.
+const (
+	SyncCheckpointPath = StoragePath + "/sync.checkpoint"
+	checkpointInterval = 64 << 20 // bytes fetched between checkpoints of a replica
+)
+
+// Part of the replica fetched at the version
+type fetchProgress struct {
+	Version uint64 `json:"version"`
+	Offset  int64  `json:"offset"`
+}
+
+// Limits the rate of data received by fetches, shared by all of them
+type throttle struct {
+	mutex sync.Mutex
+	rate  int64     // bytes per second, no limit if zero
+	until time.Time // time the data received so far is paid off
+}
+
+// Waits until receiving n bytes fits into the rate
+func (t *throttle) wait(ctx context.Context, n int) error {
+	if t.rate == 0 || n == 0 {
+		return nil
+	}
+
+	t.mutex.Lock()
+	now := time.Now()
+	if t.until.Before(now) {
+		t.until = now
+	}
+	t.until = t.until.Add(time.Duration(int64(n) * int64(time.Second) / t.rate))
+	delay := t.until.Sub(now)
+	t.mutex.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func loadCheckpoint() map[uint64]*fetchProgress {
+	checkpoint := make(map[uint64]*fetchProgress)
+	data, err := ioutil.ReadFile(SyncCheckpointPath)
+	if err != nil {
+		return checkpoint
+	}
+	err = json.Unmarshal(data, &checkpoint)
+	if err != nil {
+		println("Error loading sync checkpoint; fetches start over:", err.Error())
+		return make(map[uint64]*fetchProgress)
+	}
+	return checkpoint
+}
+
+// Records the progress of the fetch of the replica, or removes it if nil
+func (server *StorageServer) saveProgress(fileId uint64, progress *fetchProgress) {
+	server.checkpointMutex.Lock()
+	defer server.checkpointMutex.Unlock()
+
+	if progress == nil {
+		if _, ok := server.checkpoint[fileId]; !ok {
+			return
+		}
+		delete(server.checkpoint, fileId)
+	} else {
+		server.checkpoint[fileId] = progress
+	}
+
+	// the checkpoint is replaced at once, so a crash never leaves it half-written
+	data, err := json.Marshal(server.checkpoint)
+	if err == nil {
+		err = ioutil.WriteFile(SyncCheckpointPath+".tmp", data, 0666)
+	}
+	if err == nil {
+		err = os.Rename(SyncCheckpointPath+".tmp", SyncCheckpointPath)
+	}
+	if err != nil {
+		println("Error saving sync checkpoint:", err.Error())
+	}
+}
+
+// Returns the progress of the fetch of the replica, or nil if it starts over
+func (server *StorageServer) savedProgress(fileId uint64) *fetchProgress {
+	server.checkpointMutex.Lock()
+	progress, ok := server.checkpoint[fileId]
+	server.checkpointMutex.Unlock()
+	if !ok {
+		return nil
+	}
+
+	// the fetched part is synced to disk before it is recorded, but the file may have been removed since
+	fileInfo, err := os.Stat(BlobPath(fileId) + ".fetch")
+	if err != nil || fileInfo.Size() < progress.Offset {
+		return nil
+	}
+	return &fetchProgress{Version: progress.Version, Offset: progress.Offset}
+}
+
+// Discards partially fetched replicas other than the ones about to be fetched
+func (server *StorageServer) DiscardFetches(replicas []*pb.AssignedReplica) {
+	keep := make(map[uint64]bool, len(replicas))
+	for _, replica := range replicas {
+		keep[replica.FileId] = true
+	}
+
+	server.checkpointMutex.Lock()
+	var discarded []uint64
+	for fileId := range server.checkpoint {
+		if !keep[fileId] {
+			discarded = append(discarded, fileId)
+		}
+	}
+	server.checkpointMutex.Unlock()
+
+	for _, fileId := range discarded {
+		_ = os.Remove(BlobPath(fileId) + ".fetch")
+		server.saveProgress(fileId, nil)
+	}
+}
+
+// Returns the mutex that keeps the replica from being fetched twice at once
+func (server *StorageServer) fetchMutex(fileId uint64) *sync.Mutex {
+	server.fetchMutexesMutex.Lock()
+	defer server.fetchMutexesMutex.Unlock()
+
+	mutex, ok := server.fetchMutexes[fileId]
+	if !ok {
+		mutex = &sync.Mutex{}
+		server.fetchMutexes[fileId] = mutex
+	}
+	return mutex
+}
+
+// Fetches the replicas the naming server found missing or stale, several at once.
+// Every replica is tried from its sources in turn.
+func (server *StorageServer) FetchReplicas(replicas []*pb.AssignedReplica) {
+	queue := make(chan *pb.AssignedReplica)
+	wg := sync.WaitGroup{}
+	for i := 0; i < server.SyncWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for replica := range queue {
+				server.fetchAssigned(replica)
+			}
+		}()
+	}
+
+	for _, replica := range replicas {
+		queue <- replica
+	}
+	close(queue)
+	wg.Wait()
+}
+
+func (server *StorageServer) fetchAssigned(replica *pb.AssignedReplica) {
+	err := errors.New("no source to fetch from")
+	for _, source := range replica.Sources {
+		err = server.FetchFromReplica(context.Background(), source.Address, replica.FileId)
+		if err == nil && GetFileVersion(BlobPath(replica.FileId)) < replica.Version {
+			err = errors.New("replica on " + source.Alias + " is stale")
+		}
+		if err == nil {
+			return
+		}
+	}
+	// the naming server reconciles the replica later
+	println("Error fetching file", replica.FileId, "during sync:", err.Error())
+}
+
+// Transfer is rejected by the source, and fetching the replica has to start over
+var errStartOver = errors.New("replica changed since the fetch was interrupted")
+
+// Downloads the file from another storage server, replacing the local copy along with its version.
+// Only the parts of the file the local copy lacks are transferred.
+func (server *StorageServer) FetchFromReplica(ctx context.Context, address string, fileId uint64) error {
+	storageClient := server.GetStorageClient(address)
+	if storageClient == nil {
+		return errors.New("no connection to storage server " + address)
+	}
+
+	mutex := server.fetchMutex(fileId)
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	err := os.MkdirAll(StoragePath, 0777)
+	if err != nil {
+		return err
+	}
+
+	err = server.fetchDelta(ctx, storageClient, fileId, server.savedProgress(fileId))
+	if err == errStartOver {
+		server.saveProgress(fileId, nil)
+		err = server.fetchDelta(ctx, storageClient, fileId, nil)
+	}
+	return err
+}
+
+// Fetches the replica into the fetch file, resuming at the progress if any
+func (server *StorageServer) fetchDelta(ctx context.Context, storageClient pb.StorageClient, fileId uint64, progress *fetchProgress) error {
+	path := BlobPath(fileId)
+	fetchPath := path + ".fetch"
+
+	// The local copy is preserved for the fetch, so writes applied to it meanwhile
+	// never change the blocks the file is built from
+	basisPath := path + ".basis"
+	_ = os.Remove(basisPath)
+	var basis *os.File
+	var blockSize int64
+	var blocks []*pb.BlockChecksum
+	err := PreserveFile(path, basisPath)
+	if err == nil {
+		defer os.Remove(basisPath)
+		blockSize, blocks, err = blockChecksums(basisPath)
+		if err == nil {
+			basis, err = os.Open(basisPath)
+		}
+		if err != nil {
+			return err
+		}
+		defer basis.Close()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if progress != nil {
+		println("Resuming fetch of file", fileId, "at offset", progress.Offset)
+		flags = os.O_WRONLY
+	} else {
+		progress = &fetchProgress{}
+	}
+	fd, err := os.OpenFile(fetchPath, flags, 0666)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	err = fd.Truncate(progress.Offset)
+	if err == nil {
+		_, err = fd.Seek(progress.Offset, io.SeekStart)
+	}
+	if err != nil {
+		return err
+	}
+
+	stream, err := storageClient.ReadDelta(ctx, &pb.ReadDeltaArgs{
+		FileId:    fileId,
+		Offset:    progress.Offset,
+		Version:   progress.Version,
+		BlockSize: blockSize,
+		Blocks:    blocks,
+	})
+	if err != nil {
+		return err
+	}
+	header, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if header.ErrorStatus.Code == uint32(syscall.ESTALE) && progress.Offset > 0 {
+		return errStartOver
+	}
+	if header.ErrorStatus.Code != 0 {
+		return errors.New(header.ErrorStatus.Description)
+	}
+	version := header.Version
+
+	writer := newDeltaWriter(fd, basis, blockSize)
+	checkpointed := int64(0)
+	// Saves the progress once what is fetched so far is on disk
+	checkpoint := func() {
+		if writer.written == checkpointed {
+			return
+		}
+		if fd.Sync() == nil {
+			checkpointed = writer.written
+			server.saveProgress(fileId, &fetchProgress{Version: version, Offset: progress.Offset + writer.written})
+		}
+	}
+
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			err = errors.New("transfer of file ended early")
+		}
+		if err == nil && chunk.ErrorStatus != nil && chunk.ErrorStatus.Code != 0 {
+			// the contents received do not belong to a single version
+			server.saveProgress(fileId, nil)
+			return errors.New(chunk.ErrorStatus.Description)
+		}
+		if err == nil {
+			err = writer.apply(chunk)
+		}
+		if err == nil {
+			err = server.syncThrottle.wait(ctx, len(chunk.Data))
+		}
+		if err != nil {
+			checkpoint()
+			return err
+		}
+
+		if len(chunk.Checksum) > 0 {
+			if !bytes.Equal(chunk.Checksum, writer.checksum()) {
+				server.saveProgress(fileId, nil)
+				return errors.New("checksum of fetched file does not match")
+			}
+			break
+		}
+		if writer.written-checkpointed >= checkpointInterval {
+			checkpoint()
+		}
+	}
+
+	err = SetFileVersion(fetchPath, version)
+	if err == nil {
+		err = os.Rename(fetchPath, path)
+	}
+	if err != nil {
+		return err
+	}
+	server.saveProgress(fileId, nil)
+	return nil
+}
+
+// Downloads the saved version of the file from another storage server.
+// Saved versions never change, so the version is transferred whole and kept if it is already held.
+func (server *StorageServer) FetchVersion(ctx context.Context, address string, fileId uint64, version uint64) error {
+	versionPath := VersionPath(fileId, version)
+	if _, err := os.Lstat(versionPath); err == nil {
+		return nil
+	}
+	storageClient := server.GetStorageClient(address)
+	if storageClient == nil {
+		return errors.New("no connection to storage server " + address)
+	}
+
+	err := os.MkdirAll(VersionsDirectory(fileId), 0777)
+	if err != nil {
+		return err
+	}
+	fetchPath := versionPath + ".fetch"
+	fd, err := os.Create(fetchPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(fetchPath)
+	defer fd.Close()
+
+	stream, err := storageClient.ReadDelta(ctx, &pb.ReadDeltaArgs{FileId: fileId, SavedVersion: version})
+	if err != nil {
+		return err
+	}
+	header, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if header.ErrorStatus.Code != 0 {
+		return errors.New(header.ErrorStatus.Description)
+	}
+
+	writer := newDeltaWriter(fd, nil, 0)
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			err = errors.New("transfer of file ended early")
+		}
+		if err == nil && chunk.ErrorStatus != nil && chunk.ErrorStatus.Code != 0 {
+			err = errors.New(chunk.ErrorStatus.Description)
+		}
+		if err == nil {
+			err = writer.apply(chunk)
+		}
+		if err == nil {
+			err = server.syncThrottle.wait(ctx, len(chunk.Data))
+		}
+		if err != nil {
+			return err
+		}
+		if len(chunk.Checksum) > 0 {
+			if !bytes.Equal(chunk.Checksum, writer.checksum()) {
+				return errors.New("checksum of fetched version does not match")
+			}
+			break
+		}
+	}
+
+	err = SetFileVersion(fetchPath, header.Version)
+	if err == nil {
+		err = os.Rename(fetchPath, versionPath)
+	}
+	return err
+}
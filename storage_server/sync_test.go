@@ -0,0 +1,262 @@
+package storage_server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"project-dfs/pb"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Source replica served by ReadDelta of the controller in the same process. The source is kept under
+// an ID of its own, as both replicas are in the working directory of the test.
+type pipeSource struct {
+	pb.StorageClient
+	ctlr      *StorageServiceController
+	sourceId  uint64
+	failAfter int // messages received before the transfer breaks, never if zero
+
+	mutex    sync.Mutex
+	requests []*pb.ReadDeltaArgs
+	sent     int // bytes sent as data
+}
+
+func (source *pipeSource) ReadDelta(ctx context.Context, in *pb.ReadDeltaArgs, opts ...grpc.CallOption) (pb.Storage_ReadDeltaClient, error) {
+	source.mutex.Lock()
+	source.requests = append(source.requests, in)
+	source.mutex.Unlock()
+
+	pipe := &deltaPipe{ctx: ctx, chunks: make(chan *pb.DeltaChunk), done: make(chan struct{})}
+	args := &pb.ReadDeltaArgs{
+		FileId:       source.sourceId,
+		Offset:       in.Offset,
+		Version:      in.Version,
+		BlockSize:    in.BlockSize,
+		Blocks:       in.Blocks,
+		SavedVersion: in.SavedVersion,
+	}
+	go func() {
+		_ = source.ctlr.ReadDelta(args, &deltaPipeServer{pipe})
+		close(pipe.chunks)
+	}()
+	return &deltaPipeClient{pipe: pipe, source: source}, nil
+}
+
+type deltaPipe struct {
+	ctx    context.Context
+	chunks chan *pb.DeltaChunk
+	done   chan struct{} // closed once the receiver stops
+}
+
+type deltaPipeServer struct {
+	*deltaPipe
+}
+
+func (server *deltaPipeServer) Send(chunk *pb.DeltaChunk) error {
+	select {
+	case server.chunks <- chunk:
+		return nil
+	case <-server.done:
+		return io.ErrClosedPipe
+	}
+}
+
+func (server *deltaPipeServer) Context() context.Context     { return server.ctx }
+func (server *deltaPipeServer) SetHeader(metadata.MD) error  { return nil }
+func (server *deltaPipeServer) SendHeader(metadata.MD) error { return nil }
+func (server *deltaPipeServer) SetTrailer(metadata.MD)       {}
+func (server *deltaPipeServer) SendMsg(m interface{}) error  { return server.Send(m.(*pb.DeltaChunk)) }
+func (server *deltaPipeServer) RecvMsg(m interface{}) error  { return io.EOF }
+
+type deltaPipeClient struct {
+	grpc.ClientStream
+	pipe     *deltaPipe
+	source   *pipeSource
+	received int
+}
+
+func (client *deltaPipeClient) Recv() (*pb.DeltaChunk, error) {
+	if client.source.failAfter > 0 && client.received == client.source.failAfter {
+		close(client.pipe.done)
+		return nil, errors.New("connection reset")
+	}
+	chunk, ok := <-client.pipe.chunks
+	if !ok {
+		return nil, io.EOF
+	}
+	client.received++
+	client.source.mutex.Lock()
+	client.source.sent += len(chunk.Data)
+	client.source.mutex.Unlock()
+	return chunk, nil
+}
+
+const (
+	fetchedId    = 1
+	sourceId     = 2
+	sourceAddr   = "source:7001"
+	fetchedBytes = 5<<20 + 4321
+)
+
+// Returns a storage server fetching from the source replica
+func newFetchingServer(t *testing.T, failAfter int) (*StorageServer, *pipeSource) {
+	inTempDir(t)
+	source := &pipeSource{ctlr: &StorageServiceController{}, sourceId: sourceId, failAfter: failAfter}
+	server := &StorageServer{
+		storageClients: map[string]pb.StorageClient{sourceAddr: source},
+		syncThrottle:   &throttle{},
+		checkpoint:     loadCheckpoint(),
+		fetchMutexes:   map[uint64]*sync.Mutex{},
+	}
+	return server, source
+}
+
+func checkFetched(t *testing.T, server *StorageServer, data []byte, version uint64) {
+	fetched, err := ioutil.ReadFile(BlobPath(fetchedId))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fetched, data) {
+		t.Fatalf("fetched %d bytes differ from the %d bytes of the source", len(fetched), len(data))
+	}
+	if fetchedVersion := GetFileVersion(BlobPath(fetchedId)); fetchedVersion != version {
+		t.Fatalf("fetched replica is at version %d; want %d", fetchedVersion, version)
+	}
+	if _, err = os.Stat(BlobPath(fetchedId) + ".fetch"); !os.IsNotExist(err) {
+		t.Fatalf("fetch file is left behind: %v", err)
+	}
+	if progress := server.savedProgress(fetchedId); progress != nil {
+		t.Fatalf("progress %+v of a completed fetch is kept", progress)
+	}
+}
+
+func TestFetchResumesInterruptedTransfer(t *testing.T) {
+	// the header and two chunks of data get through before the transfer breaks
+	server, source := newFetchingServer(t, 3)
+	data := randomBytes(10, fetchedBytes)
+	writeReplica(t, BlobPath(sourceId), data, 4)
+
+	err := server.FetchFromReplica(context.Background(), sourceAddr, fetchedId)
+	if err == nil {
+		t.Fatal("interrupted fetch succeeded")
+	}
+	progress := server.savedProgress(fetchedId)
+	if progress == nil || progress.Offset != 2*deltaChunk || progress.Version != 4 {
+		t.Fatalf("progress of the interrupted fetch = %+v; want 2 chunks at version 4", progress)
+	}
+
+	// the checkpoint survives a restart of the fetching server
+	server.checkpoint = loadCheckpoint()
+	source.failAfter = 0
+	err = server.FetchFromReplica(context.Background(), sourceAddr, fetchedId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFetched(t, server, data, 4)
+	resumed := source.requests[len(source.requests)-1]
+	if len(source.requests) != 2 || resumed.Offset != progress.Offset || resumed.Version != 4 {
+		t.Fatalf("fetch resumed with %+v; want offset %d at version 4", resumed, progress.Offset)
+	}
+	if source.sent != fetchedBytes {
+		t.Fatalf("%d bytes sent for a file of %d", source.sent, fetchedBytes)
+	}
+}
+
+func TestFetchStartsOverWhenSourceChanged(t *testing.T) {
+	server, source := newFetchingServer(t, 3)
+	writeReplica(t, BlobPath(sourceId), randomBytes(11, fetchedBytes), 4)
+	err := server.FetchFromReplica(context.Background(), sourceAddr, fetchedId)
+	if err == nil {
+		t.Fatal("interrupted fetch succeeded")
+	}
+
+	data := randomBytes(12, fetchedBytes)
+	writeReplica(t, BlobPath(sourceId), data, 5)
+	source.failAfter = 0
+	err = server.FetchFromReplica(context.Background(), sourceAddr, fetchedId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFetched(t, server, data, 5)
+	if len(source.requests) != 3 || source.requests[1].Offset == 0 || source.requests[2].Offset != 0 {
+		t.Fatalf("requests %v; want a resumed one rejected and one starting over", source.requests)
+	}
+}
+
+func TestFetchReusesLocalBlocks(t *testing.T) {
+	server, source := newFetchingServer(t, 0)
+	data := randomBytes(13, fetchedBytes)
+	writeReplica(t, BlobPath(fetchedId), data, 6)
+	data = append(append(append([]byte{}, data[:1000]...), "inserted"...), data[1000:]...)
+	writeReplica(t, BlobPath(sourceId), data, 7)
+
+	err := server.FetchFromReplica(context.Background(), sourceAddr, fetchedId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFetched(t, server, data, 7)
+	if source.sent > 4*int(blockSizeFor(fetchedBytes)) {
+		t.Fatalf("%d bytes sent for a change of 8 bytes", source.sent)
+	}
+	if _, err = os.Stat(BlobPath(fetchedId) + ".basis"); !os.IsNotExist(err) {
+		t.Fatalf("basis is left behind: %v", err)
+	}
+}
+
+func TestFetchVersion(t *testing.T) {
+	server, _ := newFetchingServer(t, 0)
+	writeReplica(t, BlobPath(sourceId), []byte("saved contents"), 3)
+	err := SaveVersion(sourceId, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the saved version shares the blob until it is written
+	err = os.Remove(BlobPath(sourceId))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeReplica(t, BlobPath(sourceId), []byte("live contents"), 4)
+
+	err = server.FetchVersion(context.Background(), sourceAddr, fetchedId, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := ioutil.ReadFile(VersionPath(fetchedId, 3))
+	if err != nil || string(saved) != "saved contents" || GetFileVersion(VersionPath(fetchedId, 3)) != 3 {
+		t.Fatalf("fetched version = %q, %v; want saved contents at version 3", saved, err)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	unlimited := &throttle{}
+	start := time.Now()
+	if err := unlimited.wait(context.Background(), 1<<30); err != nil || time.Since(start) > 100*time.Millisecond {
+		t.Fatalf("unlimited throttle waited %v, %v", time.Since(start), err)
+	}
+
+	limited := &throttle{rate: 1 << 20}
+	start = time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limited.wait(context.Background(), 100<<10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 300 KiB at 1 MiB per second
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Fatalf("throttled data was received after %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limited.wait(ctx, 10<<20); err != context.Canceled {
+		t.Fatalf("wait with a canceled context = %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package storage_server
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// Saved versions of a file are kept under versions/<fileId>/<version>
+const VersionsPath = "versions"
+
+func VersionsDirectory(fileId uint64) string {
+	return VersionsPath + "/" + strconv.FormatUint(fileId, 10)
+}
+
+func VersionPath(fileId uint64, version uint64) string {
+	return VersionsDirectory(fileId) + "/" + strconv.FormatUint(version, 10)
+}
+
+// Preserves the current contents of the replica as the version.
+// Like snapshots, the saved version shares the inode with the replica until the replica is modified.
+func SaveVersion(fileId uint64, version uint64) error {
+	livePath := BlobPath(fileId)
+	if GetFileVersion(livePath) != version {
+		return syscall.ESTALE
+	}
+
+	versionPath := VersionPath(fileId, version)
+	err := os.MkdirAll(VersionsDirectory(fileId), 0777)
+	if err != nil {
+		return err
+	}
+	if _, err = os.Lstat(versionPath); err == nil {
+		// already saved
+		return nil
+	}
+	return PreserveFile(livePath, versionPath)
+}
+
+func DeleteVersion(fileId uint64, version uint64) error {
+	err := os.Remove(VersionPath(fileId, version))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Replaces contents of the replica with the saved version
+func RestoreVersion(fileId uint64, version uint64) error {
+	src, err := os.Open(VersionPath(fileId, version))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	livePath := BlobPath(fileId)
+	restorePath := livePath + ".restore"
+	dest, err := os.Create(restorePath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dest, src)
+	dest.Close()
+	if err == nil {
+		err = SetFileVersion(restorePath, GetFileVersion(livePath))
+	}
+	if err != nil {
+		_ = os.Remove(restorePath)
+		return err
+	}
+
+	// the preserved contents keep the replaced inode
+	err = os.Rename(restorePath, livePath)
+	if err != nil {
+		_ = os.Remove(restorePath)
+	}
+	return err
+}
@@ -1,10 +1,31 @@
 package utils
 
 import (
+	"google.golang.org/grpc"
 	"os"
 	"strings"
 )
 
+// Limit of gRPC messages servers and clients exchange. Inventories of storage servers and the replicas
+// assigned to them are sent in single messages, which outgrow the default limit of 4 MB on large clusters.
+const MaxMessageSize = 256 << 20
+
+// Options of gRPC servers raising the limit of messages
+func ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(MaxMessageSize),
+		grpc.MaxSendMsgSize(MaxMessageSize),
+	}
+}
+
+// Options of gRPC connections raising the limit of messages
+func DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithInsecure(),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(MaxMessageSize), grpc.MaxCallSendMsgSize(MaxMessageSize)),
+	}
+}
+
 func DoesDirectoryExist(path string) (bool, string) {
 	lastIndexSlash := strings.LastIndex(path, "/")
 	directoryPath := path[:lastIndexSlash]